@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+type AttributeSchemaController struct{}
+
+var attributeSchemaModel = models.AttributeSchemaModel{}
+var attributeSchemaForm = forms.AttributeSchemaForm{}
+
+// parseAttributeResource reads the resource path parameter, the request is aborted with 404 when it is unknown
+func parseAttributeResource(c *gin.Context) (resource models.AttributeResource, ok bool) {
+	if !models.IsAttributeResource(c.Param("resource")) {
+		models.AbortWithError(c, http.StatusNotFound, "Attribute schemas are only supported for services and versions")
+		return "", false
+	}
+	return models.AttributeResource(c.Param("resource")), true
+}
+
+// GetAttributeSchema returns the attribute schema of a kind of resource of the organization
+// @Summary Get an attribute schema
+// @Description Get the JSON Schema the attributes of the services or versions of the organization are validated against
+// @Tags Organizations
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param resource path string true "Kind of resource" Enums(services, versions)
+// @Success 200 {object} models.AttributeSchema
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/attribute-schemas/{resource} [get]
+func (ctrl AttributeSchemaController) GetAttributeSchema(c *gin.Context) {
+	resource, ok := parseAttributeResource(c)
+	if !ok {
+		return
+	}
+
+	schema, isFound, err := attributeSchemaModel.One(c.Request.Context(), c.Param("orgId"), resource)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, fmt.Sprintf("The organization has no attribute schema for %s", resource))
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get attribute schema")
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// UpdateAttributeSchema registers the attribute schema of a kind of resource of the organization
+// @Summary Set an attribute schema
+// @Description Register the JSON Schema the attributes of the services or versions of the organization are validated against when they are
+// @Description created or updated, replacing the current one. Attributes stored before aren't revalidated. The schema can only reference its own definitions.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param resource path string true "Kind of resource" Enums(services, versions)
+// @Param schema body forms.UpdateAttributeSchemaForm true "Schema"
+// @Success 200 {object} models.AttributeSchema
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/attribute-schemas/{resource} [put]
+func (ctrl AttributeSchemaController) UpdateAttributeSchema(c *gin.Context) {
+	resource, ok := parseAttributeResource(c)
+	if !ok {
+		return
+	}
+
+	var form forms.UpdateAttributeSchemaForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := attributeSchemaForm.Update(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	schema, err := attributeSchemaModel.Set(c.Request.Context(), c.Param("orgId"), resource, form)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidAttributeSchema) {
+			models.AbortWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not update attribute schema")
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// DeleteAttributeSchema removes the attribute schema of a kind of resource of the organization
+// @Summary Delete an attribute schema
+// @Description Remove the attribute schema of the services or versions of the organization, their attributes aren't validated anymore
+// @Tags Organizations
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param resource path string true "Kind of resource" Enums(services, versions)
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/attribute-schemas/{resource} [delete]
+func (ctrl AttributeSchemaController) DeleteAttributeSchema(c *gin.Context) {
+	resource, ok := parseAttributeResource(c)
+	if !ok {
+		return
+	}
+
+	if err := attributeSchemaModel.Delete(c.Request.Context(), c.Param("orgId"), resource); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not delete attribute schema")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+)
+
+type DependencyController struct{}
+
+var dependencyModel = models.DependencyModel{}
+var dependencyForm = forms.DependencyForm{}
+
+// dependencyViewer is the user the dependency graph is read for, a personal access token limits it to the
+// organizations of the token and to none without the services:read scope
+func dependencyViewer(c *gin.Context) models.DependencyViewer {
+	viewer := models.DependencyViewer{UserID: utils.GetUserID(c)}
+	if pat := models.GetPersonalAccessToken(c); pat != nil {
+		viewer.OrganizationIDs = append([]string{}, pat.OrganizationIDs...)
+		if !pat.HasScope(models.PermissionServicesRead.Scope()) {
+			viewer.OrganizationIDs = []string{}
+		}
+	}
+	return viewer
+}
+
+// findDependencyVersion responds with 404 when the version doesn't exist, ok is false when the request was aborted
+func findDependencyVersion(c *gin.Context) (version models.ServiceVersion, ok bool) {
+	version, isFound, err := serviceVersionModel.One(c.Request.Context(), c.Param("serviceId"), c.Param("orgId"), c.Param("versionId"))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, "Service version not found")
+			return models.ServiceVersion{}, false
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get version")
+		return models.ServiceVersion{}, false
+	}
+	return version, true
+}
+
+// SetDependency makes a version depend on a range of versions of another service
+// @Summary Set a dependency
+// @Description Make the version depend on a range of versions of a service of the organization or of another organization the user can read,
+// @Description an existing dependency on the service gets the new range. Fails with 409 of type dependency_cycle with the services along
+// @Description the cycle in the details when the service already depends on the service of the version, the ids of services the user can't read
+// @Description are empty. Fails with 409 as well when the service is retired
+// @Tags Dependencies
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Param dependsOnId path string true "ID of the service depended on"
+// @Param dependency body forms.SetDependencyForm true "Range of versions, e.g. ^1.4.0"
+// @Success 200 {object} models.ServiceDependency
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/dependencies/{dependsOnId} [put]
+func (ctrl DependencyController) SetDependency(c *gin.Context) {
+	var form forms.SetDependencyForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := dependencyForm.Set(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	version, ok := findDependencyVersion(c)
+	if !ok {
+		return
+	}
+
+	dependency, err := dependencyModel.Set(c.Request.Context(), dependencyViewer(c), version, c.Param("dependsOnId"), form)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Service to depend on not found")
+			return
+		}
+		if errors.Is(err, models.ErrServiceRetired) {
+			models.AbortWithError(c, http.StatusConflict, "Retired services can't be depended on")
+			return
+		}
+		if cycleErr, ok := models.IsDependencyCycle(err); ok {
+			models.AbortWithErrorDetails(c, http.StatusConflict, "dependency_cycle",
+				"The service already depends on the service of the version", cycleErr)
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not set dependency")
+		return
+	}
+
+	c.JSON(http.StatusOK, dependency)
+}
+
+// GetDependencies returns the dependencies of a version
+// @Summary Get the dependencies of a version
+// @Description Get the services the version depends on with their range of versions and the highest version satisfying it, dependencies on services the user can't read are left out
+// @Tags Dependencies
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Success 200 {array} models.ServiceDependency
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/dependencies [get]
+func (ctrl DependencyController) GetDependencies(c *gin.Context) {
+	version, ok := findDependencyVersion(c)
+	if !ok {
+		return
+	}
+
+	dependencies, err := dependencyModel.All(c.Request.Context(), dependencyViewer(c), version.ID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get dependencies")
+		return
+	}
+
+	c.JSON(http.StatusOK, dependencies)
+}
+
+// DeleteDependency removes a dependency of a version
+// @Summary Delete a dependency
+// @Description Remove the dependency of the version on a service
+// @Tags Dependencies
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Param dependsOnId path string true "ID of the service depended on"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/dependencies/{dependsOnId} [delete]
+func (ctrl DependencyController) DeleteDependency(c *gin.Context) {
+	version, ok := findDependencyVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := dependencyModel.Delete(c.Request.Context(), version.ID, c.Param("dependsOnId")); err != nil {
+		if errors.Is(err, models.ErrDependencyNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Dependency not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not delete dependency")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDependents returns the versions which depend on a version
+// @Summary Get the dependents of a version
+// @Description Get the versions of any organization the user can read which depend on the service of the version with a range the version satisfies
+// @Tags Dependencies
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Success 200 {array} models.Dependent
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/dependents [get]
+func (ctrl DependencyController) GetDependents(c *gin.Context) {
+	version, ok := findDependencyVersion(c)
+	if !ok {
+		return
+	}
+
+	dependents, err := dependencyModel.Dependents(c.Request.Context(), dependencyViewer(c), version)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get dependents")
+		return
+	}
+
+	c.JSON(http.StatusOK, dependents)
+}
+
+// GetDependencyClosure returns the transitive dependencies of a version
+// @Summary Get the transitive dependencies of a version
+// @Description Get the dependencies of the version and of the versions they resolve to, each dependency is followed through the highest version satisfying its range.
+// @Description Depth is 1 for the dependencies of the version itself, dependencies nothing satisfies aren't followed and services the user can't read are left out
+// @Tags Dependencies
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Success 200 {array} models.DependencyNode
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/dependencies/closure [get]
+func (ctrl DependencyController) GetDependencyClosure(c *gin.Context) {
+	version, ok := findDependencyVersion(c)
+	if !ok {
+		return
+	}
+
+	nodes, err := dependencyModel.Closure(c.Request.Context(), dependencyViewer(c), version)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get transitive dependencies")
+		return
+	}
+
+	c.JSON(http.StatusOK, nodes)
+}
+
+// GetImpact returns the versions affected by versions of a service going away
+// @Summary Get the impact of deprecating versions
+// @Description Get the versions which break or are at risk when the versions of the service in the range go away, e.g. versions=1.x when they are deprecated.
+// @Description A version breaks when every version satisfying its range is affected, which affects the versions depending on it in turn, and is at risk when
+// @Description other versions still satisfy its range. Versions of services the user can't read are left out
+// @Tags Dependencies
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versions query string false "Range of the affected versions, e.g. 1.x. Default is all versions"
+// @Success 200 {object} models.ImpactReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/impact [get]
+func (ctrl DependencyController) GetImpact(c *gin.Context) {
+	versions := c.DefaultQuery("versions", "*")
+	if _, err := semver.NewConstraint(versions); err != nil {
+		models.AbortWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid versions %q, it should be a range of semantic versions like 1.x or <2.0.0", versions))
+		return
+	}
+
+	report, err := dependencyModel.Impact(c.Request.Context(), dependencyViewer(c), c.Param("serviceId"), versions)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get impact")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetDependencyGraph exports the dependency graph of an organization
+// @Summary Export the dependency graph
+// @Description Export the dependencies of the versions of the organization and the dependencies of other organizations on its services as JSON,
+// @Description as Graphviz DOT or as a Mermaid flowchart. Services are the nodes and each dependency of a version is an edge labelled with the version,
+// @Description its range and the version it resolves to. Services the user can't read are left out
+// @Tags Dependencies
+// @Produce json
+// @Produce plain
+// @Param orgId path string true "Organization ID"
+// @Param format query string false "Format of the graph. Default is json" Enums(json, dot, mermaid)
+// @Success 200 {object} models.DependencyGraph
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/dependency-graph [get]
+func (ctrl DependencyController) GetDependencyGraph(c *gin.Context) {
+	format := c.DefaultQuery("format", string(models.DependencyGraphJSON))
+	if !models.IsDependencyGraphFormat(format) {
+		models.AbortWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid format %q, supported formats are json, dot and mermaid", format))
+		return
+	}
+
+	graph, err := dependencyModel.Graph(c.Request.Context(), dependencyViewer(c), c.Param("orgId"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get dependency graph")
+		return
+	}
+
+	switch models.DependencyGraphFormat(format) {
+	case models.DependencyGraphDOT:
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+	case models.DependencyGraphMermaid:
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(graph.Mermaid()))
+	default:
+		c.JSON(http.StatusOK, graph)
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// appLink builds a link to the frontend (APP_BASE_URL) with the token as query parameter
+func appLink(path string, token string) string {
+	baseURL := strings.TrimSuffix(utils.GetEnv("APP_BASE_URL", "http://localhost:9000"), "/")
+	return fmt.Sprintf("%s%s?token=%s", baseURL, path, url.QueryEscape(token))
+}
+
+// humanizeDuration formats durations used in emails, e.g. "30 minutes", "2 hours" or "7 days"
+func humanizeDuration(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		days := int(d / day)
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	minutes := int(d / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+func passwordResetEmail(to string, name string, token string, validFor time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Reset your Konnect password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset the password of your Konnect account.
+Use the link below to choose a new password, it is valid for %s and can be used only once.
+
+%s
+
+If you did not request a password reset you can ignore this email, your password will not change.
+`, name, humanizeDuration(validFor), appLink("/reset-password", token)),
+	}
+}
+
+func verificationEmail(to string, name string, token string, validFor time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Verify your Konnect account",
+		Body: fmt.Sprintf(`Hi %s,
+
+Thanks for signing up for Konnect. Please verify your email using the link below, it is valid for %s.
+
+%s
+
+If you did not create a Konnect account you can ignore this email.
+`, name, humanizeDuration(validFor), appLink("/verify-email", token)),
+	}
+}
+
+func accountExistsEmail(to string, name string) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Your Konnect account",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone tried to create a Konnect account with this email, but you already have an account.
+If it was you, log in with your existing password or reset it if you forgot it.
+
+If it was not you, you can ignore this email.
+`, name),
+	}
+}
+
+func accountLockedEmail(to string, name string, token string, lockout time.Duration, validFor time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Your Konnect account has been locked",
+		Body: fmt.Sprintf(`Hi %s,
+
+There were too many failed attempts to log in to your Konnect account, so logging in has been locked for %s.
+
+If it was you, you can wait until the lock ends or unlock your account right away by choosing a new password
+with the link below. It is valid for %s and can be used only once.
+
+%s
+
+If it was not you, someone may be trying to guess your password. We recommend choosing a new one using the link above.
+`, name, humanizeDuration(lockout), humanizeDuration(validFor), appLink("/reset-password", token)),
+	}
+}
+
+func invitationEmail(to string, inviterName string, organizationName string, role models.Role, token string, validFor time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: fmt.Sprintf("You have been invited to %s on Konnect", organizationName),
+		Body: fmt.Sprintf(`Hi,
+
+%s invited you to join the organization %s on Konnect as %s.
+
+Use the link below to accept or decline the invitation, it is valid for %s.
+If you don't have a Konnect account yet, sign up with this email first and then open the link again.
+
+%s
+
+If you were not expecting this invitation you can ignore this email.
+`, inviterName, organizationName, role, humanizeDuration(validFor), appLink("/invitations", token)),
+	}
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type InvitationController struct{}
+
+var invitationModel = models.InvitationModel{}
+var invitationForm = forms.InvitationForm{}
+
+// CreateInvitation invites an email to the organization
+// @Summary Invite to organization
+// @Description Email an invitation to join the organization with a role, the email doesn't need to have an account yet.
+// @Description Inviting an email again replaces its pending invitation. Only owners can invite owners.
+// @Description With membershipDays the membership ends that many days after the invitation is accepted, owners can't be invited for a limited time.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param invitation body forms.CreateInvitationForm true "Email and role"
+// @Success 201 {object} models.Invitation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/invitations [post]
+func (ctrl InvitationController) CreateInvitation(c *gin.Context) {
+	userID := utils.GetUserID(c)
+	orgID := c.Param("orgId")
+	var form forms.CreateInvitationForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := invitationForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	role := models.Role(form.Role)
+	if role == models.RoleOwner && models.GetOrganizationRole(c) != models.RoleOwner {
+		models.AbortWithError(c, http.StatusForbidden, "Only owners can invite owners")
+		return
+	}
+
+	organization, _, err := organizationModel.One(c.Request.Context(), orgID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	inviter, _, err := userModel.One(c.Request.Context(), userID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	invitation, token, err := invitationModel.Create(c.Request.Context(), orgID, form.Email, role, form.MembershipDays, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrAlreadyMember) {
+			models.AbortWithError(c, http.StatusConflict, "User is already a member of the organization")
+			return
+		}
+		if errors.Is(err, models.ErrOwnerMembershipExpiry) {
+			models.AbortWithError(c, http.StatusBadRequest, "Owners can't have a time-bound membership")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	msg := invitationEmail(invitation.Email, inviter.Name, organization.Name, role, token, models.InvitationTTL())
+	if err := mailer.Send(c.Request.Context(), msg); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to send invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetInvitations returns the pending invitations of the organization
+// @Summary List invitations
+// @Description Get the pending invitations of the organization, newest first
+// @Tags Invitations
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 200 {array} models.Invitation
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/invitations [get]
+func (ctrl InvitationController) GetInvitations(c *gin.Context) {
+	invitations, err := invitationModel.All(c.Request.Context(), c.Param("orgId"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation withdraws a pending invitation of the organization
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation, its link stops working
+// @Tags Invitations
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/invitations/{invitationId} [delete]
+func (ctrl InvitationController) RevokeInvitation(c *gin.Context) {
+	if err := invitationModel.Revoke(c.Request.Context(), c.Param("orgId"), c.Param("invitationId")); err != nil {
+		if errors.Is(err, models.ErrInvitationNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation joins the organization of an invitation
+// @Summary Accept an invitation
+// @Description Join the organization using the token from the invitation email with the invited role.
+// @Description The invitation has to be for the email of the current user, users invited before signing up accept it after registering with the invited email.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param request body forms.InvitationTokenForm true "Invitation token"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /invitations/accept [post]
+func (ctrl InvitationController) AcceptInvitation(c *gin.Context) {
+	var form forms.InvitationTokenForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := invitationForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, isFound, err := userModel.One(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	invitation, err := invitationModel.Accept(c.Request.Context(), form.Token, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvitationInvalid):
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid or expired invitation")
+		case errors.Is(err, models.ErrInvitationEmailMismatch):
+			models.AbortWithError(c, http.StatusForbidden, "This invitation was sent to a different email")
+		case errors.Is(err, models.ErrAlreadyMember):
+			models.AbortWithError(c, http.StatusConflict, "You are already a member of the organization")
+		default:
+			if abortQuotaExceeded(c, err) {
+				return
+			}
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to accept invitation")
+		}
+		return
+	}
+
+	organization, _, err := organizationModel.One(c.Request.Context(), invitation.OrganizationID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch organization")
+		return
+	}
+	organization.Role = invitation.Role
+	if invitation.MembershipDays > 0 {
+		membership, _, err := memberModel.One(c.Request.Context(), invitation.OrganizationID, user.ID)
+		if err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch organization")
+			return
+		}
+		organization.SetMembershipExpiry(membership.ExpiresAt)
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// DeclineInvitation rejects an invitation
+// @Summary Decline an invitation
+// @Description Decline an invitation using the token from the invitation email, no account is needed
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param request body forms.InvitationTokenForm true "Invitation token"
+// @Success 204 ""
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /invitations/decline [post]
+func (ctrl InvitationController) DeclineInvitation(c *gin.Context) {
+	var form forms.InvitationTokenForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := invitationForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	if err := invitationModel.Decline(c.Request.Context(), form.Token); err != nil {
+		if errors.Is(err, models.ErrInvitationInvalid) {
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid or expired invitation")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to decline invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
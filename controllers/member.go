@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type MemberController struct{}
+
+var memberModel = models.MemberModel{}
+var memberForm = forms.MemberForm{}
+
+// abortMemberError responds with the error of a membership change, message is used for unexpected errors
+func abortMemberError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrMemberNotFound):
+		models.AbortWithError(c, http.StatusNotFound, "Member not found")
+	case errors.Is(err, models.ErrOwnerRoleRequired):
+		models.AbortWithError(c, http.StatusForbidden, "Only owners can change owners or make members owners")
+	case errors.Is(err, models.ErrLastOwner):
+		models.AbortWithError(c, http.StatusConflict, "The organization needs at least one owner, make another member owner first")
+	case errors.Is(err, models.ErrOwnerMembershipExpiry):
+		models.AbortWithError(c, http.StatusBadRequest, "Owners can't have a time-bound membership")
+	case errors.Is(err, models.ErrMembershipExpiryRemoval):
+		models.AbortWithError(c, http.StatusForbidden, "Only owners and members with a higher role can make a membership permanent")
+	default:
+		models.AbortWithError(c, http.StatusInternalServerError, message)
+	}
+}
+
+// GetMembers returns the members of the organization
+// @Summary List members
+// @Description Get the members of the organization with their roles
+// @Tags Members
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param q query string false "Search the name and email of members"
+// @Param sort_by query string false "Sort field" Enums(name, email, role, joined_at)
+// @Param sort query string false "Sort direction" Enums(asc, desc)
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResult[models.Member]
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/members [get]
+func (ctrl MemberController) GetMembers(c *gin.Context) {
+	orgID := c.Param("orgId")
+
+	q := c.Query("q")
+	sortBy, sort := models.ParseSortParams(c, models.GetMemberValidSortFields(), "joined_at")
+	page, perPage := models.ParsePaginationParams(c)
+
+	result, err := memberModel.All(c.Request.Context(), orgID, q, sortBy, sort, page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch members")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateMember changes the role of a member
+// @Summary Change member role
+// @Description Change the role of a member. Only owners can change the role of owners or make members owners,
+// @Description the only owner of the organization can't be demoted.
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Param member body forms.UpdateMemberForm true "New role"
+// @Success 200 {object} models.Member
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/members/{userId} [patch]
+func (ctrl MemberController) UpdateMember(c *gin.Context) {
+	var form forms.UpdateMemberForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := memberForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	member, err := memberModel.UpdateRole(c.Request.Context(), c.Param("orgId"), c.Param("userId"), models.Role(form.Role), models.GetOrganizationRole(c))
+	if err != nil {
+		abortMemberError(c, err, "Failed to update member")
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// ExtendMembership extends a time-bound membership
+// @Summary Extend membership
+// @Description Make the membership of a member end the given number of days later, a membership which doesn't end yet
+// @Description ends that many days from now. Without days the membership becomes permanent, which only owners and members with a higher
+// @Description role than the member can do. Members can't extend their own membership and owners can't have a time-bound membership.
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Param request body forms.ExtendMembershipForm true "Days to extend by"
+// @Success 200 {object} models.Member
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/members/{userId}/extend [post]
+func (ctrl MemberController) ExtendMembership(c *gin.Context) {
+	var form forms.ExtendMembershipForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := memberForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	// a time-bound member could otherwise keep their access for good
+	if c.Param("userId") == utils.GetUserID(c) {
+		models.AbortWithError(c, http.StatusForbidden, "You can't extend your own membership")
+		return
+	}
+
+	member, err := memberModel.Extend(c.Request.Context(), c.Param("orgId"), c.Param("userId"), form.Days, models.GetOrganizationRole(c))
+	if err != nil {
+		abortMemberError(c, err, "Failed to extend membership")
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember removes a member from the organization
+// @Summary Remove member
+// @Description Remove a member from the organization. Only owners can remove owners, the only owner of the organization can't be removed.
+// @Tags Members
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/members/{userId} [delete]
+func (ctrl MemberController) RemoveMember(c *gin.Context) {
+	if err := memberModel.Remove(c.Request.Context(), c.Param("orgId"), c.Param("userId"), models.GetOrganizationRole(c), models.MembershipRemoved); err != nil {
+		abortMemberError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LeaveOrganization removes the current user from the organization
+// @Summary Leave organization
+// @Description Leave the organization, the only owner has to transfer the ownership or delete the organization instead
+// @Tags Members
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/leave [post]
+func (ctrl MemberController) LeaveOrganization(c *gin.Context) {
+	if err := memberModel.Remove(c.Request.Context(), c.Param("orgId"), utils.GetUserID(c), models.GetOrganizationRole(c), models.MembershipLeft); err != nil {
+		abortMemberError(c, err, "Failed to leave organization")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TransferOwnership hands the organization over to another member
+// @Summary Transfer ownership
+// @Description Make another member the owner and creator of the organization, the current owner stays in the organization as an admin
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param request body forms.TransferOwnershipForm true "New owner"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/transfer [post]
+func (ctrl MemberController) TransferOwnership(c *gin.Context) {
+	var form forms.TransferOwnershipForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := memberForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	organization, err := memberModel.TransferOwnership(c.Request.Context(), c.Param("orgId"), utils.GetUserID(c), form.UserID)
+	if err != nil {
+		abortMemberError(c, err, "Failed to transfer ownership")
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/pkg/oidc"
+)
+
+type OIDCController struct{}
+
+var oidcLoginStateModel = models.OIDCLoginStateModel{}
+var userIdentityModel = models.UserIdentityModel{}
+
+// Start redirects the user to the identity provider to sign in
+// @Summary Start single sign-on
+// @Description Redirects to the OpenID Connect identity provider to sign in (authorization code flow with PKCE).
+// @Description After signing in the provider redirects back to the callback endpoint which returns the tokens.
+// @Tags Authentication
+// @Param provider path string true "Name of the identity provider"
+// @Success 302 ""
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Router /auth/oidc/{provider}/start [get]
+func (ctrl OIDCController) Start(c *gin.Context) {
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		models.AbortWithError(c, http.StatusNotFound, "Identity provider not found")
+		return
+	}
+
+	state, loginState, err := oidcLoginStateModel.Create(c.Request.Context(), provider.Name)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, loginState.Nonce, loginState.CodeVerifier)
+	if err != nil {
+		log.With(c.Request.Context()).Errorf("failed to build authorization url for provider %s :: error: %s", provider.Name, err.Error())
+		models.AbortWithError(c, http.StatusBadGateway, "Failed to reach the identity provider")
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes single sign-on and returns tokens
+// @Summary Single sign-on callback
+// @Description The identity provider redirects here after the user signed in. The user is looked up by their identity at the provider,
+// @Description an identity seen for the first time is linked to the account with the same email or a new account is created for it.
+// @Description The email has to be verified by the provider to be linked.
+// @Tags Authentication
+// @Produce json
+// @Param provider path string true "Name of the identity provider"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the login"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func (ctrl OIDCController) Callback(c *gin.Context) {
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		models.AbortWithError(c, http.StatusNotFound, "Identity provider not found")
+		return
+	}
+
+	if errorCode := c.Query("error"); errorCode != "" {
+		log.With(c.Request.Context()).Infof("identity provider %s returned error %s: %s", provider.Name, errorCode, c.Query("error_description"))
+		models.AbortWithError(c, http.StatusUnauthorized, "Sign in at the identity provider failed")
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		models.AbortWithError(c, http.StatusBadRequest, "Missing code or state")
+		return
+	}
+
+	loginState, err := oidcLoginStateModel.Consume(c.Request.Context(), provider.Name, state)
+	if err != nil {
+		if errors.Is(err, models.ErrOIDCLoginStateInvalid) {
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid or expired login, please try again")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	claims, err := provider.Authenticate(c.Request.Context(), code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.With(c.Request.Context()).Warnf("failed to authenticate with identity provider %s :: error: %s", provider.Name, err.Error())
+		models.AbortWithError(c, http.StatusUnauthorized, "Failed to verify the sign in at the identity provider")
+		return
+	}
+
+	user, err := userIdentityModel.FindOrProvision(c.Request.Context(), models.ExternalIdentity{
+		Provider:      provider.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrIdentityEmailNotVerified) {
+			models.AbortWithError(c, http.StatusForbidden, "Your email is not verified by the identity provider")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	issueTokens(c, user)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,7 +17,7 @@ var organizationForm = forms.OrganizationForm{}
 
 // GetOrganizations returns all organizations the user belongs to
 // @Summary Get user's organizations
-// @Description Get all organizations that the authenticated user belongs to
+// @Description Get all organizations that the authenticated user belongs to along with the role of the user in each
 // @Tags Organizations
 // @Accept json
 // @Produce json
@@ -48,7 +49,8 @@ func (ctrl OrganizationController) GetOrganizations(c *gin.Context) {
 
 // CreateOrganization creates a new organization
 // @Summary Create a new organization
-// @Description Create a new organization for the authenticated user
+// @Description Create a new organization for the authenticated user, the user becomes its owner
+// @Description The slug is generated from the name when left out, routes take the slug in place of the organization ID
 // @Tags Organizations
 // @Accept json
 // @Produce json
@@ -56,6 +58,7 @@ func (ctrl OrganizationController) GetOrganizations(c *gin.Context) {
 // @Success 201 {object} models.Organization
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Security BearerAuth
 // @Router /orgs [post]
@@ -71,6 +74,10 @@ func (ctrl OrganizationController) CreateOrganization(c *gin.Context) {
 
 	organization, err := organizationModel.Create(c.Request.Context(), form, userID)
 	if err != nil {
+		if errors.Is(err, models.ErrSlugTaken) {
+			models.AbortWithError(c, http.StatusConflict, "An organization with this slug already exists")
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create organization")
 		return
 	}
@@ -93,21 +100,8 @@ func (ctrl OrganizationController) CreateOrganization(c *gin.Context) {
 // @Security BearerAuth
 // @Router /orgs/{orgId} [get]
 func (ctrl OrganizationController) GetOrganization(c *gin.Context) {
-	userID := utils.GetUserID(c)
 	orgID := c.Param("orgId")
 
-	// Check if user is member of organization
-	isMember, err := organizationModel.IsUserMember(c.Request.Context(), orgID, userID)
-	if err != nil {
-		models.AbortWithError(c, http.StatusInternalServerError, "Failed to check organization access")
-		return
-	}
-
-	if !isMember {
-		models.AbortWithError(c, http.StatusForbidden, "You are not authorized to perform the request")
-		return
-	}
-
 	organization, exists, err := organizationModel.One(c.Request.Context(), orgID)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch organization")
@@ -124,7 +118,8 @@ func (ctrl OrganizationController) GetOrganization(c *gin.Context) {
 
 // UpdateOrganization updates an organization
 // @Summary Update organization
-// @Description Update an existing organization
+// @Description Update an existing organization, owners and admins of the organization can update it
+// @Description A new slug replaces the current one, the previous slug keeps redirecting to the organization
 // @Tags Organizations
 // @Accept json
 // @Produce json
@@ -135,11 +130,11 @@ func (ctrl OrganizationController) GetOrganization(c *gin.Context) {
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Security BearerAuth
 // @Router /orgs/{orgId} [put]
 func (ctrl OrganizationController) UpdateOrganization(c *gin.Context) {
-	userID := utils.GetUserID(c)
 	orgID := c.Param("orgId")
 	var form forms.CreateOrganizationForm
 
@@ -149,20 +144,12 @@ func (ctrl OrganizationController) UpdateOrganization(c *gin.Context) {
 		return
 	}
 
-	// Check if user is member of organization
-	isMember, err := organizationModel.IsUserMember(c.Request.Context(), orgID, userID)
-	if err != nil {
-		models.AbortWithError(c, http.StatusInternalServerError, "Failed to check organization access")
-		return
-	}
-
-	if !isMember {
-		models.AbortWithError(c, http.StatusForbidden, "You are not authorized to perform the request")
-		return
-	}
-
 	organization, err := organizationModel.Update(c.Request.Context(), orgID, form)
 	if err != nil {
+		if errors.Is(err, models.ErrSlugTaken) {
+			models.AbortWithError(c, http.StatusConflict, "An organization with this slug already exists")
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to update organization")
 		return
 	}
@@ -172,7 +159,8 @@ func (ctrl OrganizationController) UpdateOrganization(c *gin.Context) {
 
 // DeleteOrganization deletes an organization
 // @Summary Delete organization
-// @Description Delete an organization
+// @Description Delete an organization, only owners of the organization can delete it
+// @Description The organization is moved to the trash with everything in it, its owners can restore it until it is purged
 // @Tags Organizations
 // @Accept json
 // @Produce json
@@ -185,22 +173,9 @@ func (ctrl OrganizationController) UpdateOrganization(c *gin.Context) {
 // @Security BearerAuth
 // @Router /orgs/{orgId} [delete]
 func (ctrl OrganizationController) DeleteOrganization(c *gin.Context) {
-	userID := utils.GetUserID(c)
 	orgID := c.Param("orgId")
 
-	// Check if user is member of organization
-	isMember, err := organizationModel.IsUserMember(c.Request.Context(), orgID, userID)
-	if err != nil {
-		models.AbortWithError(c, http.StatusInternalServerError, "Failed to check organization access")
-		return
-	}
-
-	if !isMember {
-		models.AbortWithError(c, http.StatusForbidden, "You are not authorized to perform the request")
-		return
-	}
-
-	err = organizationModel.Delete(c.Request.Context(), orgID)
+	err := organizationModel.Delete(c.Request.Context(), orgID)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to delete organization")
 		return
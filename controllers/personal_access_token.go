@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type PersonalAccessTokenController struct{}
+
+var personalAccessTokenModel = models.PersonalAccessTokenModel{}
+var personalAccessTokenForm = forms.PersonalAccessTokenForm{}
+
+// CreateToken creates a personal access token for the current user
+// @Summary Create a personal access token
+// @Description Create a long lived token for automation. The token can only be used for the given organizations
+// @Description with the given scopes, a write scope includes the read scope of the same resource.
+// @Description The token is returned only once, use it as a Bearer token in the Authorization header.
+// @Tags Personal Access Tokens
+// @Accept json
+// @Produce json
+// @Param token body forms.CreatePersonalAccessTokenForm true "Token data"
+// @Success 201 {object} models.CreatedPersonalAccessTokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens [post]
+func (ctrl PersonalAccessTokenController) CreateToken(c *gin.Context) {
+	userID := utils.GetUserID(c)
+	var form forms.CreatePersonalAccessTokenForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := personalAccessTokenForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	// a token can't grant access to organizations the user is not a member of
+	for _, orgID := range form.OrganizationIDs {
+		isMember, err := organizationModel.IsUserMember(c.Request.Context(), orgID, userID)
+		if err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to create token")
+			return
+		}
+		if !isMember {
+			models.AbortWithError(c, http.StatusForbidden, fmt.Sprintf("You are not a member of the organization %s", orgID))
+			return
+		}
+	}
+
+	pat, token, err := personalAccessTokenModel.Create(c.Request.Context(), userID, form)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreatedPersonalAccessTokenResponse{
+		PersonalAccessToken: pat,
+		Token:               token,
+	})
+}
+
+// GetTokens returns the personal access tokens of the current user
+// @Summary List personal access tokens
+// @Description Get the personal access tokens of the authenticated user which are not revoked, including expired ones
+// @Tags Personal Access Tokens
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.PersonalAccessToken
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens [get]
+func (ctrl PersonalAccessTokenController) GetTokens(c *gin.Context) {
+	userID := utils.GetUserID(c)
+
+	tokens, err := personalAccessTokenModel.All(c.Request.Context(), userID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch tokens")
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken revokes a personal access token of the current user
+// @Summary Revoke a personal access token
+// @Description Revoke a personal access token, it can't be used anymore
+// @Tags Personal Access Tokens
+// @Accept json
+// @Produce json
+// @Param tokenId path string true "Token ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens/{tokenId} [delete]
+func (ctrl PersonalAccessTokenController) RevokeToken(c *gin.Context) {
+	userID := utils.GetUserID(c)
+	tokenID := c.Param("tokenId")
+
+	if err := personalAccessTokenModel.Revoke(c.Request.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, models.ErrPersonalAccessTokenNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Token not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+type QuotaController struct{}
+
+var quotaModel = models.QuotaModel{}
+var quotaForm = forms.QuotaForm{}
+
+// abortQuotaExceeded responds with 409 when err is a QuotaExceededError, ok is true when the request was aborted
+func abortQuotaExceeded(c *gin.Context, err error) (ok bool) {
+	quotaErr, ok := models.IsQuotaExceeded(err)
+	if !ok {
+		return false
+	}
+	message := fmt.Sprintf("The organization reached its limit of %d %s", quotaErr.Limit, quotaErr.Resource)
+	if quotaErr.Resource == models.QuotaVersionsPerService {
+		message = fmt.Sprintf("The service reached its limit of %d versions", quotaErr.Limit)
+	}
+	models.AbortWithErrorDetails(c, http.StatusConflict, "quota_exceeded", message, quotaErr)
+	return true
+}
+
+// findQuotaOrganization responds with 404 when the organization doesn't exist, ok is false when the request was aborted
+func findQuotaOrganization(c *gin.Context) (ok bool) {
+	_, isFound, err := organizationModel.One(c.Request.Context(), c.Param("orgId"))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, "Organization not found")
+			return false
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get organization")
+		return false
+	}
+	return true
+}
+
+// GetUsage returns the usage of the organization against its limits
+// @Summary Get organization usage
+// @Description Get the current usage of the organization against each of its limits, a limit of 0 means unlimited.
+// @Description The usage of versions is the version count of the service with the most versions.
+// @Tags Organizations
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 200 {object} models.OrganizationUsage
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/usage [get]
+func (ctrl QuotaController) GetUsage(c *gin.Context) {
+	usage, err := quotaModel.Usage(c.Request.Context(), c.Param("orgId"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get usage")
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetQuota returns the limits of an organization
+// @Summary Get organization quota
+// @Description Get the limits of an organization, the defaults with its override applied. Only operators can use this endpoint.
+// @Tags Operator
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 200 {object} models.QuotaLimits
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /operator/orgs/{orgId}/quotas [get]
+func (ctrl QuotaController) GetQuota(c *gin.Context) {
+	if !findQuotaOrganization(c) {
+		return
+	}
+
+	limits, err := quotaModel.Limits(c.Request.Context(), c.Param("orgId"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get quota")
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// UpdateQuota overrides the limits of an organization
+// @Summary Override organization quota
+// @Description Override the limits of an organization, limits left out go back to the default and 0 means unlimited.
+// @Description Lowering a limit below the current usage only blocks new resources. Only operators can use this endpoint.
+// @Tags Operator
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param quota body forms.UpdateQuotaForm true "Limits"
+// @Success 200 {object} models.QuotaLimits
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /operator/orgs/{orgId}/quotas [put]
+func (ctrl QuotaController) UpdateQuota(c *gin.Context) {
+	var form forms.UpdateQuotaForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := quotaForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	if !findQuotaOrganization(c) {
+		return
+	}
+
+	limits, err := quotaModel.Override(c.Request.Context(), c.Param("orgId"), form)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not update quota")
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
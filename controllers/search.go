@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type SearchController struct{}
+
+var searchModel = models.SearchModel{}
+
+// Search searches the organizations of the user along with their services and versions
+// @Summary Search all organizations
+// @Description Full-text search over the names and descriptions of the organizations the user is a member of and of their services and versions,
+// @Description in one list ranked by relevance. Hits have their kind, organization and score, matching words are wrapped in <mark> tags in the HTML escaped snippets.
+// @Description When nothing matches, the hits are the resources with a name similar to the query and didYouMean is the most similar name.
+// @Description Personal access tokens only search their organizations and the kinds of resources their scopes allow reading.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search query, supports \"quoted phrases\", or and -excluded words"
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.SearchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /search [get]
+func (ctrl SearchController) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		models.AbortWithError(c, http.StatusBadRequest, "Please enter a search query")
+		return
+	}
+
+	page, perPage := models.ParsePaginationParams(c)
+
+	scope := models.SearchScope{Organizations: true, Services: true, Versions: true}
+	if pat := models.GetPersonalAccessToken(c); pat != nil {
+		scope = models.SearchScope{
+			OrganizationIDs: append([]string{}, pat.OrganizationIDs...),
+			Organizations:   pat.HasScope(models.PermissionOrgRead.Scope()),
+			Services:        pat.HasScope(models.PermissionServicesRead.Scope()),
+			Versions:        pat.HasScope(models.PermissionVersionsRead.Scope()),
+		}
+	}
+
+	result, err := searchModel.Global(c.Request.Context(), utils.GetUserID(c), scope, q, page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not search")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SearchOrganization searches the services and versions of the organization
+// @Summary Search an organization
+// @Description Full-text search over the names and descriptions of the services and versions of the organization, most relevant first.
+// @Description Matching words are wrapped in <mark> tags in the HTML escaped snippets. When nothing matches, the hits are the services and versions
+// @Description with a name similar to the query and didYouMean is the most similar name. Versions are left out for tokens without the versions:read scope.
+// @Tags Search
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param q query string true "Search query, supports \"quoted phrases\", or and -excluded words"
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.SearchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/search [get]
+func (ctrl SearchController) SearchOrganization(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		models.AbortWithError(c, http.StatusBadRequest, "Please enter a search query")
+		return
+	}
+
+	page, perPage := models.ParsePaginationParams(c)
+	viewer := models.ServiceViewer{UserID: utils.GetUserID(c), Role: models.GetOrganizationRole(c)}
+
+	// the route only requires services:read, versions need their own scope
+	includeVersions := true
+	if pat := models.GetPersonalAccessToken(c); pat != nil {
+		includeVersions = pat.HasScope(models.PermissionVersionsRead.Scope())
+	}
+
+	result, err := searchModel.Organization(c.Request.Context(), c.Param("orgId"), viewer, q, includeVersions, page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not search the organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
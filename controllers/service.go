@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thilak009/kong-assignment/forms"
 	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/labels"
+	"github.com/thilak009/kong-assignment/utils"
 )
 
 type ServiceController struct{}
@@ -30,11 +34,62 @@ func parseIncludeParams(include string) (includeVersionCount bool) {
 	return includeVersionCount
 }
 
+// parseLifecycleStates parses the comma separated lifecycle query parameter, the request is aborted with 400
+// when it has an unknown state
+func parseLifecycleStates(c *gin.Context) (states []models.LifecycleState, ok bool) {
+	lifecycle := c.Query("lifecycle")
+	if lifecycle == "" {
+		return nil, true
+	}
+
+	for _, state := range strings.Split(lifecycle, ",") {
+		state = strings.TrimSpace(state)
+		if !models.IsLifecycleState(state) {
+			models.AbortWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid lifecycle %q, supported values are experimental, active, deprecated and retired", state))
+			return nil, false
+		}
+		states = append(states, models.LifecycleState(state))
+	}
+	return states, true
+}
+
+// parseLabelSelector parses the labelSelector query parameter, the request is aborted with 400 when it is invalid
+func parseLabelSelector(c *gin.Context) (selector labels.Selector, ok bool) {
+	selector, err := labels.Parse(c.Query("labelSelector"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid labelSelector: %s", err.Error()))
+		return nil, false
+	}
+	return selector, true
+}
+
+// parseAttributeFilters parses the attribute query parameters, the request is aborted with 400 when one is invalid
+func parseAttributeFilters(c *gin.Context) (filters []models.AttributeFilter, ok bool) {
+	filters, err := models.ParseAttributeFilters(c.QueryArray("attribute"))
+	if err != nil {
+		models.AbortWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid attribute filter: %s", err.Error()))
+		return nil, false
+	}
+	return filters, true
+}
+
+// abortInvalidAttributes responds with 400 when err is an InvalidAttributesError, ok is true when the request was aborted
+func abortInvalidAttributes(c *gin.Context, err error) (ok bool) {
+	attributesErr, ok := models.IsInvalidAttributes(err)
+	if !ok {
+		return false
+	}
+	models.AbortWithErrorDetails(c, http.StatusBadRequest, "invalid_attributes",
+		"Attributes don't match the attribute schema of the organization", attributesErr)
+	return true
+}
+
 
 // CreateService creates a new service in an organization
 // @Summary Create a service
 // @Schemes
-// @Description Creates a service
+// @Description Creates a service, fails with 409 of type quota_exceeded when the organization reached its limit of services
+// @Description and with 400 of type invalid_attributes listing the violations when the attributes don't match the attribute schema of the organization
 // @Tags Service
 // @Accept json
 // @Produce json
@@ -43,6 +98,7 @@ func parseIncludeParams(include string) (includeVersionCount bool) {
 // @Success 	 200  {object}  models.Service
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      403  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
 // @Failure      500  {object}	models.ErrorResponse
 // @Security BearerAuth
 // @Router /orgs/{orgId}/services [post]
@@ -58,6 +114,13 @@ func (ctrl ServiceController) CreateService(c *gin.Context) {
 
 	service, err := serviceModel.Create(c.Request.Context(), form, orgID)
 	if err != nil {
+		if abortQuotaExceeded(c, err) || abortInvalidAttributes(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrSlugTaken) {
+			models.AbortWithError(c, http.StatusConflict, "A service with this slug already exists in the organization")
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Service could not be created")
 		return
 	}
@@ -68,18 +131,22 @@ func (ctrl ServiceController) CreateService(c *gin.Context) {
 // Get All Services godoc
 // @Summary Get All services
 // @Schemes
-// @Description Gets all the services available
+// @Description Gets all the services available, services granted to teams are only listed to owners, admins and members of those teams
 // @Tags Service
 // @Accept json
 // @Produce json
 // @Param	orgId path string true "Organization ID"
-// @Param	q	query   string	false	"Service name, supports searching the passed string in the name of the service"
+// @Param	q	query   string	false	"Matches the words of the name and description of the service or a part of the name, see /orgs/{orgId}/search for results ranked by relevance"
 // @Param	sort	query   string	false	"Sort order for the list of services. Accepted values are asc and desc. Default is desc(assumes default on invalid values as well)" Enums(asc, desc)
 // @Param	sort_by	query   string	false	"The field on which sorting to be applied, supports name, created_at, updated_at. Default is updated_at(assumes default on invalid values as well)" Enums(name, created_at, updated_at)
 // @Param	page	query   int	false	"Page number for pagination (0-based). Default is 0"
 // @Param	per_page	query   int	false	"Number of items per page. Default is 10, max is 100, assumes 100 if >100 is passed"
 // @Param	include	query   string	false	"Additional data to include (comma-separated). Supported values: versionCount"
+// @Param	labelSelector	query   string	false	"Comma separated label requirements which all have to match, supports key=value, key!=value, key in (v1,v2), key notin (v1,v2), key and !key. For example: env in (prod,staging),!deprecated"
+// @Param	attribute	query   []string	false	"Attribute filters of the form path=value which all have to match, the path is dot separated for nested attributes and the value is read as JSON when it is valid JSON and as a string otherwise. For example: team.oncall=payments" collectionFormat(multi)
+// @Param	lifecycle	query   string	false	"Lifecycle states of the services to list (comma-separated). Supported values: experimental, active, deprecated, retired. Default is all states"
 // @Success 	 200  {object}  models.PaginatedResult[models.Service]
+// @Failure      400  {object}	models.ErrorResponse
 // @Failure      403  {object}	models.ErrorResponse
 // @Failure      500  {object}	models.ErrorResponse
 // @Security BearerAuth
@@ -88,6 +155,18 @@ func (ctrl ServiceController) GetServices(c *gin.Context) {
 	orgID := c.Param("orgId")
 
 	q := c.Query("q")
+	selector, ok := parseLabelSelector(c)
+	if !ok {
+		return
+	}
+	attributes, ok := parseAttributeFilters(c)
+	if !ok {
+		return
+	}
+	lifecycles, ok := parseLifecycleStates(c)
+	if !ok {
+		return
+	}
 	sortBy, sort := models.ParseSortParams(c, models.GetServiceValidSortFields(), "updated_at")
 	page, perPage := models.ParsePaginationParams(c)
 
@@ -95,7 +174,10 @@ func (ctrl ServiceController) GetServices(c *gin.Context) {
 	include := c.Query("include")
 	includeVersionCount := parseIncludeParams(include)
 
-	results, err := serviceModel.All(c.Request.Context(), orgID, q, sortBy, sort, page, perPage, includeVersionCount)
+	// in organizations with teams services are only listed to the members allowed to see them
+	viewer := models.ServiceViewer{UserID: utils.GetUserID(c), Role: models.GetOrganizationRole(c)}
+
+	results, err := serviceModel.All(c.Request.Context(), orgID, viewer, q, selector, attributes, lifecycles, sortBy, sort, page, perPage, includeVersionCount)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Could not get services")
 		return
@@ -143,7 +225,11 @@ func (ctrl ServiceController) GetService(c *gin.Context) {
 // UpdateService updates a service
 // @Summary Update a service
 // @Schemes
-// @Description Updates the specified service. Both name and description are optional.
+// @Description Updates the specified service. Name, description and slug are optional.
+// @Description A new slug replaces the current one, the previous slug keeps redirecting to the service
+// @Description The lifecycle moves from experimental to active, deprecated or retired, from active to deprecated and from deprecated back to active or to retired.
+// @Description Other transitions fail with 409 of type invalid_lifecycle_transition, retired is final and a sunset date can only be set on deprecated services
+// @Description New attributes replace the current ones and have to match the attribute schema of the organization, otherwise the update fails with 400 of type invalid_attributes
 // @Tags Service
 // @Accept json
 // @Produce json
@@ -154,6 +240,7 @@ func (ctrl ServiceController) GetService(c *gin.Context) {
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      403  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Security BearerAuth
 // @Router /orgs/{orgId}/services/{serviceId} [PATCH]
@@ -186,6 +273,22 @@ func (ctrl ServiceController) UpdateService(c *gin.Context) {
 
 	service, err := serviceModel.Update(c.Request.Context(), serviceID, orgID, form)
 	if err != nil {
+		if abortInvalidAttributes(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrSlugTaken) {
+			models.AbortWithError(c, http.StatusConflict, "A service with this slug already exists in the organization")
+			return
+		}
+		if transitionErr, ok := models.IsLifecycleTransitionError(err); ok {
+			models.AbortWithErrorDetails(c, http.StatusConflict, "invalid_lifecycle_transition",
+				fmt.Sprintf("Service can't move from %s to %s", transitionErr.From, transitionErr.To), transitionErr)
+			return
+		}
+		if errors.Is(err, models.ErrSunsetNotDeprecated) {
+			models.AbortWithError(c, http.StatusBadRequest, "Sunset date can only be set on deprecated services")
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Service could not be updated")
 		return
 	}
@@ -195,7 +298,7 @@ func (ctrl ServiceController) UpdateService(c *gin.Context) {
 // DeleteService deletes a service
 // @Summary Delete a service
 // @Schemes
-// @Description Deletes the specified service
+// @Description Moves the specified service to the trash along with its versions, it can be restored until it is purged
 // @Tags Service
 // @Accept json
 // @Produce json
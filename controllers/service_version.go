@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,8 @@ var serviceVersionForm = new(forms.ServiceVersionForm)
 // @Schemes
 // @Description Creates a version for the specified service
 // @Description version value must be a semantic version
+// @Description Fails with 409 of type quota_exceeded when the service reached its limit of versions and with 409 when the service is retired
+// @Description Fails with 400 of type invalid_attributes listing the violations when the attributes don't match the attribute schema of the organization
 // @Tags ServiceVersion
 // @Accept json
 // @Produce json
@@ -28,6 +31,7 @@ var serviceVersionForm = new(forms.ServiceVersionForm)
 // @Success 	 200  {object}  models.ServiceVersion
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      403  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
 // @Failure      500  {object} models.ErrorResponse
 // @Security BearerAuth
 // @Router /orgs/{orgId}/services/{serviceId}/versions [post]
@@ -56,6 +60,13 @@ func (ctrl ServiceVersionController) CreateServiceVersion(c *gin.Context) {
 	// TODO: handle same version tag creation by returning a bad request maybe
 	version, err := serviceVersionModel.Create(c.Request.Context(), serviceID, form)
 	if err != nil {
+		if abortQuotaExceeded(c, err) || abortInvalidAttributes(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrServiceRetired) {
+			models.AbortWithError(c, http.StatusConflict, "Versions can't be created for a retired service")
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Service version could not be created")
 		return
 	}
@@ -77,7 +88,10 @@ func (ctrl ServiceVersionController) CreateServiceVersion(c *gin.Context) {
 // @Param	per_page	query   int	false	"Number of items per page. Default is 10, max is 100, assumes 100 if >100 is passed"
 // @Param	orgId path string true "Organization ID"
 // @Param	serviceId	path	string	true	"Service ID"
+// @Param	labelSelector	query   string	false	"Comma separated label requirements which all have to match, supports key=value, key!=value, key in (v1,v2), key notin (v1,v2), key and !key. For example: channel=stable,!deprecated"
+// @Param	attribute	query   []string	false	"Attribute filters of the form path=value which all have to match, the path is dot separated for nested attributes and the value is read as JSON when it is valid JSON and as a string otherwise. For example: rollout.canary=true" collectionFormat(multi)
 // @Success 	 200  {object}  models.PaginatedResult[models.ServiceVersion]
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      403  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Failure      500  {object} models.ErrorResponse
@@ -98,10 +112,18 @@ func (ctrl ServiceVersionController) GetServiceVersions(c *gin.Context) {
 		return
 	}
 	q := c.Query("q")
+	selector, ok := parseLabelSelector(c)
+	if !ok {
+		return
+	}
+	attributes, ok := parseAttributeFilters(c)
+	if !ok {
+		return
+	}
 	sortBy, sort := models.ParseSortParams(c, models.GetServiceVersionValidSortFields(), "updated_at")
 	page, perPage := models.ParsePaginationParams(c)
 
-	versions, err := serviceVersionModel.All(c.Request.Context(), serviceID, orgID, q, sortBy, sort, page, perPage)
+	versions, err := serviceVersionModel.All(c.Request.Context(), serviceID, orgID, q, selector, attributes, sortBy, sort, page, perPage)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Could not get service versions")
 		return
@@ -149,6 +171,7 @@ func (ctrl ServiceVersionController) GetServiceVersion(c *gin.Context) {
 // @Summary Update a version for a service
 // @Schemes
 // @Description Updates the specified version of a service, version tag cannot be updated
+// @Description New attributes replace the current ones and have to match the attribute schema of the organization, otherwise the update fails with 400 of type invalid_attributes
 // @Tags ServiceVersion
 // @Accept json
 // @Produce json
@@ -194,6 +217,9 @@ func (ctrl ServiceVersionController) UpdateServiceVersion(c *gin.Context) {
 
 	version, err := serviceVersionModel.Update(c.Request.Context(), serviceID, orgID, id, form)
 	if err != nil {
+		if abortInvalidAttributes(c, err) {
+			return
+		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Service version could not be updated")
 		return
 	}
@@ -203,7 +229,7 @@ func (ctrl ServiceVersionController) UpdateServiceVersion(c *gin.Context) {
 // DeleteServiceVersion deletes a service version
 // @Summary Delete a version for a service
 // @Schemes
-// @Description Deletes the specified version of a service
+// @Description Moves the specified version of a service to the trash, it can be restored until it is purged
 // @Tags ServiceVersion
 // @Accept json
 // @Produce json
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// GetSessions returns the active sessions of the current user
+// @Summary List sessions
+// @Description Get the active logins of the current user with the device, IP address and user agent they were created from.
+// @Description The session of the request is marked as current.
+// @Tags Sessions
+// @Produce json
+// @Success 200 {array} models.Session
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/sessions [get]
+func (ctrl UserController) GetSessions(c *gin.Context) {
+	sessions, err := sessionModel.All(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	currentSessionID := utils.GetSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession logs out a session of the current user
+// @Summary Revoke a session
+// @Description Log out a session, its access tokens and refresh token stop working
+// @Tags Sessions
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/sessions/{sessionId} [delete]
+func (ctrl UserController) RevokeSession(c *gin.Context) {
+	if err := sessionModel.Revoke(c.Request.Context(), utils.GetUserID(c), c.Param("sessionId")); err != nil {
+		if errors.Is(err, models.ErrSessionNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Session not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions logs out every session of the current user
+// @Summary Log out everywhere
+// @Description Log out every session of the current user including the one making the request
+// @Tags Sessions
+// @Produce json
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/sessions [delete]
+func (ctrl UserController) RevokeAllSessions(c *gin.Context) {
+	if err := sessionModel.RevokeAll(c.Request.Context(), utils.GetUserID(c)); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
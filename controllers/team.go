@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+type TeamController struct{}
+
+var teamModel = models.TeamModel{}
+var teamForm = forms.TeamForm{}
+
+// findTeam responds with 404 when the team is not in the organization, ok is false when the request was aborted
+func findTeam(c *gin.Context) (team models.Team, ok bool) {
+	team, isFound, err := teamModel.One(c.Request.Context(), c.Param("teamId"), c.Param("orgId"))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, "Team not found")
+			return models.Team{}, false
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get team")
+		return models.Team{}, false
+	}
+	return team, true
+}
+
+// CreateTeam creates a team in the organization
+// @Summary Create team
+// @Description Create a team in the organization, teams can be granted access to specific services. Once the organization has a team,
+// @Description members other than owners and admins only see the services granted to their teams.
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param team body forms.CreateTeamForm true "Team"
+// @Success 201 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams [post]
+func (ctrl TeamController) CreateTeam(c *gin.Context) {
+	var form forms.CreateTeamForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := teamForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	team, err := teamModel.Create(c.Request.Context(), c.Param("orgId"), form)
+	if err != nil {
+		if errors.Is(err, models.ErrTeamNameTaken) {
+			models.AbortWithError(c, http.StatusConflict, "A team with this name already exists")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Team could not be created")
+		return
+	}
+
+	c.JSON(http.StatusCreated, team)
+}
+
+// GetTeams returns the teams of the organization
+// @Summary List teams
+// @Description Get the teams of the organization
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param q query string false "Search the name of teams"
+// @Param sort_by query string false "Sort field" Enums(name, created_at, updated_at)
+// @Param sort query string false "Sort direction" Enums(asc, desc)
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResult[models.Team]
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams [get]
+func (ctrl TeamController) GetTeams(c *gin.Context) {
+	q := c.Query("q")
+	sortBy, sort := models.ParseSortParams(c, models.GetTeamValidSortFields(), "name")
+	page, perPage := models.ParsePaginationParams(c)
+
+	result, err := teamModel.All(c.Request.Context(), c.Param("orgId"), q, sortBy, sort, page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to fetch teams")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTeam returns a team with its members and service grants
+// @Summary Get team
+// @Description Get a team of the organization along with its members and the services it was granted access to
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Success 200 {object} models.Team
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId} [get]
+func (ctrl TeamController) GetTeam(c *gin.Context) {
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// UpdateTeam updates the name and description of a team
+// @Summary Update team
+// @Description Update the name and description of a team
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Param team body forms.CreateTeamForm true "Team"
+// @Success 200 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId} [put]
+func (ctrl TeamController) UpdateTeam(c *gin.Context) {
+	var form forms.CreateTeamForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := teamForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	if _, ok := findTeam(c); !ok {
+		return
+	}
+
+	team, err := teamModel.Update(c.Request.Context(), c.Param("teamId"), c.Param("orgId"), form)
+	if err != nil {
+		if errors.Is(err, models.ErrTeamNameTaken) {
+			models.AbortWithError(c, http.StatusConflict, "A team with this name already exists")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Team could not be updated")
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam deletes a team
+// @Summary Delete team
+// @Description Delete a team along with its members and service grants, services no other team has access to are left to owners and admins
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId} [delete]
+func (ctrl TeamController) DeleteTeam(c *gin.Context) {
+	if _, ok := findTeam(c); !ok {
+		return
+	}
+
+	if err := teamModel.Delete(c.Request.Context(), c.Param("teamId"), c.Param("orgId")); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Team could not be deleted")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddTeamMember adds a member of the organization to a team
+// @Summary Add team member
+// @Description Add a member of the organization to the team, adding a member of the team again has no effect
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Param userId path string true "User ID"
+// @Success 204 ""
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId}/members/{userId} [put]
+func (ctrl TeamController) AddTeamMember(c *gin.Context) {
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	if err := teamModel.AddMember(c.Request.Context(), team, c.Param("userId")); err != nil {
+		if errors.Is(err, models.ErrMemberNotFound) {
+			models.AbortWithError(c, http.StatusBadRequest, "User is not a member of the organization")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to add team member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveTeamMember removes a member from a team
+// @Summary Remove team member
+// @Description Remove a member from the team, the user stays a member of the organization
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Param userId path string true "User ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId}/members/{userId} [delete]
+func (ctrl TeamController) RemoveTeamMember(c *gin.Context) {
+	if _, ok := findTeam(c); !ok {
+		return
+	}
+
+	if err := teamModel.RemoveMember(c.Request.Context(), c.Param("teamId"), c.Param("userId")); err != nil {
+		if errors.Is(err, models.ErrTeamMemberNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Team member not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to remove team member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GrantService gives a team access to a service
+// @Summary Grant service access
+// @Description Give the members of the team read, write or admin access to a service and its versions. Only owners, admins and
+// @Description members of teams with access can see a service of an organization with teams.
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Param serviceId path string true "Service ID"
+// @Param grant body forms.ServiceGrantForm true "Access"
+// @Success 200 {object} models.ServiceGrant
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId}/services/{serviceId} [put]
+func (ctrl TeamController) GrantService(c *gin.Context) {
+	var form forms.ServiceGrantForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := teamForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	if _, ok := findTeam(c); !ok {
+		return
+	}
+
+	serviceID := c.Param("serviceId")
+	_, isFound, err := serviceModel.One(c.Request.Context(), serviceID, c.Param("orgId"), false)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, "Service not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get service")
+		return
+	}
+
+	grant, err := teamModel.Grant(c.Request.Context(), c.Param("teamId"), serviceID, models.ServiceAccess(form.Access))
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to grant service access")
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+// RevokeService removes the access of a team to a service
+// @Summary Revoke service access
+// @Description Remove the access of the team to a service, the service is left to owners and admins once no team has access to it
+// @Tags Teams
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param teamId path string true "Team ID"
+// @Param serviceId path string true "Service ID"
+// @Success 204 ""
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/teams/{teamId}/services/{serviceId} [delete]
+func (ctrl TeamController) RevokeService(c *gin.Context) {
+	if _, ok := findTeam(c); !ok {
+		return
+	}
+
+	if err := teamModel.Revoke(c.Request.Context(), c.Param("teamId"), c.Param("serviceId")); err != nil {
+		if errors.Is(err, models.ErrServiceGrantNotFound) {
+			models.AbortWithError(c, http.StatusNotFound, "Service grant not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to revoke service access")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
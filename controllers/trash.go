@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type TrashController struct{}
+
+var trashModel = models.TrashModel{}
+
+// GetDeletedServices returns the services in the trash of the organization
+// @Summary List deleted services
+// @Description Get the services in the trash of the organization, most recently deleted first. Deleted services
+// @Description can be restored until purgeAt, which is TRASH_RETENTION_DAYS after they were deleted.
+// @Tags Trash
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param q query string false "Search the name of deleted services"
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResult[models.DeletedService]
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/trash/services [get]
+func (ctrl TrashController) GetDeletedServices(c *gin.Context) {
+	page, perPage := models.ParsePaginationParams(c)
+	viewer := models.ServiceViewer{UserID: utils.GetUserID(c), Role: models.GetOrganizationRole(c)}
+
+	result, err := trashModel.Services(c.Request.Context(), c.Param("orgId"), viewer, c.Query("q"), page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get deleted services")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDeletedServiceVersions returns the versions in the trash of services of the organization
+// @Summary List deleted versions
+// @Description Get the versions which were deleted on their own from services of the organization, most recently deleted first.
+// @Description Versions deleted along with their service are restored with the service.
+// @Tags Trash
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResult[models.DeletedServiceVersion]
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/trash/versions [get]
+func (ctrl TrashController) GetDeletedServiceVersions(c *gin.Context) {
+	page, perPage := models.ParsePaginationParams(c)
+	viewer := models.ServiceViewer{UserID: utils.GetUserID(c), Role: models.GetOrganizationRole(c)}
+
+	result, err := trashModel.Versions(c.Request.Context(), c.Param("orgId"), viewer, page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get deleted versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreService brings back a service from the trash
+// @Summary Restore a service
+// @Description Restore a service from the trash along with the versions which were deleted with it
+// @Tags Trash
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Success 200 {object} models.Service
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/restore [post]
+func (ctrl TrashController) RestoreService(c *gin.Context) {
+	service, err := trashModel.RestoreService(c.Request.Context(), c.Param("orgId"), c.Param("serviceId"))
+	if err != nil {
+		if errors.Is(err, models.ErrNotInTrash) {
+			models.AbortWithError(c, http.StatusNotFound, "Service not found in the trash")
+			return
+		}
+		if abortQuotaExceeded(c, err) {
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Service could not be restored")
+		return
+	}
+
+	c.JSON(http.StatusOK, service)
+}
+
+// RestoreServiceVersion brings back a version from the trash
+// @Summary Restore a version
+// @Description Restore a version of a service which was deleted on its own from the trash, versions of retired services can't be restored
+// @Tags Trash
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param serviceId path string true "Service ID"
+// @Param versionId path string true "Service Version ID"
+// @Success 200 {object} models.ServiceVersion
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/services/{serviceId}/versions/{versionId}/restore [post]
+func (ctrl TrashController) RestoreServiceVersion(c *gin.Context) {
+	serviceID := c.Param("serviceId")
+	_, isFound, err := serviceModel.One(c.Request.Context(), serviceID, c.Param("orgId"), false)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusNotFound, "Service not found")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get service")
+		return
+	}
+
+	version, err := trashModel.RestoreVersion(c.Request.Context(), serviceID, c.Param("versionId"))
+	if err != nil {
+		if errors.Is(err, models.ErrNotInTrash) {
+			models.AbortWithError(c, http.StatusNotFound, "Service version not found in the trash")
+			return
+		}
+		if abortQuotaExceeded(c, err) {
+			return
+		}
+		if errors.Is(err, models.ErrServiceRetired) {
+			models.AbortWithError(c, http.StatusConflict, "Versions of a retired service can't be restored")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Service version could not be restored")
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// GetDeletedOrganizations returns the organizations in the trash the user owned
+// @Summary List deleted organizations
+// @Description Get the organizations in the trash the user was an owner of when they were deleted, most recently deleted first
+// @Tags Trash
+// @Produce json
+// @Param page query int false "Page number" default(0)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResult[models.DeletedOrganization]
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /trash/orgs [get]
+func (ctrl TrashController) GetDeletedOrganizations(c *gin.Context) {
+	page, perPage := models.ParsePaginationParams(c)
+
+	result, err := trashModel.Organizations(c.Request.Context(), utils.GetUserID(c), page, perPage)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Could not get deleted organizations")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreOrganization brings back an organization from the trash
+// @Summary Restore an organization
+// @Description Restore an organization from the trash along with the services, versions, teams and memberships which were deleted with it.
+// @Description Only owners of the organization at the time it was deleted can restore it, pending invitations don't come back.
+// @Tags Trash
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 200 {object} models.Organization
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /orgs/{orgId}/restore [post]
+func (ctrl TrashController) RestoreOrganization(c *gin.Context) {
+	organization, err := trashModel.RestoreOrganization(c.Request.Context(), c.Param("orgId"), utils.GetUserID(c))
+	if err != nil {
+		if errors.Is(err, models.ErrNotInTrash) {
+			models.AbortWithError(c, http.StatusNotFound, "Organization not found in the trash")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Organization could not be restored")
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
@@ -1,12 +1,18 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thilak009/kong-assignment/forms"
 	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
 	"github.com/thilak009/kong-assignment/utils"
 )
 
@@ -14,17 +20,23 @@ type UserController struct{}
 
 var userModel = models.UserModel{}
 var userForm = forms.UserForm{}
+var refreshTokenModel = models.RefreshTokenModel{}
+var passwordResetTokenModel = models.PasswordResetTokenModel{}
+var emailVerificationTokenModel = models.EmailVerificationTokenModel{}
+var loginAttemptModel = models.LoginAttemptModel{}
+var sessionModel = models.SessionModel{}
 
 // Register creates a new user account
 // @Summary Register a new user
 // @Description Register a new user account. Password must be at least 8 characters and contain at least one uppercase letter, one lowercase letter, and one special character.
+// @Description A verification link is emailed to the user, the account has to be verified with POST /users/verify before logging in.
+// @Description The response is the same whether or not an account already exists for the email.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param user body forms.CreateUserForm true "User registration data"
-// @Success 201 {object} models.User
+// @Success 202 {object} models.MessageResponse
 // @Failure 400 {object} models.ErrorResponse
-// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/register [post]
 func (ctrl UserController) Register(c *gin.Context) {
@@ -37,16 +49,24 @@ func (ctrl UserController) Register(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	_, exists, err := userModel.FindByEmail(c.Request.Context(), form.Email)
+	existingUser, exists, err := userModel.FindByEmail(c.Request.Context(), form.Email)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to check user existence")
 		return
 	}
+
+	// respond the same way in all cases to avoid email enumeration, the owner of the email is told what happened
 	if exists {
-		// TODO: avoid username enumeration
-		// ideally there should be a email verification flow so that all register calls
-		// return something like check your email for link kind of response
-		models.AbortWithError(c, http.StatusConflict, "User with this email already exists")
+		if existingUser.IsVerified() {
+			err = mailer.Send(c.Request.Context(), accountExistsEmail(existingUser.Email, existingUser.Name))
+		} else {
+			err = sendVerificationEmail(c, existingUser)
+		}
+		if err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to create user")
+			return
+		}
+		c.JSON(http.StatusAccepted, registrationResponse)
 		return
 	}
 
@@ -57,19 +77,113 @@ func (ctrl UserController) Register(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, user)
+	if err := sendVerificationEmail(c, user); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, registrationResponse)
+}
+
+var registrationResponse = models.MessageResponse{
+	Message: "Please check your email to verify your account",
+}
+
+// sendVerificationEmail issues a new verification token for the user and emails it
+func sendVerificationEmail(c *gin.Context, user models.User) error {
+	token, err := emailVerificationTokenModel.Create(c.Request.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(c.Request.Context(), verificationEmail(user.Email, user.Name, token, models.EmailVerificationTokenTTL()))
+}
+
+// VerifyEmail activates an account using the token from the verification email
+// @Summary Verify email
+// @Description Verify the email of an account using the token from the verification email. The token can be used only once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.VerifyEmailForm true "Verification token"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/verify [post]
+func (ctrl UserController) VerifyEmail(c *gin.Context) {
+	var form forms.VerifyEmailForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, err := emailVerificationTokenModel.Verify(c.Request.Context(), form.Token)
+	if err != nil {
+		if errors.Is(err, models.ErrEmailVerificationTokenInvalid) {
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid or expired verification token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification emails a new verification link
+// @Summary Resend verification email
+// @Description Sends a new verification email if an unverified account exists for the email, earlier links stop working.
+// @Description The response is the same whether or not the account exists.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.ResendVerificationForm true "Email of the account"
+// @Success 202 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/verify/resend [post]
+func (ctrl UserController) ResendVerification(c *gin.Context) {
+	var form forms.ResendVerificationForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, exists, err := userModel.FindByEmail(c.Request.Context(), form.Email)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to resend verification email")
+		return
+	}
+
+	if exists && !user.IsVerified() {
+		if err := sendVerificationEmail(c, user); err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to resend verification email")
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, registrationResponse)
 }
 
 // Login authenticates a user and returns a JWT token
 // @Summary Login user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return a short lived JWT access token along with a refresh token.
+// @Description When MFA is enabled for the user no tokens are returned, the response is a models.MFAChallengeResponse
+// @Description instead and the challenge token has to be exchanged for tokens at POST /users/login/mfa.
+// @Description After a few failed attempts further attempts have to wait a growing delay and after too many the account
+// @Description (or the client IP) is locked out for a while. The account owner is emailed a password reset link, resetting the password unlocks the account.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param credentials body forms.LoginForm true "User login credentials"
-// @Success 200 {object} map[string]interface{} "Contains user info and JWT token"
+// @Success 200 {object} models.TokenResponse "Tokens, or models.MFAChallengeResponse when MFA is enabled"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts, retry after the number of seconds in the Retry-After header"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/login [post]
 func (ctrl UserController) Login(c *gin.Context) {
@@ -81,38 +195,237 @@ func (ctrl UserController) Login(c *gin.Context) {
 		return
 	}
 
+	// the password is not checked at all while throttled, so that guesses tell nothing
+	if loginThrottled(c, form.Email) {
+		return
+	}
+
 	// Find user by email
 	user, exists, err := userModel.FindByEmail(c.Request.Context(), form.Email)
 	if err != nil {
-		if !exists {
-			models.AbortWithError(c, http.StatusUnauthorized, "Invalid email/password")
-			return
-		}
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to find user")
 		return
 	}
+	if !exists {
+		loginFailed(c, form.Email, nil)
+		return
+	}
 
 	// Check password
 	if !user.CheckPassword(form.Password) {
+		loginFailed(c, form.Email, &user)
+		return
+	}
+
+	// with MFA enabled the password is only the first step, tokens are issued by LoginMFA
+	mfaEnabled, err := userMFAModel.IsEnabled(c.Request.Context(), user.ID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	// the failures are only cleared once the second factor is checked too, see LoginMFA
+	if !mfaEnabled {
+		if err := loginAttemptModel.RecordSuccess(c.Request.Context(), form.Email); err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+			return
+		}
+	}
+
+	if !user.CanAuthenticate() {
+		models.AbortWithError(c, http.StatusForbidden, "Please verify your email before logging in")
+		return
+	}
+
+	if mfaEnabled {
+		challengeToken, err := mfaChallengeModel.Create(c.Request.Context(), user.ID)
+		if err != nil {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+			return
+		}
+		c.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: challengeToken,
+			ExpiresIn:      int(models.MFAChallengeTTL().Seconds()),
+		})
+		return
+	}
+
+	issueTokens(c, user)
+}
+
+// loginThrottled responds with 429 and returns true when the client has to wait before trying to log in to the account
+func loginThrottled(c *gin.Context, email string) bool {
+	retryAfter, err := loginAttemptModel.RetryAfter(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return true
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		models.AbortWithError(c, http.StatusTooManyRequests, "Too many failed login attempts, please try again later")
+		return true
+	}
+	return false
+}
+
+// loginFailed counts the failed login and responds with 401, user is nil when no account exists for the email
+func loginFailed(c *gin.Context, email string, user *models.User) {
+	if recordLoginFailure(c, email, user) {
 		models.AbortWithError(c, http.StatusUnauthorized, "Invalid email/password")
+	}
+}
+
+// recordLoginFailure counts a failed password or MFA code, the request is aborted when ok is false. Lockouts are
+// logged as security events and the owner of a locked account is emailed a password reset link.
+func recordLoginFailure(c *gin.Context, email string, user *models.User) (ok bool) {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	result, err := loginAttemptModel.RecordFailure(ctx, email, ip)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return false
+	}
+
+	lockout := models.LoginThrottle().LockoutDuration
+	if result.IPLocked {
+		log.With(ctx, "security_event", "login_ip_locked", "client_ip", ip).
+			Warnf("client ip %s locked out of login for %s after too many failed attempts", ip, lockout)
+	}
+	if result.AccountLocked {
+		log.With(ctx, "security_event", "login_account_locked", "email", email, "client_ip", ip).
+			Warnf("account %s locked for %s after too many failed login attempts", email, lockout)
+
+		if user != nil {
+			// the login already failed, a missing email only means the user waits for the lockout to end
+			if err := sendAccountLockedEmail(c, *user, lockout); err != nil {
+				log.With(ctx).Errorf("failed to send account locked email to user with id %s :: error: %s", user.ID, err.Error())
+			}
+		}
+	}
+
+	return true
+}
+
+// sendAccountLockedEmail emails the owner of a locked account a password reset link, anyone can lock an account
+// so the links the owner requested before stay valid
+func sendAccountLockedEmail(c *gin.Context, user models.User, lockout time.Duration) error {
+	token, err := passwordResetTokenModel.CreateAdditional(c.Request.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(c.Request.Context(), accountLockedEmail(user.Email, user.Name, token, lockout, models.PasswordResetTokenTTL()))
+}
+
+// issueTokens starts a new session for the user and responds with its access token and refresh token,
+// the refresh tokens of the session are the token family with the ID of the session
+func issueTokens(c *gin.Context, user models.User) {
+	session, err := sessionModel.Create(c.Request.Context(), user.ID, models.SessionInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	token, err := utils.GenerateToken(user.ID, user.Email, session.ID, session.Epoch)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	refreshToken, err := refreshTokenModel.Create(c.Request.Context(), user.ID, session.ID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	})
+}
+
+// RefreshToken exchanges a refresh token for a new access token and refresh token
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token. Refresh tokens are single use, a new refresh token is returned on every call.
+// @Description Presenting an already used refresh token revokes all refresh tokens issued from the same login.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param token body forms.RefreshTokenForm true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/token/refresh [post]
+func (ctrl UserController) RefreshToken(c *gin.Context) {
+	var form forms.RefreshTokenForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	refreshToken, sessionID, userID, err := refreshTokenModel.Rotate(c.Request.Context(), form.RefreshToken)
+	if err != nil {
+		if errors.Is(err, models.ErrRefreshTokenInvalid) || errors.Is(err, models.ErrRefreshTokenReused) {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	user, isFound, err := userModel.One(c.Request.Context(), userID)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	// the grace period for unverified accounts may have ended since the login
+	if !user.CanAuthenticate() {
+		models.AbortWithError(c, http.StatusForbidden, "Please verify your email before logging in")
+		return
+	}
+
+	// refresh tokens issued before sessions were introduced have no session, those logins have to login again
+	session, err := sessionModel.Refresh(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, models.ErrSessionInvalid) {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Email, session.ID, session.Epoch)
 	if err != nil {
 		models.AbortWithError(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
 	c.JSON(http.StatusOK, models.TokenResponse{
-		AccessToken: token,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
 	})
 }
 
-// Logout invalidates the JWT token by adding it to blacklist
+// Logout ends the session of the JWT token
 // @Summary Logout user
-// @Description Invalidate user JWT token
+// @Description End the session of the JWT token, its access tokens and refresh token stop working
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -137,7 +450,16 @@ func (ctrl UserController) Logout(c *gin.Context) {
 		return
 	}
 
-	// Add token to blacklist
+	if claims.SessionID != "" {
+		if err := sessionModel.Revoke(c.Request.Context(), claims.UserID, claims.SessionID); err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to logout")
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// tokens issued before sessions were introduced can only be revoked one by one with the blacklist
 	blacklistModel := models.BlacklistedTokenModel{}
 	tokenHash := utils.HashToken(tokenString)
 
@@ -148,3 +470,77 @@ func (ctrl UserController) Logout(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// ForgotPassword emails a password reset link to the user
+// @Summary Request a password reset
+// @Description Sends an email with a single use password reset token if an account exists for the email.
+// @Description The response is the same whether or not the account exists.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.ForgotPasswordForm true "Email of the account"
+// @Success 202 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/password/forgot [post]
+func (ctrl UserController) ForgotPassword(c *gin.Context) {
+	var form forms.ForgotPasswordForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, exists, err := userModel.FindByEmail(c.Request.Context(), form.Email)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	// don't reveal whether an account exists for the email, the mail is sent in the background and failures after
+	// the lookup are only logged so that neither the response time nor the status differ
+	if exists {
+		token, err := passwordResetTokenModel.Create(c.Request.Context(), user.ID)
+		if err == nil {
+			mailer.SendAsync(c.Request.Context(), passwordResetEmail(user.Email, user.Name, token, models.PasswordResetTokenTTL()))
+		}
+	}
+
+	c.JSON(http.StatusAccepted, models.MessageResponse{
+		Message: "If an account exists for this email, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword sets a new password using a password reset token
+// @Summary Reset password
+// @Description Set a new password using the token from the password reset email. The token can be used only once.
+// @Description All existing refresh tokens of the user are revoked.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.ResetPasswordForm true "Reset token and new password"
+// @Success 204 ""
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/password/reset [post]
+func (ctrl UserController) ResetPassword(c *gin.Context) {
+	var form forms.ResetPasswordForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	if _, err := passwordResetTokenModel.Reset(c.Request.Context(), form.Token, form.Password); err != nil {
+		if errors.Is(err, models.ErrPasswordResetTokenInvalid) {
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid or expired password reset token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
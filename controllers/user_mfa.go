@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+var userMFAModel = models.UserMFAModel{}
+var mfaChallengeModel = models.MFAChallengeModel{}
+
+// LoginMFA completes a login of a user with MFA enabled
+// @Summary Complete login with MFA
+// @Description Exchange the challenge token returned by POST /users/login along with a TOTP code or a recovery code for tokens.
+// @Description Recovery codes can be used only once. The challenge stops working after 5 wrong codes, wrong codes count as failed
+// @Description logins of the account so that they lead to the same delays and lockout as wrong passwords.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.MFALoginForm true "Challenge token and code"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts, retry after the number of seconds in the Retry-After header"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/login/mfa [post]
+func (ctrl UserController) LoginMFA(c *gin.Context) {
+	var form forms.MFALoginForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	userID, err := mfaChallengeModel.UserOf(c.Request.Context(), form.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, models.ErrMFAChallengeInvalid) {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid or expired MFA challenge, please login again")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	user, isFound, err := userModel.One(c.Request.Context(), userID)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid or expired MFA challenge, please login again")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	// codes count against the same throttle as passwords, otherwise new challenges would allow guessing codes forever
+	if loginThrottled(c, user.Email) {
+		return
+	}
+
+	if _, err := mfaChallengeModel.Complete(c.Request.Context(), form.ChallengeToken, form.Code); err != nil {
+		if errors.Is(err, models.ErrMFAChallengeInvalid) {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid or expired MFA challenge, please login again")
+			return
+		}
+		if errors.Is(err, models.ErrMFACodeInvalid) {
+			if recordLoginFailure(c, user.Email, &user) {
+				models.AbortWithError(c, http.StatusUnauthorized, "Invalid code")
+			}
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	if err := loginAttemptModel.RecordSuccess(c.Request.Context(), user.Email); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to login")
+		return
+	}
+
+	if !user.CanAuthenticate() {
+		models.AbortWithError(c, http.StatusForbidden, "Please verify your email before logging in")
+		return
+	}
+
+	issueTokens(c, user)
+}
+
+// EnrollMFA starts TOTP enrollment for the current user
+// @Summary Enroll in MFA
+// @Description Generate a TOTP secret for the current user. The secret has to be added to an authenticator app,
+// @Description MFA is enabled only after confirming with a code from the app at POST /users/me/mfa/confirm.
+// @Description Enrolling again before confirming replaces the secret.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.MFAEnrollmentResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/enroll [post]
+func (ctrl UserController) EnrollMFA(c *gin.Context) {
+	userID := utils.GetUserID(c)
+
+	user, isFound, err := userModel.One(c.Request.Context(), userID)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to enroll in MFA")
+		return
+	}
+
+	secret, err := userMFAModel.Enroll(c.Request.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrMFAAlreadyEnabled) {
+			models.AbortWithError(c, http.StatusConflict, "MFA is already enabled")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to enroll in MFA")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(models.MFAIssuer(), user.Email, secret),
+	})
+}
+
+// ConfirmMFA enables MFA for the current user
+// @Summary Confirm MFA enrollment
+// @Description Enable MFA by confirming the enrollment with a code from the authenticator app.
+// @Description The response contains one time recovery codes which can be used in place of a code, they are shown only once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.MFACodeForm true "Code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/confirm [post]
+func (ctrl UserController) ConfirmMFA(c *gin.Context) {
+	var form forms.MFACodeForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	userID := utils.GetUserID(c)
+
+	recoveryCodes, err := userMFAModel.Confirm(c.Request.Context(), userID, form.Code)
+	if err != nil {
+		if errors.Is(err, models.ErrMFANotEnrolled) {
+			models.AbortWithError(c, http.StatusBadRequest, "Please enroll in MFA before confirming")
+			return
+		}
+		if errors.Is(err, models.ErrMFAAlreadyEnabled) {
+			models.AbortWithError(c, http.StatusConflict, "MFA is already enabled")
+			return
+		}
+		if errors.Is(err, models.ErrMFACodeInvalid) {
+			models.AbortWithError(c, http.StatusBadRequest, "Invalid code")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to enable MFA")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// DisableMFA disables MFA for the current user
+// @Summary Disable MFA
+// @Description Disable MFA for the current user, the password has to be entered again. Remaining recovery codes are deleted.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body forms.DisableMFAForm true "Current password"
+// @Success 204 ""
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/disable [post]
+func (ctrl UserController) DisableMFA(c *gin.Context) {
+	var form forms.DisableMFAForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	userID := utils.GetUserID(c)
+
+	user, isFound, err := userModel.One(c.Request.Context(), userID)
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to disable MFA")
+		return
+	}
+
+	// a stolen access token alone must not be enough to turn off the second factor
+	if !user.CheckPassword(form.Password) {
+		models.AbortWithError(c, http.StatusForbidden, "Invalid password")
+		return
+	}
+
+	if err := userMFAModel.Disable(c.Request.Context(), user.ID); err != nil {
+		if errors.Is(err, models.ErrMFANotEnabled) {
+			models.AbortWithError(c, http.StatusBadRequest, "MFA is not enabled")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to disable MFA")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
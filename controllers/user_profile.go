@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// GetMe returns the profile of the current user
+// @Summary Get current user
+// @Description Get the profile of the current user
+// @Tags Users
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me [get]
+func (ctrl UserController) GetMe(c *gin.Context) {
+	user, isFound, err := userModel.One(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe updates the profile of the current user
+// @Summary Update current user
+// @Description Update the name of the current user
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user body forms.UpdateUserForm true "Profile data"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me [patch]
+func (ctrl UserController) UpdateMe(c *gin.Context) {
+	var form forms.UpdateUserForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	userID := utils.GetUserID(c)
+
+	if _, isFound, err := userModel.One(c.Request.Context(), userID); err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	user, err := userModel.Update(c.Request.Context(), userID, form)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword changes the password of the current user
+// @Summary Change password
+// @Description Change the password of the current user given the current password.
+// @Description All sessions of the user are revoked so that other logins have to authenticate again, a new session is started for the caller and its tokens are returned.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body forms.ChangePasswordForm true "Current and new password"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/password [post]
+func (ctrl UserController) ChangePassword(c *gin.Context) {
+	var form forms.ChangePasswordForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, isFound, err := userModel.One(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	if !user.CheckPassword(form.CurrentPassword) {
+		models.AbortWithError(c, http.StatusForbidden, "Invalid password")
+		return
+	}
+
+	if err := userModel.ChangePassword(c.Request.Context(), user.ID, form.NewPassword); err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	// the session of this request was revoked along with the others
+	issueTokens(c, user)
+}
+
+// DeleteMe deletes the account of the current user
+// @Summary Delete current user
+// @Description Delete the account of the current user, the password has to be entered again.
+// @Description The account can't be deleted while the user is the only member or the only owner of an organization, the organization has to be
+// @Description deleted or the ownership transferred first.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body forms.DeleteUserForm true "Current password"
+// @Success 204 ""
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me [delete]
+func (ctrl UserController) DeleteMe(c *gin.Context) {
+	var form forms.DeleteUserForm
+
+	if err := c.ShouldBindJSON(&form); err != nil {
+		message := userForm.Create(err)
+		models.AbortWithError(c, http.StatusBadRequest, message)
+		return
+	}
+
+	user, isFound, err := userModel.One(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		if !isFound {
+			models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	if !user.CheckPassword(form.Password) {
+		models.AbortWithError(c, http.StatusForbidden, "Invalid password")
+		return
+	}
+
+	if err := userModel.Delete(c.Request.Context(), user.ID); err != nil {
+		if errors.Is(err, models.ErrSoleMember) {
+			models.AbortWithError(c, http.StatusConflict, "You are the only member of an organization, delete the organization before deleting your account")
+			return
+		}
+		if errors.Is(err, models.ErrLastOwner) {
+			models.AbortWithError(c, http.StatusConflict, "You are the only owner of an organization, transfer the ownership or delete the organization before deleting your account")
+			return
+		}
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+type WellKnownController struct{}
+
+// JWKS returns the public keys that can be used to verify access tokens issued by this server.
+// Served at /.well-known/jwks.json (outside of /v1) so that other services can verify tokens
+// without sharing a secret. The key set is empty when tokens are signed with HS256.
+func (ctrl WellKnownController) JWKS(c *gin.Context) {
+	jwks, err := utils.GetJWKS()
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to load signing keys")
+		return
+	}
+
+	// keys change only on rotation, let verifiers cache them for a while
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
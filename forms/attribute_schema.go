@@ -0,0 +1,44 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type AttributeSchemaForm struct{}
+
+// UpdateAttributeSchemaForm registers the JSON Schema the attributes of services or versions are validated against
+type UpdateAttributeSchemaForm struct {
+	Schema map[string]interface{} `json:"schema" binding:"required"`
+}
+
+func (f AttributeSchemaForm) Schema(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		return "Please provide the schema as a JSON object"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f AttributeSchemaForm) Update(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			if err.Field() == "Schema" {
+				return f.Schema(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
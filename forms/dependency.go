@@ -0,0 +1,55 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-playground/validator/v10"
+)
+
+type DependencyForm struct{}
+
+// SetDependencyForm sets the range of versions of another service a version depends on
+type SetDependencyForm struct {
+	Constraint string `json:"constraint" binding:"required,max=100,semverrange"`
+}
+
+// semverRangeValidator validates a range of semantic versions, e.g. ^1.4.0, ~2.1, >=1.2.0 <3.0.0 or 1.x
+func semverRangeValidator(fl validator.FieldLevel) bool {
+	_, err := semver.NewConstraint(fl.Field().String())
+	return err == nil
+}
+
+func (f DependencyForm) Constraint(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		return "Please enter the range of versions the version depends on"
+	case "max":
+		return "Constraint should be at most 100 characters"
+	case "semverrange":
+		return "Constraint should be a range of semantic versions, e.g. ^1.4.0, ~2.1, >=1.2.0 <3.0.0 or 1.x"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f DependencyForm) Set(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			if err.Field() == "Constraint" {
+				return f.Constraint(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
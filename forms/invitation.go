@@ -0,0 +1,99 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type InvitationForm struct{}
+
+type CreateInvitationForm struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+	Role  string `json:"role" binding:"required,oneof=owner admin editor viewer"`
+	// MembershipDays makes the membership end that many days after the invitation is accepted
+	MembershipDays int `json:"membershipDays" binding:"omitempty,min=1,max=365"`
+}
+
+type InvitationTokenForm struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (f InvitationForm) Email(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the email"
+		}
+		return errMsg[0]
+	case "email", "max":
+		return "Please enter a valid email"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f InvitationForm) Role(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please select the role"
+		}
+		return errMsg[0]
+	case "oneof":
+		return "Role should be one of owner, admin, editor or viewer"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f InvitationForm) MembershipDays(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "min", "max":
+		return "Membership days should be between 1 and 365"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f InvitationForm) Token(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please provide the invitation token"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f InvitationForm) Create(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			if err.Field() == "Email" {
+				return f.Email(err.Tag())
+			}
+			if err.Field() == "Role" {
+				return f.Role(err.Tag())
+			}
+			if err.Field() == "MembershipDays" {
+				return f.MembershipDays(err.Tag())
+			}
+			if err.Field() == "Token" {
+				return f.Token(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
@@ -0,0 +1,86 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type MemberForm struct{}
+
+type UpdateMemberForm struct {
+	Role string `json:"role" binding:"required,oneof=owner admin editor viewer"`
+}
+
+type TransferOwnershipForm struct {
+	UserID string `json:"userId" binding:"required,uuid"`
+}
+
+type ExtendMembershipForm struct {
+	// Days extends the membership, omitting it makes the membership permanent
+	Days *int `json:"days" binding:"omitempty,min=1,max=365"`
+}
+
+func (f MemberForm) Role(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please select the role"
+		}
+		return errMsg[0]
+	case "oneof":
+		return "Role should be one of owner, admin, editor or viewer"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f MemberForm) UserID(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please select the new owner"
+		}
+		return errMsg[0]
+	case "uuid":
+		return "Please provide a valid user id"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f MemberForm) Days(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "min", "max":
+		return "Days should be between 1 and 365"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f MemberForm) Create(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			if err.Field() == "Role" {
+				return f.Role(err.Tag())
+			}
+			if err.Field() == "UserID" {
+				return f.UserID(err.Tag())
+			}
+			if err.Field() == "Days" {
+				return f.Days(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
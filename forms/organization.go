@@ -9,8 +9,10 @@ import (
 type OrganizationForm struct{}
 
 type CreateOrganizationForm struct {
-	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Name        string `json:"name" binding:"required,min=3,max=100,singleline"`
 	Description string `json:"description" binding:"required,min=10,max=1000"`
+	// Slug is generated from the name when empty, on update an empty slug keeps the current one
+	Slug string `json:"slug" binding:"omitempty,slug"`
 }
 
 func (f OrganizationForm) Name(tag string, errMsg ...string) (message string) {
@@ -22,6 +24,8 @@ func (f OrganizationForm) Name(tag string, errMsg ...string) (message string) {
 		return errMsg[0]
 	case "min", "max":
 		return "Name should be between 3 to 100 characters"
+	case "singleline":
+		return "Name can't have line breaks"
 	default:
 		return "Something went wrong, please try again later"
 	}
@@ -41,6 +45,10 @@ func (f OrganizationForm) Description(tag string, errMsg ...string) (message str
 	}
 }
 
+func (f OrganizationForm) Slug(tag string, errMsg ...string) (message string) {
+	return slugMessage(tag)
+}
+
 func (f OrganizationForm) Create(err error) string {
 	switch err.(type) {
 	case validator.ValidationErrors:
@@ -56,6 +64,9 @@ func (f OrganizationForm) Create(err error) string {
 			if err.Field() == "Description" {
 				return f.Description(err.Tag())
 			}
+			if err.Field() == "Slug" {
+				return f.Slug(err.Tag())
+			}
 		}
 
 	default:
@@ -0,0 +1,101 @@
+package forms
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type PersonalAccessTokenForm struct{}
+
+type CreatePersonalAccessTokenForm struct {
+	Name            string   `json:"name" binding:"required,min=3,max=100"`
+	OrganizationIDs []string `json:"organizationIds" binding:"required,min=1,dive,uuid"`
+	Scopes          []string `json:"scopes" binding:"required,min=1,dive,oneof=orgs:read orgs:write services:read services:write versions:read versions:write"`
+	ExpiresInDays   int      `json:"expiresInDays" binding:"required,min=1,max=365"`
+}
+
+func (f PersonalAccessTokenForm) Name(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the name"
+		}
+		return errMsg[0]
+	case "min", "max":
+		return "Name should be between 3 to 100 characters"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f PersonalAccessTokenForm) OrganizationIDs(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required", "min":
+		if len(errMsg) == 0 {
+			return "Please provide at least one organization"
+		}
+		return errMsg[0]
+	case "uuid":
+		return "Organization IDs should be valid UUIDs"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f PersonalAccessTokenForm) Scopes(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required", "min":
+		if len(errMsg) == 0 {
+			return "Please provide at least one scope"
+		}
+		return errMsg[0]
+	case "oneof":
+		return "Scopes should be one of orgs:read, orgs:write, services:read, services:write, versions:read, versions:write"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f PersonalAccessTokenForm) ExpiresInDays(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required", "min", "max":
+		if len(errMsg) == 0 {
+			return "Expiry should be between 1 to 365 days"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f PersonalAccessTokenForm) Create(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			// elements of slices are reported with their index, e.g. "Scopes[0]"
+			field, _, _ := strings.Cut(err.Field(), "[")
+			switch field {
+			case "Name":
+				return f.Name(err.Tag())
+			case "OrganizationIDs":
+				return f.OrganizationIDs(err.Tag())
+			case "Scopes":
+				return f.Scopes(err.Tag())
+			case "ExpiresInDays":
+				return f.ExpiresInDays(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
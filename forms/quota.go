@@ -0,0 +1,47 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type QuotaForm struct{}
+
+// UpdateQuotaForm overrides the limits of an organization, a limit left out uses the default and 0 means unlimited
+type UpdateQuotaForm struct {
+	MaxServices           *int `json:"maxServices" binding:"omitempty,min=0"`
+	MaxVersionsPerService *int `json:"maxVersionsPerService" binding:"omitempty,min=0"`
+	MaxMembers            *int `json:"maxMembers" binding:"omitempty,min=0"`
+}
+
+func (f QuotaForm) Limit(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "min":
+		return "Limits can't be negative"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f QuotaForm) Create(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			switch err.Field() {
+			case "MaxServices", "MaxVersionsPerService", "MaxMembers":
+				return f.Limit(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
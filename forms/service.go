@@ -2,6 +2,7 @@ package forms
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -11,11 +12,28 @@ type ServiceForm struct{}
 type CreateServiceForm struct {
 	Name        string `form:"name" json:"name" binding:"required,min=3,max=100"`
 	Description string `form:"description" json:"description" binding:"omitempty,min=10,max=1000"`
+	// Slug is generated from the name when empty
+	Slug   string            `form:"slug" json:"slug" binding:"omitempty,slug"`
+	Labels map[string]string `form:"labels" json:"labels" binding:"omitempty,labels"`
+	// Attributes are validated against the attribute schema of the organization for services
+	Attributes map[string]interface{} `form:"attributes" json:"attributes"`
+	// Lifecycle is active when empty, retired and deprecated services can't be created
+	Lifecycle string `form:"lifecycle" json:"lifecycle" binding:"omitempty,oneof=experimental active"`
 }
 
 type UpdateServiceForm struct {
 	Name        string `form:"name" json:"name" binding:"omitempty,min=3,max=100"`
 	Description string `form:"description" json:"description" binding:"omitempty,min=10,max=1000"`
+	Slug        string `form:"slug" json:"slug" binding:"omitempty,slug"`
+	// Labels replace all labels of the service when provided
+	Labels map[string]string `form:"labels" json:"labels" binding:"omitempty,labels"`
+	// Attributes replace all attributes of the service when provided
+	Attributes map[string]interface{} `form:"attributes" json:"attributes"`
+	// Lifecycle moves the service to another state, the reason replaces the previous one on a state change
+	Lifecycle       string `form:"lifecycle" json:"lifecycle" binding:"omitempty,oneof=experimental active deprecated retired"`
+	LifecycleReason string `form:"lifecycleReason" json:"lifecycleReason" binding:"omitempty,max=500"`
+	// SunsetAt can only be set on deprecated services
+	SunsetAt *time.Time `form:"sunsetAt" json:"sunsetAt" binding:"omitempty"`
 }
 
 func (f ServiceForm) Name(tag string, errMsg ...string) (message string) {
@@ -41,6 +59,32 @@ func (f ServiceForm) Description(tag string, errMsg ...string) (message string)
 	}
 }
 
+func (f ServiceForm) Slug(tag string, errMsg ...string) (message string) {
+	return slugMessage(tag)
+}
+
+func (f ServiceForm) Labels(tag string, errMsg ...string) (message string) {
+	return labelsMessage(tag)
+}
+
+func (f ServiceForm) Lifecycle(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "oneof":
+		return "Lifecycle should be one of experimental, active, deprecated or retired, new services can only be experimental or active"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f ServiceForm) LifecycleReason(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "max":
+		return "Lifecycle reason should be at most 500 characters"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
 func (f ServiceForm) Create(err error) string {
 	switch err.(type) {
 	case validator.ValidationErrors:
@@ -56,6 +100,18 @@ func (f ServiceForm) Create(err error) string {
 			if err.Field() == "Description" {
 				return f.Description(err.Tag())
 			}
+			if err.Field() == "Slug" {
+				return f.Slug(err.Tag())
+			}
+			if err.Field() == "Labels" {
+				return f.Labels(err.Tag())
+			}
+			if err.Field() == "Lifecycle" {
+				return f.Lifecycle(err.Tag())
+			}
+			if err.Field() == "LifecycleReason" {
+				return f.LifecycleReason(err.Tag())
+			}
 		}
 
 	default:
@@ -80,6 +136,18 @@ func (f ServiceForm) Update(err error) string {
 			if err.Field() == "Description" {
 				return f.Description(err.Tag())
 			}
+			if err.Field() == "Slug" {
+				return f.Slug(err.Tag())
+			}
+			if err.Field() == "Labels" {
+				return f.Labels(err.Tag())
+			}
+			if err.Field() == "Lifecycle" {
+				return f.Lifecycle(err.Tag())
+			}
+			if err.Field() == "LifecycleReason" {
+				return f.LifecycleReason(err.Tag())
+			}
 		}
 
 	default:
@@ -91,8 +159,12 @@ func (f ServiceForm) Update(err error) string {
 
 func (f ServiceForm) ValidateUpdate(form UpdateServiceForm) string {
 	// Require at least one field to be provided for PATCH
-	if form.Name == "" && form.Description == "" {
-		return "At least one field (name or description) must be provided"
+	if form.Name == "" && form.Description == "" && form.Slug == "" && form.Labels == nil && form.Attributes == nil &&
+		form.Lifecycle == "" && form.LifecycleReason == "" && form.SunsetAt == nil {
+		return "At least one field (name, description, slug, labels, attributes, lifecycle, lifecycleReason or sunsetAt) must be provided"
+	}
+	if form.SunsetAt != nil && !form.SunsetAt.After(time.Now()) {
+		return "Sunset date must be in the future"
 	}
 	return ""
 }
@@ -10,14 +10,21 @@ import (
 type ServiceVersionForm struct{}
 
 type CreateServiceVersionForm struct {
-	Name        string `form:"name" json:"name" binding:"required,min=3,max=100"`
-	Version     string `form:"version" json:"version" binding:"required,semver"`
-	Description string `form:"description" json:"description" binding:"omitempty,min=10,max=1000"`
+	Name        string            `form:"name" json:"name" binding:"required,min=3,max=100"`
+	Version     string            `form:"version" json:"version" binding:"required,semver"`
+	Description string            `form:"description" json:"description" binding:"omitempty,min=10,max=1000"`
+	Labels      map[string]string `form:"labels" json:"labels" binding:"omitempty,labels"`
+	// Attributes are validated against the attribute schema of the organization for versions
+	Attributes map[string]interface{} `form:"attributes" json:"attributes"`
 }
 
 type UpdateServiceVersionForm struct {
 	Name        string `form:"name" json:"name" binding:"omitempty,min=3,max=100"`
 	Description string `form:"description" json:"description" binding:"omitempty,min=10,max=1000"`
+	// Labels replace all labels of the version when provided
+	Labels map[string]string `form:"labels" json:"labels" binding:"omitempty,labels"`
+	// Attributes replace all attributes of the version when provided
+	Attributes map[string]interface{} `form:"attributes" json:"attributes"`
 }
 
 // semverValidator validates semantic version format (e.g., 1.0.0, 2.1.3-beta)
@@ -64,7 +71,9 @@ func (f ServiceVersionForm) Description(tag string, errMsg ...string) (message s
 	}
 }
 
-
+func (f ServiceVersionForm) Labels(tag string, errMsg ...string) (message string) {
+	return labelsMessage(tag)
+}
 
 func (f ServiceVersionForm) Create(err error) string {
 	switch err.(type) {
@@ -84,6 +93,9 @@ func (f ServiceVersionForm) Create(err error) string {
 			if err.Field() == "Description" {
 				return f.Description(err.Tag())
 			}
+			if err.Field() == "Labels" {
+				return f.Labels(err.Tag())
+			}
 		}
 
 	default:
@@ -108,6 +120,9 @@ func (f ServiceVersionForm) Update(err error) string {
 			if err.Field() == "Description" {
 				return f.Description(err.Tag())
 			}
+			if err.Field() == "Labels" {
+				return f.Labels(err.Tag())
+			}
 		}
 
 	default:
@@ -119,8 +134,8 @@ func (f ServiceVersionForm) Update(err error) string {
 
 func (f ServiceVersionForm) ValidateUpdate(form UpdateServiceVersionForm) string {
 	// Require at least one field to be provided for PATCH
-	if form.Name == "" && form.Description == "" {
-		return "At least one field (name or description) must be provided"
+	if form.Name == "" && form.Description == "" && form.Labels == nil && form.Attributes == nil {
+		return "At least one field (name, description, labels or attributes) must be provided"
 	}
 	return ""
 }
@@ -0,0 +1,82 @@
+package forms
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type TeamForm struct{}
+
+type CreateTeamForm struct {
+	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+}
+
+type ServiceGrantForm struct {
+	Access string `json:"access" binding:"required,oneof=read write admin"`
+}
+
+func (f TeamForm) Name(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the name"
+		}
+		return errMsg[0]
+	case "min", "max":
+		return "Name should be between 3 to 100 characters"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f TeamForm) Description(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "max":
+		return "Description should be at most 1000 characters"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f TeamForm) Access(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please select the access"
+		}
+		return errMsg[0]
+	case "oneof":
+		return "Access should be one of read, write or admin"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f TeamForm) Create(err error) string {
+	switch err.(type) {
+	case validator.ValidationErrors:
+
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "Something went wrong, please try again later"
+		}
+
+		for _, err := range err.(validator.ValidationErrors) {
+			if err.Field() == "Name" {
+				return f.Name(err.Tag())
+			}
+			if err.Field() == "Description" {
+				return f.Description(err.Tag())
+			}
+			if err.Field() == "Access" {
+				return f.Access(err.Tag())
+			}
+		}
+
+	default:
+		return "Invalid request"
+	}
+
+	return "Something went wrong, please try again later"
+}
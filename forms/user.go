@@ -15,7 +15,33 @@ type CreateUserForm struct {
 }
 
 type UpdateUserForm struct {
-	Password string `form:"password" json:"password" binding:"required,min=8,max=100,strongpassword"`
+	Name string `form:"name" json:"name" binding:"required,min=2,max=100"`
+}
+
+type ChangePasswordForm struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=100,strongpassword"`
+}
+
+type DeleteUserForm struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ForgotPasswordForm struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordForm struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=100,strongpassword"`
+}
+
+type VerifyEmailForm struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationForm struct {
+	Email string `json:"email" binding:"required,email"`
 }
 
 type LoginForm struct {
@@ -23,6 +49,24 @@ type LoginForm struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshTokenForm struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type MFALoginForm struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	// Code is either a TOTP code or a recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type MFACodeForm struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFAForm struct {
+	Password string `json:"password" binding:"required"`
+}
+
 func (f UserForm) Email(tag string, errMsg ...string) (message string) {
 	switch tag {
 	case "required":
@@ -53,6 +97,80 @@ func (f UserForm) Password(tag string, errMsg ...string) (message string) {
 	}
 }
 
+func (f UserForm) Name(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the name"
+		}
+		return errMsg[0]
+	case "min", "max":
+		return "Name should be between 2 to 100 characters"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f UserForm) CurrentPassword(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the current password"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f UserForm) RefreshToken(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please provide the refresh token"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f UserForm) Token(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please provide the token"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f UserForm) ChallengeToken(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please provide the challenge token"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+func (f UserForm) Code(tag string, errMsg ...string) (message string) {
+	switch tag {
+	case "required":
+		if len(errMsg) == 0 {
+			return "Please enter the code"
+		}
+		return errMsg[0]
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
 func (f UserForm) Create(err error) string {
 	switch err.(type) {
 	case validator.ValidationErrors:
@@ -65,9 +183,27 @@ func (f UserForm) Create(err error) string {
 			if err.Field() == "Email" {
 				return f.Email(err.Tag())
 			}
-			if err.Field() == "Password" {
+			if err.Field() == "Name" {
+				return f.Name(err.Tag())
+			}
+			if err.Field() == "Password" || err.Field() == "NewPassword" {
 				return f.Password(err.Tag())
 			}
+			if err.Field() == "CurrentPassword" {
+				return f.CurrentPassword(err.Tag())
+			}
+			if err.Field() == "RefreshToken" {
+				return f.RefreshToken(err.Tag())
+			}
+			if err.Field() == "Token" {
+				return f.Token(err.Tag())
+			}
+			if err.Field() == "ChallengeToken" {
+				return f.ChallengeToken(err.Tag())
+			}
+			if err.Field() == "Code" {
+				return f.Code(err.Tag())
+			}
 		}
 
 	default:
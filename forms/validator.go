@@ -1,12 +1,16 @@
 package forms
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/pkg/labels"
 )
 
 // DefaultValidator ...
@@ -42,6 +46,10 @@ func (v *DefaultValidator) lazyinit() {
 		// Register custom validators
 		v.validate.RegisterValidation("semver", semverValidator)
 		v.validate.RegisterValidation("strongpassword", strongPasswordValidator)
+		v.validate.RegisterValidation("slug", slugValidator)
+		v.validate.RegisterValidation("labels", labelsValidator)
+		v.validate.RegisterValidation("semverrange", semverRangeValidator)
+		v.validate.RegisterValidation("singleline", singleLineValidator)
 	})
 }
 
@@ -87,3 +95,55 @@ func strongPasswordValidator(fl validator.FieldLevel) bool {
 
 	return true
 }
+
+// singleLineValidator validates that a text has no line breaks, e.g. names which end up in email headers
+func singleLineValidator(fl validator.FieldLevel) bool {
+	return !strings.ContainsAny(fl.Field().String(), "\r\n")
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// slugValidator validates that a slug:
+// - Is between 3 and 60 characters
+// - Only has lowercase letters and digits separated by single hyphens
+// - Doesn't have the format of an id, which routes take in place of the slug
+func slugValidator(fl validator.FieldLevel) bool {
+	slug := fl.Field().String()
+
+	if len(slug) < 3 || len(slug) > 60 {
+		return false
+	}
+
+	if !slugPattern.MatchString(slug) {
+		return false
+	}
+
+	return uuid.Validate(slug) != nil
+}
+
+func slugMessage(tag string) string {
+	switch tag {
+	case "slug":
+		return "Slug should be 3 to 60 lowercase letters, digits and hyphens and not have the format of an id"
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
+
+// labelsValidator validates labels the way Kubernetes does, see labels.Validate
+func labelsValidator(fl validator.FieldLevel) bool {
+	values, ok := fl.Field().Interface().(map[string]string)
+	if !ok {
+		return false
+	}
+	return labels.Validate(values) == nil
+}
+
+func labelsMessage(tag string) string {
+	switch tag {
+	case "labels":
+		return fmt.Sprintf("Labels can have at most %d keys of an optional DNS subdomain prefix and a name of up to 63 alphanumeric characters, '-', '_' or '.', values have the same format as the name and can be empty", labels.MaxLabels)
+	default:
+		return "Something went wrong, please try again later"
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	stdlog "log"
 	"net/http"
 	"os"
@@ -9,7 +10,9 @@ import (
 	"github.com/thilak009/kong-assignment/models"
 	"github.com/thilak009/kong-assignment/pkg/log"
 	"github.com/thilak009/kong-assignment/pkg/middleware"
+	"github.com/thilak009/kong-assignment/pkg/oidc"
 	"github.com/thilak009/kong-assignment/routes"
+	"github.com/thilak009/kong-assignment/utils"
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
@@ -50,9 +53,24 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Load the keys used to sign access tokens, refuses to start with the default secret in PRODUCTION
+	if err := utils.InitKeyRing(); err != nil {
+		stdlog.Fatalf("error: failed to load JWT signing keys: %s", err.Error())
+	}
+
+	// Load the OpenID Connect identity providers users can sign in with
+	if err := oidc.Init(); err != nil {
+		stdlog.Fatalf("error: failed to load OIDC providers: %s", err.Error())
+	}
+
 	//Start the gin server without default middleware
 	r := gin.New()
 
+	// Client IPs are only taken from forwarding headers set by the configured proxies
+	if err := r.SetTrustedProxies(utils.TrustedProxies()); err != nil {
+		stdlog.Fatalf("error: invalid TRUSTED_PROXIES: %s", err.Error())
+	}
+
 	// Add only the recovery middleware (panics), skip default logger
 	r.Use(gin.Recovery())
 
@@ -66,9 +84,25 @@ func main() {
 
 	//Start PostgreSQL database
 	db.Init()
-	// create the https://www.postgresql.org/docs/current/pgtrgm.html extension before doing auto migrate
-	// improves like operation efficiency for search
-	db.GetDB().Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm;")
+	// Memberships created before roles existed get their role before the column is migrated
+	if err := models.MigrateMembershipRoles(context.Background()); err != nil {
+		stdlog.Fatalf("error: failed to migrate membership roles: %s", err.Error())
+	}
+
+	// Users registered before email verification existed are verified before the column is migrated
+	if err := models.MigrateUserVerification(context.Background()); err != nil {
+		stdlog.Fatalf("error: failed to migrate user verification: %s", err.Error())
+	}
+
+	// Organizations with teams from before grants were required keep restricting their services to the teams
+	if err := models.MigrateServiceGrants(context.Background()); err != nil {
+		stdlog.Fatalf("error: failed to migrate service grants: %s", err.Error())
+	}
+
+	// Organizations and services created before slugs existed get their slug before the unique indexes are migrated
+	if err := models.MigrateSlugs(context.Background()); err != nil {
+		stdlog.Fatalf("error: failed to migrate slugs: %s", err.Error())
+	}
 
 	// Run migrations
 	db.RunMigrations(
@@ -78,8 +112,32 @@ func main() {
 		&models.ServiceVersion{},
 		&models.UserOrganizationMap{},
 		&models.BlacklistedToken{},
+		&models.RefreshToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.PersonalAccessToken{},
+		&models.OIDCLoginState{},
+		&models.UserIdentity{},
+		&models.LoginAttempt{},
+		&models.Session{},
+		&models.Invitation{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.ServiceGrant{},
+		&models.OrganizationQuota{},
+		&models.SlugRedirect{},
+		&models.AttributeSchema{},
+		&models.ServiceDependency{},
 	)
 
+	// The full-text search columns and the search indexes are added after the tables exist
+	if err := models.MigrateSearch(context.Background()); err != nil {
+		stdlog.Fatalf("error: failed to migrate search: %s", err.Error())
+	}
+
 	// Setup API routes
 	routes.SetupRoutes(r)
 
@@ -89,6 +147,7 @@ func main() {
 	r.GET("/", func(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusOK, gin.H{
 			"status": "UP",
+			"caches": models.AuthCacheStats(),
 		})
 	})
 
@@ -0,0 +1,293 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxAttributeFilters is the most attribute filters a list request can have
+	MaxAttributeFilters = 10
+	// maxAttributePathDepth is the most segments the path of an attribute filter can have
+	maxAttributePathDepth = 10
+	// attributeSchemaURL is the location compiled schemas are registered under, it only names the in-memory resource
+	attributeSchemaURL = "mem:///attributes.json"
+)
+
+// ErrInvalidAttributeSchema is returned when an attribute schema doesn't compile, the error says why
+var ErrInvalidAttributeSchema = errors.New("invalid attribute schema")
+
+// errSchemaRefNotAllowed is returned when an attribute schema references a schema by url, only references within
+// the schema are resolved so that compiling it never reads files or goes over the network
+var errSchemaRefNotAllowed = errors.New("attribute schemas can only reference their own definitions")
+
+// AttributeResource is a kind of resource the attributes of which an organization can register a schema for
+type AttributeResource string
+
+const (
+	AttributeServices AttributeResource = "services"
+	AttributeVersions AttributeResource = "versions"
+)
+
+// IsAttributeResource returns whether resource is a kind of resource with attributes
+func IsAttributeResource(resource string) bool {
+	switch AttributeResource(resource) {
+	case AttributeServices, AttributeVersions:
+		return true
+	}
+	return false
+}
+
+// AttributeSchema is the JSON Schema the attributes of the services or versions of an organization are validated
+// against when they are created or updated, attributes stored before the schema was registered aren't revalidated
+type AttributeSchema struct {
+	CreatedAt      time.Time              `json:"createdAt" gorm:"<-:create"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+	OrganizationID string                 `json:"organizationId" gorm:"primaryKey"`
+	Resource       AttributeResource      `json:"resource" gorm:"primaryKey;type:varchar(20)"`
+	Schema         map[string]interface{} `json:"schema" gorm:"serializer:json;type:jsonb;not null"`
+}
+
+// AttributeError is a violation of the attribute schema, Path is the JSON pointer of the offending value
+type AttributeError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidAttributesError is returned when attributes don't validate against the schema of the organization
+type InvalidAttributesError struct {
+	Errors []AttributeError `json:"errors"`
+}
+
+func (e *InvalidAttributesError) Error() string {
+	return fmt.Sprintf("attributes have %d schema violations", len(e.Errors))
+}
+
+// IsInvalidAttributes returns the InvalidAttributesError in the chain of err
+func IsInvalidAttributes(err error) (*InvalidAttributesError, bool) {
+	var attributesErr *InvalidAttributesError
+	ok := errors.As(err, &attributesErr)
+	return attributesErr, ok
+}
+
+// AttributeFilter matches the resources whose attribute at Path equals Value
+type AttributeFilter struct {
+	Path  []string
+	Value interface{}
+}
+
+// ParseAttributeFilters parses attribute filters of the form path=value, the path is a dot separated list of keys
+// into nested objects. The value is read as JSON when it is valid JSON so that numbers, booleans and null match
+// their JSON counterparts and as a string otherwise, a quoted value always matches a string.
+func ParseAttributeFilters(values []string) ([]AttributeFilter, error) {
+	if len(values) > MaxAttributeFilters {
+		return nil, fmt.Errorf("at most %d attribute filters are allowed", MaxAttributeFilters)
+	}
+
+	filters := make([]AttributeFilter, 0, len(values))
+	for _, value := range values {
+		path, raw, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("%q should be of the form path=value", value)
+		}
+
+		segments := strings.Split(strings.TrimSpace(path), ".")
+		if len(segments) > maxAttributePathDepth {
+			return nil, fmt.Errorf("path %q should have at most %d keys", path, maxAttributePathDepth)
+		}
+		for _, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+		}
+
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			parsed = raw
+		}
+		filters = append(filters, AttributeFilter{Path: segments, Value: parsed})
+	}
+	return filters, nil
+}
+
+// emptyAttributes stores attributes left out of a form as an empty object, the filters don't match a JSON null
+func emptyAttributes(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return map[string]interface{}{}
+	}
+	return values
+}
+
+// withAttributes filters tx to the rows whose attributes column has every filtered value. Each filter compiles
+// to a containment (@>) check of the value nested under its path, which uses the GIN index of the column.
+func withAttributes(tx *gorm.DB, column string, filters []AttributeFilter) *gorm.DB {
+	for _, filter := range filters {
+		document := filter.Value
+		for i := len(filter.Path) - 1; i >= 0; i-- {
+			document = map[string]interface{}{filter.Path[i]: document}
+		}
+		// values decoded from JSON always marshal back
+		attribute, _ := json.Marshal(document)
+		tx = tx.Where(column+" @> ?::jsonb", string(attribute))
+	}
+	return tx
+}
+
+// compileAttributeSchema compiles the schema, references to anything but the schema itself are refused
+func compileAttributeSchema(schema map[string]interface{}) (*jsonschema.Schema, error) {
+	document, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, errSchemaRefNotAllowed
+	}
+	if err := compiler.AddResource(attributeSchemaURL, bytes.NewReader(document)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(attributeSchemaURL)
+}
+
+// attributeErrors flattens the causes of a validation error to the violations they end in, sorted by path
+func attributeErrors(validationErr *jsonschema.ValidationError) []AttributeError {
+	var errs []AttributeError
+	var walk func(*jsonschema.ValidationError)
+	walk = func(err *jsonschema.ValidationError) {
+		if len(err.Causes) == 0 {
+			path := err.InstanceLocation
+			if path == "" {
+				path = "/"
+			}
+			errs = append(errs, AttributeError{Path: path, Message: err.Message})
+			return
+		}
+		for _, cause := range err.Causes {
+			walk(cause)
+		}
+	}
+	walk(validationErr)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Path < errs[j].Path
+	})
+	return errs
+}
+
+// validateAttributes validates the attributes against the schema the organization registered for the resource,
+// they are valid when there is none. Returns an InvalidAttributesError when they don't validate.
+func validateAttributes(ctx context.Context, tx *gorm.DB, organizationID string, resource AttributeResource, attributes map[string]interface{}) error {
+	var schemas []AttributeSchema
+	if err := tx.Where("organization_id = ? AND resource = ?", organizationID, resource).Limit(1).Find(&schemas).Error; err != nil {
+		log.With(ctx).Errorf("failed to find %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return err
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+
+	schema, err := compileAttributeSchema(schemas[0].Schema)
+	if err != nil {
+		// the schema compiled when it was registered
+		log.With(ctx).Errorf("failed to compile %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return err
+	}
+
+	// the validator only knows the types encoding/json decodes to, round tripping normalizes the attributes
+	document, err := json.Marshal(emptyAttributes(attributes))
+	if err != nil {
+		return err
+	}
+	var instance interface{}
+	if err := json.Unmarshal(document, &instance); err != nil {
+		return err
+	}
+
+	if err := schema.Validate(instance); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			return &InvalidAttributesError{Errors: attributeErrors(validationErr)}
+		}
+		log.With(ctx).Errorf("failed to validate %s attributes of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return err
+	}
+	return nil
+}
+
+type AttributeSchemaModel struct{}
+
+// One returns the attribute schema the organization registered for the resource
+//
+// returns isFound as false when there is either an error running the query or if the record is not found
+// caller must first check if err is not nil to know whether it is a record not found error
+// or some other error and not directly rely on isFound for record not found case
+func (m AttributeSchemaModel) One(ctx context.Context, organizationID string, resource AttributeResource) (schema AttributeSchema, isFound bool, err error) {
+	db := db.GetDB()
+	if err := db.Where("organization_id = ? AND resource = ?", organizationID, resource).First(&schema).Error; err != nil {
+		log.With(ctx).Errorf("failed to find %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return AttributeSchema{}, !errors.Is(err, gorm.ErrRecordNotFound), err
+	}
+	return schema, true, nil
+}
+
+// Set registers the schema for the resource, replacing the current one
+//
+// Returns ErrInvalidAttributeSchema when the schema doesn't compile.
+func (m AttributeSchemaModel) Set(ctx context.Context, organizationID string, resource AttributeResource, form forms.UpdateAttributeSchemaForm) (schema AttributeSchema, err error) {
+	if _, err := compileAttributeSchema(form.Schema); err != nil {
+		var schemaErr *jsonschema.SchemaError
+		if errors.As(err, &schemaErr) && schemaErr.Err != nil {
+			return AttributeSchema{}, fmt.Errorf("%w: %s", ErrInvalidAttributeSchema, strings.TrimPrefix(schemaErr.Err.Error(), "jsonschema: "))
+		}
+		return AttributeSchema{}, fmt.Errorf("%w: %s", ErrInvalidAttributeSchema, err.Error())
+	}
+
+	db := db.GetDB()
+	now := time.Now()
+	schema = AttributeSchema{
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: organizationID,
+		Resource:       resource,
+		Schema:         form.Schema,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "resource"}},
+		DoUpdates: clause.AssignmentColumns([]string{"schema", "updated_at"}),
+	}).Create(&schema).Error; err != nil {
+		log.With(ctx).Errorf("failed to set %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return AttributeSchema{}, err
+	}
+
+	// the created at of a replaced schema is kept, read it back
+	if err := db.Where("organization_id = ? AND resource = ?", organizationID, resource).First(&schema).Error; err != nil {
+		log.With(ctx).Errorf("failed to find %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return AttributeSchema{}, err
+	}
+	return schema, nil
+}
+
+// Delete removes the schema of the resource, attributes aren't validated anymore afterwards
+func (m AttributeSchemaModel) Delete(ctx context.Context, organizationID string, resource AttributeResource) error {
+	db := db.GetDB()
+	if err := db.Where("organization_id = ? AND resource = ?", organizationID, resource).Delete(&AttributeSchema{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete %s attribute schema of organization with id %s :: error: %s", resource, organizationID, err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/thilak009/kong-assignment/pkg/cache"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// The checks done on every authenticated request are cached in process. Entries are invalidated when the
+// data changes through this instance, other instances see the change once their entries expire
+// so the time to live bounds how long a revoked token or removed member can keep access.
+
+type membershipKey struct {
+	OrganizationID string
+	UserID         string
+}
+
+type cachedSession struct {
+	UserID string
+	Epoch  int
+}
+
+type authCacheSet struct {
+	blacklist  *cache.TTLCache[string, bool]
+	sessions   *cache.TTLCache[string, cachedSession]
+	membership *cache.TTLCache[membershipKey, Role]
+}
+
+var (
+	authCachesOnce    sync.Once
+	authCacheInstance *authCacheSet
+)
+
+// authCaches returns the caches, they are created on first use as the configuration is read from the environment
+func authCaches() *authCacheSet {
+	authCachesOnce.Do(func() {
+		ttl, maxEntries := authCacheConfig()
+		authCacheInstance = &authCacheSet{
+			blacklist:  cache.New[string, bool](ttl, maxEntries),
+			sessions:   cache.New[string, cachedSession](ttl, maxEntries),
+			membership: cache.New[membershipKey, Role](ttl, maxEntries),
+		}
+	})
+	return authCacheInstance
+}
+
+// authCacheConfig returns the time to live (AUTH_CACHE_TTL_SECONDS, default: 30 seconds, 0 disables the caches)
+// and the maximum number of entries of each cache (AUTH_CACHE_MAX_ENTRIES, default: 10000)
+func authCacheConfig() (time.Duration, int) {
+	seconds, err := strconv.Atoi(utils.GetEnv("AUTH_CACHE_TTL_SECONDS", "30"))
+	if err != nil || seconds < 0 {
+		seconds = 30
+	}
+	maxEntries, err := strconv.Atoi(utils.GetEnv("AUTH_CACHE_MAX_ENTRIES", "10000"))
+	if err != nil || maxEntries < 1 {
+		maxEntries = 10000
+	}
+	return time.Duration(seconds) * time.Second, maxEntries
+}
+
+// blacklistFailClosed returns whether a token is treated as revoked when the blacklist can't be checked
+// (TOKEN_BLACKLIST_FAIL_CLOSED, default: false)
+func blacklistFailClosed() bool {
+	failClosed, err := strconv.ParseBool(utils.GetEnv("TOKEN_BLACKLIST_FAIL_CLOSED", "false"))
+	return err == nil && failClosed
+}
+
+// AuthCacheStats returns the hit and miss counters of the caches used for authenticating requests
+func AuthCacheStats() map[string]cache.Stats {
+	caches := authCaches()
+	return map[string]cache.Stats{
+		"blacklist":  caches.blacklist.Stats(),
+		"sessions":   caches.sessions.Stats(),
+		"membership": caches.membership.Stats(),
+	}
+}
+
+// PurgeAuthCaches empties the caches, the counters are kept
+func PurgeAuthCaches() {
+	caches := authCaches()
+	caches.blacklist.Purge()
+	caches.sessions.Purge()
+	caches.membership.Purge()
+}
+
+// invalidateUserSessions drops the cached sessions of the user, it has to be called after the transaction
+// revoking them is committed, otherwise a concurrent request could cache the old state again
+func invalidateUserSessions(userID string) {
+	authCaches().sessions.DeleteFunc(func(_ string, session cachedSession) bool {
+		return session.UserID == userID
+	})
+}
+
+// invalidateMembership drops the cached membership of the user in the organization
+func invalidateMembership(organizationID string, userID string) {
+	authCaches().membership.Delete(membershipKey{OrganizationID: organizationID, UserID: userID})
+}
+
+// invalidateOrganizationMemberships drops the cached memberships of the organization
+func invalidateOrganizationMemberships(organizationID string) {
+	authCaches().membership.DeleteFunc(func(key membershipKey, _ Role) bool {
+		return key.OrganizationID == organizationID
+	})
+}
+
+// invalidateUserMemberships drops the cached memberships of the user
+func invalidateUserMemberships(userID string) {
+	authCaches().membership.DeleteFunc(func(key membershipKey, _ Role) bool {
+		return key.UserID == userID
+	})
+}
@@ -30,6 +30,11 @@ type ErrorResponse struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// MessageResponse is returned by endpoints which have nothing else to return than an informational message
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
 type PaginatedResult[T any] struct {
 	Meta struct {
 		TotalCount  int `json:"totalCount"`
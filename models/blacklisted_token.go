@@ -55,11 +55,17 @@ func (m BlacklistedTokenModel) Create(ctx context.Context, tokenHash, userID str
 		return err
 	}
 
+	authCaches().blacklist.Set(tokenHash, true)
 	return nil
 }
 
-// IsBlacklisted checks if a token hash is in the blacklist and not expired
+// IsBlacklisted checks if a token hash is in the blacklist and not expired, results are cached for a short while.
+// When the blacklist can't be checked the token is assumed to be valid unless TOKEN_BLACKLIST_FAIL_CLOSED is set.
 func (m BlacklistedTokenModel) IsBlacklisted(ctx context.Context, tokenHash string) bool {
+	if blacklisted, ok := authCaches().blacklist.Get(tokenHash); ok {
+		return blacklisted
+	}
+
 	db := db.GetDB()
 	var count int64
 
@@ -69,10 +75,11 @@ func (m BlacklistedTokenModel) IsBlacklisted(ctx context.Context, tokenHash stri
 
 	if err != nil {
 		log.With(ctx).Errorf("failed to check if token is blacklisted :: error: %s", err.Error())
-		// On error, assume token is valid to avoid blocking users
-		return false
+		// On error, assume token is valid to avoid blocking users unless configured otherwise
+		return blacklistFailClosed()
 	}
 
+	authCaches().blacklist.Set(tokenHash, count > 0)
 	return count > 0
 }
 
@@ -93,10 +100,21 @@ func (m BlacklistedTokenModel) CleanupExpired(ctx context.Context) error {
 	return nil
 }
 
-// StartTokenCleanup runs periodic cleanup of expired blacklisted tokens
+// StartTokenCleanup runs periodic cleanup of expired blacklisted, refresh, password reset and email verification tokens
+// along with expired mfa challenges, oidc logins, login attempts, sessions, invitations and memberships
 func StartTokenCleanup() {
 	logger := log.GetLogger()
 	blacklistModel := BlacklistedTokenModel{}
+	refreshTokenModel := RefreshTokenModel{}
+	passwordResetTokenModel := PasswordResetTokenModel{}
+	emailVerificationTokenModel := EmailVerificationTokenModel{}
+	mfaChallengeModel := MFAChallengeModel{}
+	oidcLoginStateModel := OIDCLoginStateModel{}
+	loginAttemptModel := LoginAttemptModel{}
+	sessionModel := SessionModel{}
+	invitationModel := InvitationModel{}
+	memberModel := MemberModel{}
+	trashModel := TrashModel{}
 
 	// Get cleanup interval from environment (default: 1 hour)
 	cleanupIntervalHours, err := strconv.Atoi(utils.GetEnv("TOKEN_CLEANUP_INTERVAL_MINUTES", "60"))
@@ -114,10 +132,43 @@ func StartTokenCleanup() {
 		select {
 		case <-ticker.C:
 			logger.Info("running token clean up")
+			for name, stats := range AuthCacheStats() {
+				logger.Infof("%s cache: %d hits, %d misses, %d entries", name, stats.Hits, stats.Misses, stats.Entries)
+			}
 			ctx := context.Background()
 			if err := blacklistModel.CleanupExpired(ctx); err != nil {
 				logger.Errorf("Failed to cleanup expired tokens: %s", err.Error())
 			}
+			if err := refreshTokenModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired refresh tokens: %s", err.Error())
+			}
+			if err := passwordResetTokenModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired password reset tokens: %s", err.Error())
+			}
+			if err := emailVerificationTokenModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired email verification tokens: %s", err.Error())
+			}
+			if err := mfaChallengeModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired mfa challenges: %s", err.Error())
+			}
+			if err := oidcLoginStateModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired oidc login states: %s", err.Error())
+			}
+			if err := loginAttemptModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired login attempts: %s", err.Error())
+			}
+			if err := sessionModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired sessions: %s", err.Error())
+			}
+			if err := invitationModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired invitations: %s", err.Error())
+			}
+			if err := memberModel.CleanupExpired(ctx); err != nil {
+				logger.Errorf("Failed to cleanup expired memberships: %s", err.Error())
+			}
+			if err := trashModel.Purge(ctx); err != nil {
+				logger.Errorf("Failed to purge the trash: %s", err.Error())
+			}
 		}
 	}
 }
@@ -0,0 +1,696 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dependencyGraphLock is the key of the advisory lock changes to the dependency graph take, the cycle check
+// has to see every dependency added before it for the graph to stay acyclic
+const dependencyGraphLock = 7_250_025
+
+var (
+	// ErrDependencyNotFound is returned when removing a dependency the version doesn't have
+	ErrDependencyNotFound = errors.New("dependency not found")
+)
+
+// DependencyCycleError is returned when a dependency would make a service depend on itself, Cycle are the ids
+// of the services along the cycle starting and ending with the service of the version. The ids of the services
+// the viewer can't read are empty.
+type DependencyCycleError struct {
+	Cycle []string `json:"cycle"`
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency would create a cycle of %d services", len(e.Cycle)-1)
+}
+
+// IsDependencyCycle returns the DependencyCycleError in the chain of err
+func IsDependencyCycle(err error) (*DependencyCycleError, bool) {
+	var cycleErr *DependencyCycleError
+	ok := errors.As(err, &cycleErr)
+	return cycleErr, ok
+}
+
+// ServiceDependency records that a version depends on a range of versions of another service, which can be in
+// another organization. The graph of services along the dependencies is kept acyclic.
+type ServiceDependency struct {
+	CreatedAt          time.Time `json:"createdAt" gorm:"<-:create"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+	VersionID          string    `json:"versionId" gorm:"primaryKey"`
+	DependsOnServiceID string    `json:"dependsOnServiceId" gorm:"primaryKey;index"`
+	// ServiceID is the service of the version, the cycle check walks the graph by service
+	ServiceID  string `json:"serviceId" gorm:"index;not null"`
+	Constraint string `json:"constraint" gorm:"column:version_constraint;not null"`
+	// DependsOn and Resolved are set when reading dependencies
+	DependsOn *DependencyService `json:"dependsOn,omitempty" gorm:"-"`
+	Resolved  *ResolvedVersion   `json:"resolved,omitempty" gorm:"-"`
+}
+
+// DependencyService is a service in the dependency graph
+type DependencyService struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Slug           string         `json:"slug"`
+	OrganizationID string         `json:"organizationId"`
+	Lifecycle      LifecycleState `json:"lifecycle"`
+}
+
+// ResolvedVersion is the highest version of a service which satisfies a constraint
+type ResolvedVersion struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// Dependent is a version which depends on a range of versions of a service
+type Dependent struct {
+	ServiceID      string `json:"serviceId"`
+	ServiceName    string `json:"serviceName"`
+	OrganizationID string `json:"organizationId"`
+	VersionID      string `json:"versionId"`
+	Version        string `json:"version"`
+	Constraint     string `json:"constraint"`
+}
+
+// DependencyNode is a dependency in the transitive closure of a version, Depth is 1 for the dependencies of the
+// version itself. Nodes without a resolved version end the path, nothing satisfies their constraint.
+type DependencyNode struct {
+	DependentVersionID string            `json:"dependentVersionId"`
+	Service            DependencyService `json:"service"`
+	Constraint         string            `json:"constraint"`
+	Resolved           *ResolvedVersion  `json:"resolved"`
+	Depth              int               `json:"depth"`
+}
+
+// ImpactStatus is how a version is affected when versions of a service it depends on go away
+type ImpactStatus string
+
+const (
+	// ImpactBreaks means no version outside of the affected ones satisfies the constraint anymore
+	ImpactBreaks ImpactStatus = "breaks"
+	// ImpactAtRisk means the constraint is still satisfied by versions which aren't affected
+	ImpactAtRisk ImpactStatus = "at_risk"
+)
+
+// ImpactedVersion is a version affected by versions of a service going away, Depth is 1 for the versions
+// depending on the service itself and more for the versions depending on versions which break
+type ImpactedVersion struct {
+	Dependent
+	DependsOnServiceID string       `json:"dependsOnServiceId"`
+	Status             ImpactStatus `json:"status"`
+	Depth              int          `json:"depth"`
+}
+
+// ImpactReport lists the versions which break or are at risk when the affected versions of the service go away
+type ImpactReport struct {
+	ServiceID        string            `json:"serviceId"`
+	Versions         string            `json:"versions"`
+	AffectedVersions []ResolvedVersion `json:"affectedVersions"`
+	Impacted         []ImpactedVersion `json:"impacted"`
+}
+
+// DependencyGraphEdge is a dependency of a version of From on the service To
+type DependencyGraphEdge struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	VersionID  string           `json:"versionId"`
+	Version    string           `json:"version"`
+	Constraint string           `json:"constraint"`
+	Resolved   *ResolvedVersion `json:"resolved"`
+}
+
+// DependencyGraph is the graph of services along the dependencies of their versions
+type DependencyGraph struct {
+	Nodes []DependencyService   `json:"nodes"`
+	Edges []DependencyGraphEdge `json:"edges"`
+}
+
+// DependencyViewer is the user the dependency graph is read for, only dependencies between services the user can
+// read are part of it. OrganizationIDs limits them to the organizations of a personal access token, nil means all.
+type DependencyViewer struct {
+	UserID          string
+	OrganizationIDs []string
+}
+
+// readableServices is a subquery of the ids of the services the viewer can read in any of their organizations
+func (v DependencyViewer) readableServices(tx *gorm.DB) *gorm.DB {
+	services := joinMemberships(tx.Model(&Service{}).Select("services.id"), "services.organization_id", v.UserID)
+	services = visibleMemberServices(services, v.UserID)
+	if v.OrganizationIDs != nil {
+		services = services.Where("services.organization_id IN ?", v.OrganizationIDs)
+	}
+	return services
+}
+
+// dependencyEdge is a dependency along with its version and service
+type dependencyEdge struct {
+	VersionID          string
+	Version            string
+	ServiceID          string
+	ServiceName        string
+	OrganizationID     string
+	DependsOnServiceID string
+	Constraint         string `gorm:"column:version_constraint"`
+}
+
+func (e dependencyEdge) dependent() Dependent {
+	return Dependent{
+		ServiceID:      e.ServiceID,
+		ServiceName:    e.ServiceName,
+		OrganizationID: e.OrganizationID,
+		VersionID:      e.VersionID,
+		Version:        e.Version,
+		Constraint:     e.Constraint,
+	}
+}
+
+// versionRef is a version of a service with its parsed semantic version
+type versionRef struct {
+	ID      string
+	Version string
+	parsed  *semver.Version
+}
+
+// satisfying returns the versions which satisfy the constraint, highest first like versions
+func satisfying(versions []versionRef, constraint string) []versionRef {
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		// constraints are validated when they are set
+		return nil
+	}
+	var matching []versionRef
+	for _, version := range versions {
+		if constraints.Check(version.parsed) {
+			matching = append(matching, version)
+		}
+	}
+	return matching
+}
+
+// resolve returns the highest of the versions which satisfies the constraint, nil when none does
+func resolve(versions []versionRef, constraint string) *ResolvedVersion {
+	matching := satisfying(versions, constraint)
+	if len(matching) == 0 {
+		return nil
+	}
+	return &ResolvedVersion{ID: matching[0].ID, Version: matching[0].Version}
+}
+
+type DependencyModel struct{}
+
+// edges is the query of the dependencies between services the viewer can read whose version isn't deleted
+func (m DependencyModel) edges(tx *gorm.DB, viewer DependencyViewer) *gorm.DB {
+	return tx.Model(&ServiceDependency{}).
+		Select("service_dependencies.version_id, service_versions.version, service_dependencies.service_id, services.name AS service_name, "+
+			"services.organization_id, service_dependencies.depends_on_service_id, service_dependencies.version_constraint").
+		Joins("JOIN service_versions ON service_versions.id = service_dependencies.version_id AND service_versions.deleted_at IS NULL").
+		Joins("JOIN services ON services.id = service_dependencies.service_id AND services.deleted_at IS NULL").
+		Where("service_dependencies.service_id IN (?) AND service_dependencies.depends_on_service_id IN (?)",
+			viewer.readableServices(tx), viewer.readableServices(tx))
+}
+
+// services returns the services with the ids by id, deleted services are left out
+func (m DependencyModel) services(ctx context.Context, tx *gorm.DB, ids []string) (map[string]DependencyService, error) {
+	var services []DependencyService
+	if err := tx.Model(&Service{}).Select("id, name, slug, organization_id, lifecycle").Where("id IN ?", ids).Scan(&services).Error; err != nil {
+		log.With(ctx).Errorf("failed to find services of dependencies :: error: %s", err.Error())
+		return nil, err
+	}
+
+	byID := make(map[string]DependencyService, len(services))
+	for _, service := range services {
+		byID[service.ID] = service
+	}
+	return byID, nil
+}
+
+// versions returns the versions of the services by service id, highest version first
+func (m DependencyModel) versions(ctx context.Context, tx *gorm.DB, serviceIDs []string) (map[string][]versionRef, error) {
+	var rows []struct {
+		ID        string
+		ServiceID string
+		Version   string
+	}
+	if err := tx.Model(&ServiceVersion{}).Select("id, service_id, version").Where("service_id IN ?", serviceIDs).Scan(&rows).Error; err != nil {
+		log.With(ctx).Errorf("failed to find versions of dependencies :: error: %s", err.Error())
+		return nil, err
+	}
+
+	versions := make(map[string][]versionRef, len(serviceIDs))
+	for _, row := range rows {
+		parsed, err := semver.NewVersion(row.Version)
+		if err != nil {
+			// versions are validated to be semantic versions when they are created
+			continue
+		}
+		versions[row.ServiceID] = append(versions[row.ServiceID], versionRef{ID: row.ID, Version: row.Version, parsed: parsed})
+	}
+	for _, refs := range versions {
+		sort.Slice(refs, func(i, j int) bool {
+			return refs[i].parsed.GreaterThan(refs[j].parsed)
+		})
+	}
+	return versions, nil
+}
+
+// findCycle returns the services along the cycle a dependency of a version of the service on dependsOnServiceID
+// would create, nil when there is none. Dependencies of deleted versions count as well so that restoring a version
+// can't create a cycle.
+func (m DependencyModel) findCycle(ctx context.Context, tx *gorm.DB, serviceID string, dependsOnServiceID string) ([]string, error) {
+	if serviceID == dependsOnServiceID {
+		return []string{serviceID, serviceID}, nil
+	}
+
+	// breadth first from the service depended on, looking for a path back to the service
+	parents := map[string]string{dependsOnServiceID: ""}
+	frontier := []string{dependsOnServiceID}
+	for len(frontier) > 0 {
+		var edges []struct {
+			ServiceID          string
+			DependsOnServiceID string
+		}
+		if err := tx.Model(&ServiceDependency{}).Distinct("service_id", "depends_on_service_id").
+			Where("service_id IN ?", frontier).Scan(&edges).Error; err != nil {
+			log.With(ctx).Errorf("failed to walk dependencies of service with id %s :: error: %s", dependsOnServiceID, err.Error())
+			return nil, err
+		}
+
+		frontier = nil
+		for _, edge := range edges {
+			if _, seen := parents[edge.DependsOnServiceID]; seen {
+				continue
+			}
+			parents[edge.DependsOnServiceID] = edge.ServiceID
+			if edge.DependsOnServiceID != serviceID {
+				frontier = append(frontier, edge.DependsOnServiceID)
+				continue
+			}
+
+			cycle := []string{serviceID}
+			for at := serviceID; at != dependsOnServiceID; {
+				at = parents[at]
+				cycle = append([]string{at}, cycle...)
+			}
+			return append([]string{serviceID}, cycle...), nil
+		}
+	}
+	return nil, nil
+}
+
+// redactCycle returns the cycle with the ids of the services the viewer can't read emptied, the cycle can go
+// through organizations the viewer isn't a member of
+func (m DependencyModel) redactCycle(ctx context.Context, tx *gorm.DB, viewer DependencyViewer, cycle []string) ([]string, error) {
+	var readable []string
+	if err := tx.Model(&Service{}).Where("services.id IN ? AND services.id IN (?)", cycle, viewer.readableServices(tx)).
+		Pluck("services.id", &readable).Error; err != nil {
+		log.With(ctx).Errorf("failed to find readable services of dependency cycle :: error: %s", err.Error())
+		return nil, err
+	}
+
+	isReadable := make(map[string]bool, len(readable))
+	for _, id := range readable {
+		isReadable[id] = true
+	}
+
+	redacted := make([]string, len(cycle))
+	for i, id := range cycle {
+		if isReadable[id] {
+			redacted[i] = id
+		}
+	}
+	return redacted, nil
+}
+
+// Set makes the version depend on the range of versions of the service, replacing the range when it already
+// depends on it. The service has to be readable by the viewer.
+//
+// Returns gorm.ErrRecordNotFound if the viewer can't read the service, ErrServiceRetired if it is retired and
+// a DependencyCycleError if the service already depends on the service of the version.
+func (m DependencyModel) Set(ctx context.Context, viewer DependencyViewer, version ServiceVersion, dependsOnServiceID string, form forms.SetDependencyForm) (dependency ServiceDependency, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dependencyGraphLock).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock dependency graph :: error: %s", err.Error())
+		tx.Rollback()
+		return ServiceDependency{}, err
+	}
+
+	var target Service
+	if err := tx.Where("services.id = ? AND services.id IN (?)", dependsOnServiceID, viewer.readableServices(tx)).First(&target).Error; err != nil {
+		log.With(ctx).Errorf("failed to find service with id %s to depend on :: error: %s", dependsOnServiceID, err.Error())
+		tx.Rollback()
+		return ServiceDependency{}, err
+	}
+	if target.Lifecycle == LifecycleRetired {
+		tx.Rollback()
+		return ServiceDependency{}, ErrServiceRetired
+	}
+
+	cycle, err := m.findCycle(ctx, tx, version.ServiceID, dependsOnServiceID)
+	if err != nil {
+		tx.Rollback()
+		return ServiceDependency{}, err
+	}
+	if cycle != nil {
+		cycle, err = m.redactCycle(ctx, tx, viewer, cycle)
+		tx.Rollback()
+		if err != nil {
+			return ServiceDependency{}, err
+		}
+		return ServiceDependency{}, &DependencyCycleError{Cycle: cycle}
+	}
+
+	now := time.Now()
+	dependency = ServiceDependency{
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		VersionID:          version.ID,
+		DependsOnServiceID: dependsOnServiceID,
+		ServiceID:          version.ServiceID,
+		Constraint:         form.Constraint,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "version_id"}, {Name: "depends_on_service_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version_constraint", "updated_at"}),
+	}).Create(&dependency).Error; err != nil {
+		log.With(ctx).Errorf("failed to set dependency of version with id %s on service with id %s :: error: %s", version.ID, dependsOnServiceID, err.Error())
+		tx.Rollback()
+		return ServiceDependency{}, err
+	}
+	tx.Commit()
+
+	versions, err := m.versions(ctx, db, []string{dependsOnServiceID})
+	if err != nil {
+		return ServiceDependency{}, err
+	}
+	dependency.DependsOn = &DependencyService{
+		ID:             target.ID,
+		Name:           target.Name,
+		Slug:           target.Slug,
+		OrganizationID: target.OrganizationID,
+		Lifecycle:      target.Lifecycle,
+	}
+	dependency.Resolved = resolve(versions[dependsOnServiceID], dependency.Constraint)
+	return dependency, nil
+}
+
+// All returns the dependencies of the version on services the viewer can read along with the highest version
+// of each which satisfies the constraint
+func (m DependencyModel) All(ctx context.Context, viewer DependencyViewer, versionID string) (dependencies []ServiceDependency, err error) {
+	db := db.GetDB()
+	dependencies = make([]ServiceDependency, 0)
+
+	if err := db.Model(&ServiceDependency{}).
+		Where("version_id = ? AND depends_on_service_id IN (?)", versionID, viewer.readableServices(db)).
+		Order("created_at").
+		Find(&dependencies).Error; err != nil {
+		log.With(ctx).Errorf("failed to get dependencies of version with id %s :: error: %s", versionID, err.Error())
+		return nil, err
+	}
+	if len(dependencies) == 0 {
+		return dependencies, nil
+	}
+
+	serviceIDs := make([]string, len(dependencies))
+	for i, dependency := range dependencies {
+		serviceIDs[i] = dependency.DependsOnServiceID
+	}
+	services, err := m.services(ctx, db, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := m.versions(ctx, db, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range dependencies {
+		if service, ok := services[dependencies[i].DependsOnServiceID]; ok {
+			dependencies[i].DependsOn = &service
+		}
+		dependencies[i].Resolved = resolve(versions[dependencies[i].DependsOnServiceID], dependencies[i].Constraint)
+	}
+	return dependencies, nil
+}
+
+// Delete removes the dependency of the version on the service
+//
+// Returns ErrDependencyNotFound if the version doesn't depend on the service.
+func (m DependencyModel) Delete(ctx context.Context, versionID string, dependsOnServiceID string) error {
+	db := db.GetDB()
+
+	result := db.Where("version_id = ? AND depends_on_service_id = ?", versionID, dependsOnServiceID).Delete(&ServiceDependency{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to delete dependency of version with id %s on service with id %s :: error: %s", versionID, dependsOnServiceID, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// Dependents returns the versions the viewer can read which depend on the service of the version with a
+// constraint the version satisfies
+func (m DependencyModel) Dependents(ctx context.Context, viewer DependencyViewer, version ServiceVersion) (dependents []Dependent, err error) {
+	db := db.GetDB()
+	dependents = make([]Dependent, 0)
+
+	var edges []dependencyEdge
+	if err := m.edges(db, viewer).Where("service_dependencies.depends_on_service_id = ?", version.ServiceID).
+		Order("services.name, service_versions.version").
+		Scan(&edges).Error; err != nil {
+		log.With(ctx).Errorf("failed to get dependents of service with id %s :: error: %s", version.ServiceID, err.Error())
+		return nil, err
+	}
+
+	parsed, err := semver.NewVersion(version.Version)
+	if err != nil {
+		return dependents, nil
+	}
+	ref := []versionRef{{ID: version.ID, Version: version.Version, parsed: parsed}}
+	for _, edge := range edges {
+		if len(satisfying(ref, edge.Constraint)) > 0 {
+			dependents = append(dependents, edge.dependent())
+		}
+	}
+	return dependents, nil
+}
+
+// Closure returns the transitive dependencies of the version, each dependency is followed through the highest
+// version which satisfies its constraint. Dependencies on services the viewer can't read are left out.
+func (m DependencyModel) Closure(ctx context.Context, viewer DependencyViewer, version ServiceVersion) (nodes []DependencyNode, err error) {
+	db := db.GetDB()
+	nodes = make([]DependencyNode, 0)
+
+	visited := map[string]bool{version.ID: true}
+	frontier := []string{version.ID}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var edges []dependencyEdge
+		if err := m.edges(db, viewer).Where("service_dependencies.version_id IN ?", frontier).Scan(&edges).Error; err != nil {
+			log.With(ctx).Errorf("failed to get dependencies of version with id %s :: error: %s", version.ID, err.Error())
+			return nil, err
+		}
+		if len(edges) == 0 {
+			break
+		}
+
+		serviceIDs := make([]string, len(edges))
+		for i, edge := range edges {
+			serviceIDs[i] = edge.DependsOnServiceID
+		}
+		services, err := m.services(ctx, db, serviceIDs)
+		if err != nil {
+			return nil, err
+		}
+		versions, err := m.versions(ctx, db, serviceIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		level := make([]DependencyNode, 0, len(edges))
+		frontier = nil
+		for _, edge := range edges {
+			node := DependencyNode{
+				DependentVersionID: edge.VersionID,
+				Service:            services[edge.DependsOnServiceID],
+				Constraint:         edge.Constraint,
+				Resolved:           resolve(versions[edge.DependsOnServiceID], edge.Constraint),
+				Depth:              depth,
+			}
+			level = append(level, node)
+			if node.Resolved != nil && !visited[node.Resolved.ID] {
+				visited[node.Resolved.ID] = true
+				frontier = append(frontier, node.Resolved.ID)
+			}
+		}
+		sort.SliceStable(level, func(i, j int) bool {
+			return level[i].Service.Name < level[j].Service.Name
+		})
+		nodes = append(nodes, level...)
+	}
+	return nodes, nil
+}
+
+// Impact returns the versions the viewer can read which break or are at risk when the versions of the service
+// which satisfy the constraint go away, e.g. 1.x when they are deprecated. A version breaks when all versions
+// satisfying its constraint are affected, which in turn affects the versions depending on it.
+func (m DependencyModel) Impact(ctx context.Context, viewer DependencyViewer, serviceID string, constraint string) (report ImpactReport, err error) {
+	db := db.GetDB()
+	report = ImpactReport{ServiceID: serviceID, Versions: constraint, AffectedVersions: make([]ResolvedVersion, 0), Impacted: make([]ImpactedVersion, 0)}
+
+	versions, err := m.versions(ctx, db, []string{serviceID})
+	if err != nil {
+		return ImpactReport{}, err
+	}
+
+	// broken are the ids of the affected versions and the versions which break by service
+	broken := map[string]map[string]bool{serviceID: {}}
+	for _, version := range satisfying(versions[serviceID], constraint) {
+		broken[serviceID][version.ID] = true
+		report.AffectedVersions = append(report.AffectedVersions, ResolvedVersion{ID: version.ID, Version: version.Version})
+	}
+	if len(report.AffectedVersions) == 0 {
+		return report, nil
+	}
+
+	impacted := map[string]int{}
+	frontier := []string{serviceID}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var edges []dependencyEdge
+		if err := m.edges(db, viewer).Where("service_dependencies.depends_on_service_id IN ?", frontier).
+			Order("services.name, service_versions.version").
+			Scan(&edges).Error; err != nil {
+			log.With(ctx).Errorf("failed to get dependents of service with id %s :: error: %s", serviceID, err.Error())
+			return ImpactReport{}, err
+		}
+
+		missing := []string{}
+		for _, edge := range edges {
+			if _, ok := versions[edge.DependsOnServiceID]; !ok {
+				missing = append(missing, edge.DependsOnServiceID)
+			}
+		}
+		if len(missing) > 0 {
+			loaded, err := m.versions(ctx, db, missing)
+			if err != nil {
+				return ImpactReport{}, err
+			}
+			for id, refs := range loaded {
+				versions[id] = refs
+			}
+		}
+
+		next := map[string]bool{}
+		for _, edge := range edges {
+			matching := satisfying(versions[edge.DependsOnServiceID], edge.Constraint)
+			affected := 0
+			for _, version := range matching {
+				if broken[edge.DependsOnServiceID][version.ID] {
+					affected++
+				}
+			}
+			// constraints nothing satisfies were broken before
+			if affected == 0 {
+				continue
+			}
+
+			status := ImpactAtRisk
+			if affected == len(matching) {
+				status = ImpactBreaks
+			}
+			if i, ok := impacted[edge.VersionID]; ok {
+				if status == ImpactBreaks {
+					report.Impacted[i].Status = ImpactBreaks
+				}
+			} else {
+				impacted[edge.VersionID] = len(report.Impacted)
+				report.Impacted = append(report.Impacted, ImpactedVersion{
+					Dependent:          edge.dependent(),
+					DependsOnServiceID: edge.DependsOnServiceID,
+					Status:             status,
+					Depth:              depth,
+				})
+			}
+
+			if status == ImpactBreaks && !broken[edge.ServiceID][edge.VersionID] {
+				if broken[edge.ServiceID] == nil {
+					broken[edge.ServiceID] = map[string]bool{}
+				}
+				broken[edge.ServiceID][edge.VersionID] = true
+				next[edge.ServiceID] = true
+			}
+		}
+
+		frontier = make([]string, 0, len(next))
+		for id := range next {
+			frontier = append(frontier, id)
+		}
+	}
+	return report, nil
+}
+
+// Graph returns the dependencies of the versions of the organization and the dependencies of versions of other
+// organizations on its services, limited to the services the viewer can read
+func (m DependencyModel) Graph(ctx context.Context, viewer DependencyViewer, organizationID string) (graph DependencyGraph, err error) {
+	db := db.GetDB()
+	graph = DependencyGraph{Nodes: make([]DependencyService, 0), Edges: make([]DependencyGraphEdge, 0)}
+
+	var edges []dependencyEdge
+	if err := m.edges(db, viewer).
+		Where("services.organization_id = ? OR service_dependencies.depends_on_service_id IN (?)",
+			organizationID, db.Model(&Service{}).Select("id").Where("organization_id = ?", organizationID)).
+		Order("services.name, service_versions.version").
+		Scan(&edges).Error; err != nil {
+		log.With(ctx).Errorf("failed to get dependency graph of organization with id %s :: error: %s", organizationID, err.Error())
+		return DependencyGraph{}, err
+	}
+	if len(edges) == 0 {
+		return graph, nil
+	}
+
+	serviceIDs := make([]string, 0, len(edges)*2)
+	for _, edge := range edges {
+		serviceIDs = append(serviceIDs, edge.ServiceID, edge.DependsOnServiceID)
+	}
+	services, err := m.services(ctx, db, serviceIDs)
+	if err != nil {
+		return DependencyGraph{}, err
+	}
+	versions, err := m.versions(ctx, db, serviceIDs)
+	if err != nil {
+		return DependencyGraph{}, err
+	}
+
+	for _, service := range services {
+		graph.Nodes = append(graph.Nodes, service)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Name != graph.Nodes[j].Name {
+			return graph.Nodes[i].Name < graph.Nodes[j].Name
+		}
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, DependencyGraphEdge{
+			From:       edge.ServiceID,
+			To:         edge.DependsOnServiceID,
+			VersionID:  edge.VersionID,
+			Version:    edge.Version,
+			Constraint: edge.Constraint,
+			Resolved:   resolve(versions[edge.DependsOnServiceID], edge.Constraint),
+		})
+	}
+	return graph, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyGraphFormat is a format the dependency graph can be exported in
+type DependencyGraphFormat string
+
+const (
+	DependencyGraphJSON    DependencyGraphFormat = "json"
+	DependencyGraphDOT     DependencyGraphFormat = "dot"
+	DependencyGraphMermaid DependencyGraphFormat = "mermaid"
+)
+
+// IsDependencyGraphFormat returns whether format is a format the dependency graph can be exported in
+func IsDependencyGraphFormat(format string) bool {
+	switch DependencyGraphFormat(format) {
+	case DependencyGraphJSON, DependencyGraphDOT, DependencyGraphMermaid:
+		return true
+	}
+	return false
+}
+
+// label is the text of the edge, the version and its constraint along with the version it resolves to
+func (e DependencyGraphEdge) label() string {
+	if e.Resolved == nil {
+		return fmt.Sprintf("%s: %s (unresolved)", e.Version, e.Constraint)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Version, e.Constraint, e.Resolved.Version)
+}
+
+// DOT renders the graph in the Graphviz DOT language, services are the nodes and each dependency of a version
+// is an edge labelled with the version and its constraint
+func (g DependencyGraph) DOT() string {
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.Lifecycle != "" && node.Lifecycle != LifecycleActive {
+			label += fmt.Sprintf("\n(%s)", node.Lifecycle)
+		}
+		fmt.Fprintf(&b, "  \"%s\" [label=\"%s\"];\n", node.ID, quote.Replace(label))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  \"%s\" -> \"%s\" [label=\"%s\"];\n", edge.From, edge.To, quote.Replace(edge.label()))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart like DOT, node ids are positional since Mermaid ids can't
+// have every character of a service id
+func (g DependencyGraph) Mermaid() string {
+	// quotes can't be escaped with a backslash in Mermaid labels
+	quote := strings.NewReplacer(`"`, "#quot;", "\n", "<br/>")
+
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("s%d", i)
+		label := node.Name
+		if node.Lifecycle != "" && node.Lifecycle != LifecycleActive {
+			label += fmt.Sprintf("\n(%s)", node.Lifecycle)
+		}
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node.ID], quote.Replace(label))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", ids[edge.From], quote.Replace(edge.label()), ids[edge.To])
+	}
+	return b.String()
+}
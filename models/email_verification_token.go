@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrEmailVerificationTokenInvalid is returned when a verification token is unknown, expired or already used
+var ErrEmailVerificationTokenInvalid = errors.New("invalid email verification token")
+
+// EmailVerificationToken is a single use token emailed to a user on registration to prove they own the email,
+// only the hash of the token is stored
+type EmailVerificationToken struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	ID        string    `gorm:"primaryKey"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserID    string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+}
+
+func (evt *EmailVerificationToken) BeforeCreate(tx *gorm.DB) (err error) {
+	evt.ID = uuid.New().String()
+	evt.CreatedAt = time.Now()
+	evt.UpdatedAt = time.Now()
+	return
+}
+
+func (evt *EmailVerificationToken) BeforeUpdate(tx *gorm.DB) (err error) {
+	evt.UpdatedAt = time.Now()
+	return
+}
+
+type EmailVerificationTokenModel struct{}
+
+// EmailVerificationTokenTTL returns how long a verification token can be used for (default: 24 hours)
+func EmailVerificationTokenTTL() time.Duration {
+	hours, err := strconv.Atoi(utils.GetEnv("EMAIL_VERIFICATION_TOKEN_TTL_HOURS", "24"))
+	if err != nil || hours < 1 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Create issues a new verification token for the user and returns the raw token to be emailed,
+// tokens issued earlier for the user which were not used yet are invalidated
+func (m EmailVerificationTokenModel) Create(ctx context.Context, userID string) (token string, err error) {
+	db := db.GetDB()
+
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate email verification token for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	tx := db.Begin()
+
+	// only the latest emailed link should work
+	if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&EmailVerificationToken{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to invalidate email verification tokens for user with id %s :: error: %s", userID, err.Error())
+		tx.Rollback()
+		return "", err
+	}
+
+	verificationToken := EmailVerificationToken{
+		TokenHash: utils.HashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(EmailVerificationTokenTTL()),
+	}
+	if err := tx.Create(&verificationToken).Error; err != nil {
+		log.With(ctx).Errorf("failed to create email verification token for user with id %s :: error: %s", userID, err.Error())
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+	return token, nil
+}
+
+// Verify consumes the verification token and marks the email of the user it was issued for as verified.
+//
+// Returns ErrEmailVerificationTokenInvalid if the token is unknown, expired or already used.
+func (m EmailVerificationTokenModel) Verify(ctx context.Context, token string) (user User, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var verificationToken EmailVerificationToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&verificationToken).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return User{}, ErrEmailVerificationTokenInvalid
+		}
+		log.With(ctx).Errorf("failed to find email verification token :: error: %s", err.Error())
+		return User{}, err
+	}
+
+	if verificationToken.UsedAt != nil || time.Now().After(verificationToken.ExpiresAt) {
+		tx.Rollback()
+		return User{}, ErrEmailVerificationTokenInvalid
+	}
+
+	if err := tx.Model(&verificationToken).Update("used_at", time.Now()).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark email verification token %s as used :: error: %s", verificationToken.ID, err.Error())
+		tx.Rollback()
+		return User{}, err
+	}
+
+	if err := (UserModel{}).markVerified(ctx, tx, verificationToken.UserID); err != nil {
+		tx.Rollback()
+		return User{}, err
+	}
+
+	if err := tx.Where("id = ?", verificationToken.UserID).First(&user).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return User{}, ErrEmailVerificationTokenInvalid
+		}
+		log.With(ctx).Errorf("failed to find user with id %s :: error: %s", verificationToken.UserID, err.Error())
+		return User{}, err
+	}
+
+	tx.Commit()
+	return user, nil
+}
+
+// CleanupExpired removes expired and used email verification tokens
+func (m EmailVerificationTokenModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ? OR used_at IS NOT NULL", time.Now()).Delete(&EmailVerificationToken{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired email verification tokens :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired email verification tokens", result.RowsAffected)
+	}
+
+	return nil
+}
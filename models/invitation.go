@@ -0,0 +1,296 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvitationInvalid is returned when an invitation token is unknown, expired or the invitation is no longer pending
+	ErrInvitationInvalid = errors.New("invalid invitation")
+	// ErrInvitationNotFound is returned when revoking an invitation which doesn't exist or is no longer pending
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationEmailMismatch is returned when accepting an invitation sent to another email
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to another email")
+	// ErrAlreadyMember is returned when inviting or adding a user who already is a member of the organization
+	ErrAlreadyMember = errors.New("user is already a member of the organization")
+)
+
+// InvitationStatus is the state of an invitation, only pending invitations can be accepted, declined or revoked
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+	InvitationRevoked  InvitationStatus = "revoked"
+)
+
+// Invitation invites an email to join an organization with a role, the email doesn't need to have an account yet.
+// Only the hash of the emailed token is stored.
+type Invitation struct {
+	CreatedAt      time.Time        `json:"createdAt" gorm:"<-:create"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+	ID             string           `json:"id" gorm:"primaryKey"`
+	OrganizationID string           `json:"organizationId" gorm:"index"`
+	Email          string           `json:"email" gorm:"index"`
+	Role           Role             `json:"role" gorm:"type:varchar(20);not null"`
+	InvitedBy      string           `json:"invitedBy"`
+	Status         InvitationStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	TokenHash      string           `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt      time.Time        `json:"expiresAt" gorm:"index"`
+	// MembershipDays makes the membership time-bound, it ends that many days after the invitation is accepted
+	MembershipDays int `json:"membershipDays,omitempty"`
+	// Relationships
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.New().String()
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = time.Now()
+	return
+}
+
+func (i *Invitation) BeforeUpdate(tx *gorm.DB) (err error) {
+	i.UpdatedAt = time.Now()
+	return
+}
+
+type InvitationModel struct{}
+
+// InvitationTTL returns how long an invitation can be accepted for (default: 7 days)
+func InvitationTTL() time.Duration {
+	hours, err := strconv.Atoi(utils.GetEnv("INVITATION_TTL_HOURS", "168"))
+	if err != nil || hours < 1 {
+		hours = 168
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// normalizeEmail is used for matching invitations to accounts, emails are compared case insensitively
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Create invites the email to the organization and returns the raw token to be emailed. A pending invitation
+// of the email to the organization is replaced so that only the latest emailed link works.
+// membershipDays is 0 for a membership which doesn't end.
+//
+// Returns ErrAlreadyMember if an account with the email already is a member of the organization and
+// ErrOwnerMembershipExpiry if an owner is invited for a limited time.
+func (m InvitationModel) Create(ctx context.Context, orgID string, email string, role Role, membershipDays int, invitedBy string) (invitation Invitation, token string, err error) {
+	db := db.GetDB()
+	email = normalizeEmail(email)
+
+	if role == RoleOwner && membershipDays > 0 {
+		return Invitation{}, "", ErrOwnerMembershipExpiry
+	}
+
+	var members int64
+	if err := activeMemberships(db.Model(&UserOrganizationMap{})).
+		Joins("JOIN users ON users.id = user_organization_maps.user_id AND users.deleted_at IS NULL").
+		Where("user_organization_maps.organization_id = ? AND LOWER(users.email) = ?", orgID, email).
+		Count(&members).Error; err != nil {
+		log.With(ctx).Errorf("failed to check membership of %s in organization with id %s :: error: %s", email, orgID, err.Error())
+		return Invitation{}, "", err
+	}
+	if members > 0 {
+		return Invitation{}, "", ErrAlreadyMember
+	}
+
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate invitation token for organization with id %s :: error: %s", orgID, err.Error())
+		return Invitation{}, "", err
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Model(&Invitation{}).
+		Where("organization_id = ? AND email = ? AND status = ?", orgID, email, InvitationPending).
+		Update("status", InvitationRevoked).Error; err != nil {
+		log.With(ctx).Errorf("failed to revoke earlier invitations of %s to organization with id %s :: error: %s", email, orgID, err.Error())
+		tx.Rollback()
+		return Invitation{}, "", err
+	}
+
+	invitation = Invitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		MembershipDays: membershipDays,
+		InvitedBy:      invitedBy,
+		Status:         InvitationPending,
+		TokenHash:      utils.HashToken(token),
+		ExpiresAt:      time.Now().Add(InvitationTTL()),
+	}
+	if err := tx.Create(&invitation).Error; err != nil {
+		log.With(ctx).Errorf("failed to create invitation to organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Invitation{}, "", err
+	}
+
+	tx.Commit()
+	return invitation, token, nil
+}
+
+// All returns the pending invitations of the organization, newest first. Expired invitations are included
+// until they are cleaned up so that they can be sent again.
+func (m InvitationModel) All(ctx context.Context, orgID string) (invitations []Invitation, err error) {
+	db := db.GetDB()
+	invitations = make([]Invitation, 0)
+
+	if err := db.Where("organization_id = ? AND status = ?", orgID, InvitationPending).
+		Order("created_at desc").
+		Find(&invitations).Error; err != nil {
+		log.With(ctx).Errorf("failed to get invitations of organization with id %s :: error: %s", orgID, err.Error())
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// Revoke withdraws a pending invitation of the organization
+//
+// Returns ErrInvitationNotFound if the invitation doesn't exist, belongs to another organization or is no longer pending.
+func (m InvitationModel) Revoke(ctx context.Context, orgID string, invitationID string) error {
+	db := db.GetDB()
+
+	result := db.Model(&Invitation{}).
+		Where("id = ? AND organization_id = ? AND status = ?", invitationID, orgID, InvitationPending).
+		Update("status", InvitationRevoked)
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to revoke invitation with id %s :: error: %s", invitationID, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// Accept adds the user to the organization of the invitation with the invited role.
+// The invitation has to be for the email of the user.
+//
+// Returns ErrInvitationInvalid if the token is unknown, expired or the invitation is no longer pending,
+// ErrInvitationEmailMismatch if it was sent to another email, ErrAlreadyMember if the user already is a member and
+// a QuotaExceededError if the organization reached its limit of members.
+func (m InvitationModel) Accept(ctx context.Context, token string, user User) (invitation Invitation, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	invitation, err = m.findPending(ctx, tx, token)
+	if err != nil {
+		tx.Rollback()
+		return Invitation{}, err
+	}
+
+	if normalizeEmail(user.Email) != invitation.Email {
+		tx.Rollback()
+		return Invitation{}, ErrInvitationEmailMismatch
+	}
+
+	// the organization is locked so that concurrent acceptances are counted one after another
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", invitation.OrganizationID).First(&Organization{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock organization with id %s :: error: %s", invitation.OrganizationID, err.Error())
+		tx.Rollback()
+		return Invitation{}, err
+	}
+
+	if err := (QuotaModel{}).reserveMember(ctx, tx, invitation.OrganizationID); err != nil {
+		tx.Rollback()
+		return Invitation{}, err
+	}
+
+	var membershipExpiresAt *time.Time
+	if invitation.MembershipDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, invitation.MembershipDays)
+		membershipExpiresAt = &expiresAt
+	}
+
+	if err := (OrganizationModel{}).addMember(ctx, tx, invitation.OrganizationID, user.ID, invitation.Role, membershipExpiresAt); err != nil {
+		tx.Rollback()
+		return Invitation{}, err
+	}
+
+	if err := tx.Model(&invitation).Update("status", InvitationAccepted).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark invitation with id %s as accepted :: error: %s", invitation.ID, err.Error())
+		tx.Rollback()
+		return Invitation{}, err
+	}
+
+	tx.Commit()
+	invalidateMembership(invitation.OrganizationID, user.ID)
+	return invitation, nil
+}
+
+// Decline rejects the invitation, anyone who received the token can decline it without an account
+//
+// Returns ErrInvitationInvalid if the token is unknown, expired or the invitation is no longer pending.
+func (m InvitationModel) Decline(ctx context.Context, token string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	invitation, err := m.findPending(ctx, tx, token)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(&invitation).Update("status", InvitationDeclined).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark invitation with id %s as declined :: error: %s", invitation.ID, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// findPending locks the pending invitation of the token
+func (m InvitationModel) findPending(ctx context.Context, tx *gorm.DB, token string) (invitation Invitation, err error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Invitation{}, ErrInvitationInvalid
+		}
+		log.With(ctx).Errorf("failed to find invitation :: error: %s", err.Error())
+		return Invitation{}, err
+	}
+
+	if invitation.Status != InvitationPending || time.Now().After(invitation.ExpiresAt) {
+		return Invitation{}, ErrInvitationInvalid
+	}
+
+	return invitation, nil
+}
+
+// CleanupExpired removes expired invitations and invitations which are no longer pending
+func (m InvitationModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ? OR status <> ?", time.Now(), InvitationPending).Delete(&Invitation{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired invitations :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired invitations", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thilak009/kong-assignment/pkg/labels"
+	"gorm.io/gorm"
+)
+
+// emptyLabels stores labels left out of a form as an empty object, the selectors don't match a JSON null
+func emptyLabels(values map[string]string) map[string]string {
+	if values == nil {
+		return map[string]string{}
+	}
+	return values
+}
+
+// withLabels filters tx to the rows whose labels column meets every requirement of the selector. The requirements
+// compile to containment (@>) and key existence (?) checks on the JSONB column, both of which use its GIN index,
+// != and notin negate them the same way Kubernetes does and also match rows without the label.
+func withLabels(tx *gorm.DB, column string, selector labels.Selector) *gorm.DB {
+	for _, requirement := range selector {
+		switch requirement.Operator {
+		case labels.Equals, labels.In, labels.NotEquals, labels.NotIn:
+			conditions := make([]string, 0, len(requirement.Values))
+			values := make([]interface{}, 0, len(requirement.Values))
+			for _, value := range requirement.Values {
+				// marshalling a map of strings can't fail
+				label, _ := json.Marshal(map[string]string{requirement.Key: value})
+				conditions = append(conditions, column+" @> ?::jsonb")
+				values = append(values, string(label))
+			}
+			condition := "(" + strings.Join(conditions, " OR ") + ")"
+			if requirement.Operator == labels.NotEquals || requirement.Operator == labels.NotIn {
+				condition = "NOT " + condition
+			}
+			tx = tx.Where(condition, values...)
+		case labels.Exists, labels.DoesNotExist:
+			// the key is inlined since a placeholder would clash with the ? operator, validated keys can't
+			// have quotes so this is safe
+			condition := fmt.Sprintf("%s ? '%s'", column, requirement.Key)
+			if requirement.Operator == labels.DoesNotExist {
+				condition = "NOT (" + condition + ")"
+			}
+			tx = tx.Where(condition)
+		}
+	}
+	return tx
+}
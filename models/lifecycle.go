@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+)
+
+// ErrServiceRetired is returned when creating or restoring a version of a retired service
+var ErrServiceRetired = errors.New("service is retired")
+
+// ErrSunsetNotDeprecated is returned when a sunset date is set on a service which isn't deprecated
+var ErrSunsetNotDeprecated = errors.New("sunset date can only be set on deprecated services")
+
+// LifecycleState is the stage of a service in its lifecycle
+type LifecycleState string
+
+const (
+	LifecycleExperimental LifecycleState = "experimental"
+	LifecycleActive       LifecycleState = "active"
+	LifecycleDeprecated   LifecycleState = "deprecated"
+	// LifecycleRetired is final, versions can't be created for retired services anymore
+	LifecycleRetired LifecycleState = "retired"
+)
+
+// lifecycleTransitions are the states a service can move to from each state
+var lifecycleTransitions = map[LifecycleState][]LifecycleState{
+	LifecycleExperimental: {LifecycleActive, LifecycleDeprecated, LifecycleRetired},
+	LifecycleActive:       {LifecycleDeprecated},
+	// a deprecation can be taken back until the service is retired
+	LifecycleDeprecated: {LifecycleActive, LifecycleRetired},
+	LifecycleRetired:    {},
+}
+
+// LifecycleTransitionError is returned when a service can't move from its state to the requested one
+type LifecycleTransitionError struct {
+	From    LifecycleState   `json:"from"`
+	To      LifecycleState   `json:"to"`
+	Allowed []LifecycleState `json:"allowed"`
+}
+
+func (e *LifecycleTransitionError) Error() string {
+	return fmt.Sprintf("service can't move from %s to %s", e.From, e.To)
+}
+
+// IsLifecycleTransitionError returns the LifecycleTransitionError in the chain of err
+func IsLifecycleTransitionError(err error) (*LifecycleTransitionError, bool) {
+	var transitionErr *LifecycleTransitionError
+	ok := errors.As(err, &transitionErr)
+	return transitionErr, ok
+}
+
+// IsLifecycleState returns whether state is one of the lifecycle states
+func IsLifecycleState(state string) bool {
+	_, ok := lifecycleTransitions[LifecycleState(state)]
+	return ok
+}
+
+// CanTransition returns whether a service can move from one state to the other, staying in the same state is allowed
+func CanTransition(from LifecycleState, to LifecycleState) bool {
+	if from == to {
+		return true
+	}
+	for _, allowed := range lifecycleTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLifecycle moves the service to the lifecycle state of the form. A state change replaces the reason and
+// only deprecated services keep a sunset date, the reason and sunset date can also be changed without a state change.
+//
+// Returns a LifecycleTransitionError if the service can't move to the state and ErrSunsetNotDeprecated if a sunset
+// date is set on a service which won't be deprecated.
+func applyLifecycle(service *Service, form forms.UpdateServiceForm) error {
+	to := service.Lifecycle
+	if form.Lifecycle != "" {
+		to = LifecycleState(form.Lifecycle)
+	}
+	if !CanTransition(service.Lifecycle, to) {
+		return &LifecycleTransitionError{From: service.Lifecycle, To: to, Allowed: lifecycleTransitions[service.Lifecycle]}
+	}
+	if form.SunsetAt != nil && to != LifecycleDeprecated {
+		return ErrSunsetNotDeprecated
+	}
+
+	if to != service.Lifecycle {
+		service.Lifecycle = to
+		service.LifecycleReason = form.LifecycleReason
+		if to != LifecycleDeprecated {
+			service.SunsetAt = nil
+		}
+	} else if form.LifecycleReason != "" {
+		service.LifecycleReason = form.LifecycleReason
+	}
+	if form.SunsetAt != nil {
+		service.SunsetAt = form.SunsetAt
+	}
+	return nil
+}
+
+// ensureNotRetired returns ErrServiceRetired if the service is retired, tx has to be the transaction adding the
+// version and the service row has to be locked by it, see QuotaModel.reserveVersion
+func ensureNotRetired(ctx context.Context, tx *gorm.DB, serviceID string) error {
+	var lifecycles []LifecycleState
+	if err := tx.Model(&Service{}).Where("id = ?", serviceID).Pluck("lifecycle", &lifecycles).Error; err != nil {
+		log.With(ctx).Errorf("failed to find lifecycle of service with id %s :: error: %s", serviceID, err.Error())
+		return err
+	}
+	if len(lifecycles) > 0 && lifecycles[0] == LifecycleRetired {
+		return ErrServiceRetired
+	}
+	return nil
+}
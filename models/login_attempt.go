@@ -0,0 +1,243 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttempt counts the recent failed logins of an account or a client IP, Key is
+// "account:<email>" or "ip:<address>"
+type LoginAttempt struct {
+	Key           string `gorm:"primaryKey"`
+	Failures      int
+	LastFailureAt time.Time `gorm:"index"`
+	// BlockedUntil is when the next login may be attempted, it grows with every failure (progressive delay)
+	BlockedUntil *time.Time
+	// LockedUntil is set when the failures reach the lockout threshold
+	LockedUntil *time.Time
+}
+
+// LoginThrottleConfig are the thresholds of the brute force protection on login
+type LoginThrottleConfig struct {
+	// DelayAfter is the number of failures of an account after which every further attempt has to wait,
+	// the wait starts at BaseDelay and doubles with every failure
+	DelayAfter int
+	BaseDelay  time.Duration
+	// MaxFailures of an account within Window lock it for LockoutDuration
+	MaxFailures int
+	// MaxIPFailures of a client IP within Window lock out that IP for LockoutDuration
+	MaxIPFailures   int
+	Window          time.Duration
+	LockoutDuration time.Duration
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(utils.GetEnv(key, strconv.Itoa(fallback)))
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
+
+// LoginThrottle returns the login thresholds from the environment
+func LoginThrottle() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		DelayAfter:      envInt("LOGIN_DELAY_AFTER_FAILURES", 3),
+		BaseDelay:       time.Duration(envInt("LOGIN_BASE_DELAY_SECONDS", 1)) * time.Second,
+		MaxFailures:     envInt("LOGIN_MAX_FAILURES", 10),
+		MaxIPFailures:   envInt("LOGIN_MAX_IP_FAILURES", 50),
+		Window:          time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute,
+		LockoutDuration: time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// delay returns how long an account has to wait after its nth failure, capped at the lockout duration
+func (cfg LoginThrottleConfig) delay(failures int) time.Duration {
+	if failures < cfg.DelayAfter {
+		return 0
+	}
+	exponent := failures - cfg.DelayAfter
+	if exponent > 30 {
+		return cfg.LockoutDuration
+	}
+	delay := cfg.BaseDelay * time.Duration(math.Pow(2, float64(exponent)))
+	if delay > cfg.LockoutDuration {
+		return cfg.LockoutDuration
+	}
+	return delay
+}
+
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginFailureResult tells the caller what a failed login led to
+type LoginFailureResult struct {
+	// AccountLocked is true when this failure locked the account
+	AccountLocked bool
+	// IPLocked is true when this failure locked out the client IP
+	IPLocked bool
+}
+
+type LoginAttemptModel struct{}
+
+// RetryAfter returns how long the client has to wait before it may try to log in to the account, zero if it may try now
+func (m LoginAttemptModel) RetryAfter(ctx context.Context, email string, ip string) (time.Duration, error) {
+	db := db.GetDB()
+
+	var attempts []LoginAttempt
+	if err := db.Where("key IN ?", []string{accountLoginKey(email), ipLoginKey(ip)}).Find(&attempts).Error; err != nil {
+		log.With(ctx).Errorf("failed to find login attempts :: error: %s", err.Error())
+		return 0, err
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, attempt := range attempts {
+		for _, until := range []*time.Time{attempt.BlockedUntil, attempt.LockedUntil} {
+			if until != nil && until.Sub(now) > wait {
+				wait = until.Sub(now)
+			}
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed login against the account and the client IP
+func (m LoginAttemptModel) RecordFailure(ctx context.Context, email string, ip string) (result LoginFailureResult, err error) {
+	cfg := LoginThrottle()
+	db := db.GetDB()
+	tx := db.Begin()
+
+	account, err := m.recordFailure(ctx, tx, accountLoginKey(email), cfg)
+	if err != nil {
+		tx.Rollback()
+		return LoginFailureResult{}, err
+	}
+	client, err := m.recordFailure(ctx, tx, ipLoginKey(ip), cfg)
+	if err != nil {
+		tx.Rollback()
+		return LoginFailureResult{}, err
+	}
+
+	now := time.Now()
+	updates := []struct {
+		attempt   *LoginAttempt
+		threshold int
+		delay     time.Duration
+		locked    *bool
+	}{
+		{account, cfg.MaxFailures, cfg.delay(account.Failures), &result.AccountLocked},
+		// the ip is not slowed down as many users may share one address, it is only locked out
+		{client, cfg.MaxIPFailures, 0, &result.IPLocked},
+	}
+	for _, u := range updates {
+		if u.delay > 0 {
+			blockedUntil := now.Add(u.delay)
+			u.attempt.BlockedUntil = &blockedUntil
+		}
+		if u.attempt.Failures >= u.threshold {
+			lockedUntil := now.Add(cfg.LockoutDuration)
+			u.attempt.LockedUntil = &lockedUntil
+			// the lockout starts a new round of failures once it is over
+			u.attempt.Failures = 0
+			u.attempt.BlockedUntil = nil
+			*u.locked = true
+		}
+		if err := tx.Save(u.attempt).Error; err != nil {
+			log.With(ctx).Errorf("failed to save login attempt :: error: %s", err.Error())
+			tx.Rollback()
+			return LoginFailureResult{}, err
+		}
+	}
+
+	tx.Commit()
+	return result, nil
+}
+
+// recordFailure locks the row of the key and counts one more failure, failures older than the window are forgotten
+func (m LoginAttemptModel) recordFailure(ctx context.Context, tx *gorm.DB, key string, cfg LoginThrottleConfig) (*LoginAttempt, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&LoginAttempt{Key: key, LastFailureAt: time.Now()}).Error; err != nil {
+		log.With(ctx).Errorf("failed to create login attempt :: error: %s", err.Error())
+		return nil, err
+	}
+
+	var attempt LoginAttempt
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&attempt).Error; err != nil {
+		log.With(ctx).Errorf("failed to find login attempt :: error: %s", err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Sub(attempt.LastFailureAt) > cfg.Window {
+		attempt.Failures = 0
+		attempt.BlockedUntil = nil
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	return &attempt, nil
+}
+
+// RecordSuccess forgets the failures of the account after a successful login,
+// failures of the client IP are kept so that one known password can't be used to keep guessing others
+func (m LoginAttemptModel) RecordSuccess(ctx context.Context, email string) error {
+	db := db.GetDB()
+
+	if err := db.Where("key = ?", accountLoginKey(email)).Delete(&LoginAttempt{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to reset login attempts :: error: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// unlockUser removes the lockout of the user's account, used when the user proved they own the email
+func (m LoginAttemptModel) unlockUser(ctx context.Context, tx *gorm.DB, userID string) error {
+	var user User
+	if err := tx.Select("email").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		log.With(ctx).Errorf("failed to find user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+
+	if err := tx.Where("key = ?", accountLoginKey(user.Email)).Delete(&LoginAttempt{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to unlock login of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// CleanupExpired removes login attempts whose failures and lockout are over
+func (m LoginAttemptModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+	now := time.Now()
+
+	result := db.Where("last_failure_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", now.Add(-LoginThrottle().Window), now).
+		Delete(&LoginAttempt{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired login attempts :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired login attempts", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,445 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrMemberNotFound is returned when the user is not a member of the organization
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrLastOwner is returned when removing or demoting the only owner of an organization
+	ErrLastOwner = errors.New("organization needs at least one owner")
+	// ErrSoleMember is returned when deleting the account of the only member of an organization
+	ErrSoleMember = errors.New("user is the only member of an organization")
+	// ErrOwnerRoleRequired is returned when a member who is not an owner changes an owner or makes someone owner
+	ErrOwnerRoleRequired = errors.New("only owners can manage owners")
+	// ErrOwnerMembershipExpiry is returned when making the membership of an owner time-bound
+	ErrOwnerMembershipExpiry = errors.New("owner memberships can't expire")
+	// ErrMembershipExpiryRemoval is returned when a member who isn't an owner makes the time-bound membership of a
+	// member with the same or a higher role permanent
+	ErrMembershipExpiryRemoval = errors.New("only owners and members with a higher role can make a membership permanent")
+)
+
+// MembershipRemovedReason records why a membership ended
+type MembershipRemovedReason string
+
+const (
+	MembershipRemoved        MembershipRemovedReason = "removed"
+	MembershipLeft           MembershipRemovedReason = "left"
+	MembershipExpired        MembershipRemovedReason = "expired"
+	MembershipAccountDeleted MembershipRemovedReason = "account_deleted"
+	// MembershipOrganizationDeleted memberships come back when the organization is restored from the trash
+	MembershipOrganizationDeleted MembershipRemovedReason = "organization_deleted"
+)
+
+// Member is a user of an organization along with the role of the user in it
+type Member struct {
+	UserID    string     `json:"userId"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Role      Role       `json:"role"`
+	JoinedAt  time.Time  `json:"joinedAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// MembershipExpiringSoonWindow returns how long before its end a time-bound membership is flagged as
+// expiring soon (default: 7 days)
+func MembershipExpiringSoonWindow() time.Duration {
+	days, err := strconv.Atoi(utils.GetEnv("MEMBERSHIP_EXPIRING_SOON_DAYS", "7"))
+	if err != nil || days < 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// expiringSoon returns whether a membership ending at expiresAt ends within MembershipExpiringSoonWindow
+func expiringSoon(expiresAt *time.Time) bool {
+	return expiresAt != nil && time.Until(*expiresAt) <= MembershipExpiringSoonWindow()
+}
+
+func (o UserOrganizationMap) expired() bool {
+	return o.ExpiresAt != nil && !o.ExpiresAt.After(time.Now())
+}
+
+// activeMemberships leaves out expired memberships from a query on user_organization_maps, they are treated
+// as absent until the cleanup removes them
+func activeMemberships(tx *gorm.DB) *gorm.DB {
+	return tx.Where("(user_organization_maps.expires_at IS NULL OR user_organization_maps.expires_at > ?)", time.Now())
+}
+
+// joinMemberships joins the active memberships of the user to a query on rows of organizations, the rows of
+// organizations the user isn't a member of are left out
+func joinMemberships(tx *gorm.DB, organizationColumn string, userID string) *gorm.DB {
+	return tx.Joins("JOIN user_organization_maps ON user_organization_maps.organization_id = "+organizationColumn+
+		" AND user_organization_maps.user_id = ? AND user_organization_maps.deleted_at IS NULL"+
+		" AND (user_organization_maps.expires_at IS NULL OR user_organization_maps.expires_at > ?)", userID, time.Now())
+}
+
+type MemberModel struct{}
+
+var memberValidSortFields = map[string]bool{
+	"name":      true,
+	"email":     true,
+	"role":      true,
+	"joined_at": true,
+}
+
+func GetMemberValidSortFields() map[string]bool {
+	return memberValidSortFields
+}
+
+// memberSortColumns maps the sort fields to columns, roles are sorted by rank instead of alphabetically
+var memberSortColumns = map[string]string{
+	"name":      "users.name",
+	"email":     "users.email",
+	"role":      "CASE user_organization_maps.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 WHEN 'editor' THEN 2 ELSE 3 END",
+	"joined_at": "user_organization_maps.created_at",
+}
+
+// memberColumns selects a Member from user_organization_maps joined with users
+const memberColumns = "users.id AS user_id, users.name, users.email, user_organization_maps.role, " +
+	"user_organization_maps.created_at AS joined_at, user_organization_maps.expires_at"
+
+func (m MemberModel) query(tx *gorm.DB, orgID string) *gorm.DB {
+	return activeMemberships(tx.Table("user_organization_maps").
+		Joins("JOIN users ON users.id = user_organization_maps.user_id AND users.deleted_at IS NULL").
+		Where("user_organization_maps.organization_id = ? AND user_organization_maps.deleted_at IS NULL", orgID))
+}
+
+// All returns the members of the organization, q searches the name and email of the members
+func (m MemberModel) All(ctx context.Context, orgID string, q string, sortBy string, sort string, page int, limit int) (result PaginatedResult[Member], err error) {
+	db := db.GetDB()
+	members := make([]*Member, 0)
+
+	tx := m.query(db, orgID)
+
+	// Search filter
+	if q != "" {
+		tx = tx.Where("users.name ILIKE ? OR users.email ILIKE ?", fmt.Sprintf("%%%s%%", q), fmt.Sprintf("%%%s%%", q))
+	}
+
+	// Get total count for pagination
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to get count for pagination :: error: %s", err.Error())
+		return PaginatedResult[Member]{}, err
+	}
+
+	// Apply sorting, the user id keeps the order stable between pages
+	tx = tx.Order(fmt.Sprintf("%s %s, users.id", memberSortColumns[sortBy], sort))
+
+	// Pagination
+	offset := page * limit
+	if err := tx.Select(memberColumns).Limit(limit).Offset(offset).Scan(&members).Error; err != nil {
+		log.With(ctx).Errorf("failed to get members of organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[Member]{}, err
+	}
+
+	return BuildPaginatedResult(members, totalCount, page, limit), nil
+}
+
+// One returns the member of the organization
+func (m MemberModel) One(ctx context.Context, orgID string, userID string) (member Member, isFound bool, err error) {
+	db := db.GetDB()
+
+	result := m.query(db, orgID).Where("user_organization_maps.user_id = ?", userID).Select(memberColumns).Limit(1).Scan(&member)
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to find member with id %s of organization with id %s :: error: %s", userID, orgID, result.Error.Error())
+		return Member{}, false, result.Error
+	}
+
+	return member, result.RowsAffected > 0, nil
+}
+
+// UpdateRole changes the role of a member, actorRole is the role of the member making the change.
+//
+// Returns ErrMemberNotFound if the user is not a member, ErrOwnerRoleRequired if someone who is not an owner
+// changes an owner or makes a member owner and ErrLastOwner if the only owner would be demoted.
+func (m MemberModel) UpdateRole(ctx context.Context, orgID string, userID string, role Role, actorRole Role) (member Member, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	membership, err := m.lockMembership(ctx, tx, orgID, userID)
+	if err != nil {
+		tx.Rollback()
+		return Member{}, err
+	}
+
+	if (membership.Role == RoleOwner || role == RoleOwner) && actorRole != RoleOwner {
+		tx.Rollback()
+		return Member{}, ErrOwnerRoleRequired
+	}
+
+	if membership.Role == RoleOwner && role != RoleOwner {
+		if err := m.ensureAnotherOwner(ctx, tx, orgID, userID); err != nil {
+			tx.Rollback()
+			return Member{}, err
+		}
+	}
+
+	updates := map[string]interface{}{"role": role, "updated_at": time.Now()}
+	// owners keep the organization, their membership doesn't end
+	if role == RoleOwner {
+		updates["expires_at"] = nil
+	}
+
+	if err := tx.Model(&UserOrganizationMap{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Updates(updates).Error; err != nil {
+		log.With(ctx).Errorf("failed to update role of member with id %s in organization with id %s :: error: %s", userID, orgID, err.Error())
+		tx.Rollback()
+		return Member{}, err
+	}
+
+	tx.Commit()
+	invalidateMembership(orgID, userID)
+
+	member, _, err = m.One(ctx, orgID, userID)
+	return member, err
+}
+
+// Remove takes the user out of the organization, actorRole is the role of the member removing the user,
+// members leaving the organization remove themselves. The reason is recorded on the removed membership.
+//
+// Returns ErrMemberNotFound if the user is not a member, ErrOwnerRoleRequired if someone who is not an owner
+// removes an owner and ErrLastOwner if the user is the only owner.
+func (m MemberModel) Remove(ctx context.Context, orgID string, userID string, actorRole Role, reason MembershipRemovedReason) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	membership, err := m.lockMembership(ctx, tx, orgID, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if membership.Role == RoleOwner {
+		if actorRole != RoleOwner {
+			tx.Rollback()
+			return ErrOwnerRoleRequired
+		}
+		if err := m.ensureAnotherOwner(ctx, tx, orgID, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := removeMemberships(tx.Where("organization_id = ? AND user_id = ?", orgID, userID), reason); err != nil {
+		log.With(ctx).Errorf("failed to remove member with id %s from organization with id %s :: error: %s", userID, orgID, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := (TeamModel{}).removeUserFromTeams(ctx, tx, orgID, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	invalidateMembership(orgID, userID)
+	return nil
+}
+
+// TransferOwnership makes another member the owner and creator of the organization,
+// the current owner stays in the organization as an admin.
+//
+// Returns ErrMemberNotFound if the new owner is not a member of the organization.
+func (m MemberModel) TransferOwnership(ctx context.Context, orgID string, fromUserID string, toUserID string) (organization Organization, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if _, err := m.lockMembership(ctx, tx, orgID, toUserID); err != nil {
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if fromUserID != toUserID {
+		if err := tx.Model(&UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", orgID, toUserID).
+			Updates(map[string]interface{}{"role": RoleOwner, "updated_at": time.Now()}).Error; err != nil {
+			log.With(ctx).Errorf("failed to make member with id %s owner of organization with id %s :: error: %s", toUserID, orgID, err.Error())
+			tx.Rollback()
+			return Organization{}, err
+		}
+
+		if err := tx.Model(&UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", orgID, fromUserID).
+			Updates(map[string]interface{}{"role": RoleAdmin, "updated_at": time.Now()}).Error; err != nil {
+			log.With(ctx).Errorf("failed to make member with id %s admin of organization with id %s :: error: %s", fromUserID, orgID, err.Error())
+			tx.Rollback()
+			return Organization{}, err
+		}
+	}
+
+	if err := tx.Model(&Organization{}).Where("id = ?", orgID).
+		Updates(map[string]interface{}{"created_by": toUserID, "updated_at": time.Now()}).Error; err != nil {
+		log.With(ctx).Errorf("failed to transfer ownership of organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := tx.Where("id = ?", orgID).First(&organization).Error; err != nil {
+		log.With(ctx).Errorf("failed to find organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	tx.Commit()
+	invalidateMembership(orgID, toUserID)
+	invalidateMembership(orgID, fromUserID)
+	return organization, nil
+}
+
+// Extend makes the membership of the user end days later than it does now, or days from now if it doesn't end
+// yet. A nil days makes the membership permanent, which only owners or members with a higher role than the user
+// can do.
+//
+// Returns ErrMemberNotFound if the user is not a member, ErrOwnerMembershipExpiry if the user is an owner and
+// ErrMembershipExpiryRemoval if the actor can't make the membership permanent.
+func (m MemberModel) Extend(ctx context.Context, orgID string, userID string, days *int, actorRole Role) (member Member, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	membership, err := m.lockMembership(ctx, tx, orgID, userID)
+	if err != nil {
+		tx.Rollback()
+		return Member{}, err
+	}
+
+	if days == nil && membership.ExpiresAt != nil && actorRole != RoleOwner && !actorRole.Outranks(membership.Role) {
+		tx.Rollback()
+		return Member{}, ErrMembershipExpiryRemoval
+	}
+
+	var expiresAt *time.Time
+	if days != nil {
+		if membership.Role == RoleOwner {
+			tx.Rollback()
+			return Member{}, ErrOwnerMembershipExpiry
+		}
+		from := time.Now()
+		if membership.ExpiresAt != nil {
+			from = *membership.ExpiresAt
+		}
+		extended := from.AddDate(0, 0, *days)
+		expiresAt = &extended
+	}
+
+	if err := tx.Model(&UserOrganizationMap{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "updated_at": time.Now()}).Error; err != nil {
+		log.With(ctx).Errorf("failed to extend membership of user with id %s in organization with id %s :: error: %s", userID, orgID, err.Error())
+		tx.Rollback()
+		return Member{}, err
+	}
+
+	tx.Commit()
+	invalidateMembership(orgID, userID)
+
+	member, _, err = m.One(ctx, orgID, userID)
+	return member, err
+}
+
+// CleanupExpired removes the expired memberships, recording expiry as the reason, along with the team
+// memberships of the users in those organizations
+func (m MemberModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var memberships []UserOrganizationMap
+	if err := tx.Model(&memberships).Clauses(clause.Returning{Columns: []clause.Column{{Name: "organization_id"}, {Name: "user_id"}}}).
+		Where("expires_at <= ?", time.Now()).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "removed_reason": MembershipExpired}).Error; err != nil {
+		log.With(ctx).Errorf("failed to cleanup expired memberships :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	for _, membership := range memberships {
+		if err := (TeamModel{}).removeUserFromTeams(ctx, tx, membership.OrganizationID, membership.UserID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	tx.Commit()
+	for _, membership := range memberships {
+		invalidateMembership(membership.OrganizationID, membership.UserID)
+		log.With(ctx).Infof("removed expired membership of user with id %s in organization with id %s", membership.UserID, membership.OrganizationID)
+	}
+
+	if len(memberships) > 0 {
+		log.With(ctx).Infof("cleaned up %d expired memberships", len(memberships))
+	}
+
+	return nil
+}
+
+// removeMemberships soft deletes the memberships matched by the query and records why they ended
+func removeMemberships(tx *gorm.DB, reason MembershipRemovedReason) error {
+	return tx.Model(&UserOrganizationMap{}).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "removed_reason": reason}).Error
+}
+
+// lockMembership locks the organization, which serializes changes to its members so that two owners can't
+// demote each other at the same time, and returns the membership of the user
+func (m MemberModel) lockMembership(ctx context.Context, tx *gorm.DB, orgID string, userID string) (membership UserOrganizationMap, err error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orgID).First(&Organization{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock organization with id %s :: error: %s", orgID, err.Error())
+		return UserOrganizationMap{}, err
+	}
+
+	if err := activeMemberships(tx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return UserOrganizationMap{}, ErrMemberNotFound
+		}
+		log.With(ctx).Errorf("failed to find member with id %s of organization with id %s :: error: %s", userID, orgID, err.Error())
+		return UserOrganizationMap{}, err
+	}
+
+	return membership, nil
+}
+
+// ensureAnotherMember returns ErrSoleMember if the user is the only active member of the organization,
+// the organization has to be locked by the transaction
+func (m MemberModel) ensureAnotherMember(ctx context.Context, tx *gorm.DB, orgID string, userID string) error {
+	var members int64
+	if err := activeMemberships(tx.Model(&UserOrganizationMap{})).
+		Where("organization_id = ? AND user_id <> ?", orgID, userID).
+		Count(&members).Error; err != nil {
+		log.With(ctx).Errorf("failed to count members of organization with id %s :: error: %s", orgID, err.Error())
+		return err
+	}
+
+	if members == 0 {
+		return ErrSoleMember
+	}
+	return nil
+}
+
+// ensureAnotherOwner returns ErrLastOwner if the user is the only owner of the organization,
+// the organization has to be locked by the transaction
+func (m MemberModel) ensureAnotherOwner(ctx context.Context, tx *gorm.DB, orgID string, userID string) error {
+	var owners int64
+	if err := tx.Model(&UserOrganizationMap{}).
+		Where("organization_id = ? AND user_id <> ? AND role = ?", orgID, userID, RoleOwner).
+		Count(&owners).Error; err != nil {
+		log.With(ctx).Errorf("failed to count owners of organization with id %s :: error: %s", orgID, err.Error())
+		return err
+	}
+
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMFAChallengeInvalid is returned when a challenge token is unknown, expired or has no attempts left
+var ErrMFAChallengeInvalid = errors.New("invalid mfa challenge")
+
+// mfaChallengeMaxAttempts is the number of wrong codes after which a challenge can't be used anymore
+const mfaChallengeMaxAttempts = 5
+
+// MFAChallenge is issued by a login with the correct password when MFA is enabled, it is exchanged
+// for tokens along with a TOTP or recovery code. Only the hash of the challenge token is stored.
+type MFAChallenge struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	ID        string    `gorm:"primaryKey"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserID    string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	Attempts  int
+}
+
+func (mc *MFAChallenge) BeforeCreate(tx *gorm.DB) (err error) {
+	mc.ID = uuid.New().String()
+	mc.CreatedAt = time.Now()
+	mc.UpdatedAt = time.Now()
+	return
+}
+
+func (mc *MFAChallenge) BeforeUpdate(tx *gorm.DB) (err error) {
+	mc.UpdatedAt = time.Now()
+	return
+}
+
+type MFAChallengeModel struct{}
+
+// MFAChallengeTTL returns how long the second login step can be completed for (default: 5 minutes)
+func MFAChallengeTTL() time.Duration {
+	minutes, err := strconv.Atoi(utils.GetEnv("MFA_CHALLENGE_TTL_MINUTES", "5"))
+	if err != nil || minutes < 1 {
+		minutes = 5
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Create issues a challenge for the user and returns the raw challenge token
+func (m MFAChallengeModel) Create(ctx context.Context, userID string) (token string, err error) {
+	db := db.GetDB()
+
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate mfa challenge for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	challenge := MFAChallenge{
+		TokenHash: utils.HashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(MFAChallengeTTL()),
+	}
+	if err := db.Create(&challenge).Error; err != nil {
+		log.With(ctx).Errorf("failed to create mfa challenge for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
+
+// UserOf returns the id of the user the challenge was issued to
+//
+// Returns ErrMFAChallengeInvalid if the challenge is unknown, expired or out of attempts.
+func (m MFAChallengeModel) UserOf(ctx context.Context, token string) (userID string, err error) {
+	db := db.GetDB()
+
+	var challenge MFAChallenge
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrMFAChallengeInvalid
+		}
+		log.With(ctx).Errorf("failed to find mfa challenge :: error: %s", err.Error())
+		return "", err
+	}
+
+	if challenge.Attempts >= mfaChallengeMaxAttempts || time.Now().After(challenge.ExpiresAt) {
+		return "", ErrMFAChallengeInvalid
+	}
+	return challenge.UserID, nil
+}
+
+// Complete verifies the code for the user the challenge was issued to and consumes the challenge.
+// Every wrong code counts as an attempt, the challenge stops working after mfaChallengeMaxAttempts.
+//
+// Returns ErrMFAChallengeInvalid if the challenge is unknown, expired or out of attempts and
+// ErrMFACodeInvalid if the code doesn't match.
+func (m MFAChallengeModel) Complete(ctx context.Context, token string, code string) (userID string, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var challenge MFAChallenge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&challenge).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrMFAChallengeInvalid
+		}
+		log.With(ctx).Errorf("failed to find mfa challenge :: error: %s", err.Error())
+		return "", err
+	}
+
+	if challenge.Attempts >= mfaChallengeMaxAttempts || time.Now().After(challenge.ExpiresAt) {
+		tx.Rollback()
+		return "", ErrMFAChallengeInvalid
+	}
+
+	if err := (UserMFAModel{}).verify(ctx, tx, challenge.UserID, code); err != nil {
+		if !errors.Is(err, ErrMFACodeInvalid) {
+			tx.Rollback()
+			return "", err
+		}
+		// nothing was changed by the failed verification, only the attempt has to be persisted
+		if err := tx.Model(&challenge).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			log.With(ctx).Errorf("failed to update mfa challenge %s :: error: %s", challenge.ID, err.Error())
+			tx.Rollback()
+			return "", err
+		}
+		tx.Commit()
+		log.With(ctx).Warnf("invalid mfa code for user with id %s", challenge.UserID)
+		return "", ErrMFACodeInvalid
+	}
+
+	if err := tx.Delete(&challenge).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete mfa challenge %s :: error: %s", challenge.ID, err.Error())
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+	return challenge.UserID, nil
+}
+
+// CleanupExpired removes expired mfa challenges
+func (m MFAChallengeModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ?", time.Now()).Delete(&MFAChallenge{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired mfa challenges :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired mfa challenges", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrOIDCLoginStateInvalid is returned when the state of an OIDC callback is unknown, expired or for another provider
+var ErrOIDCLoginStateInvalid = errors.New("invalid oidc login state")
+
+// oidcLoginStateTTL is how long the user has to sign in at the identity provider
+const oidcLoginStateTTL = 10 * time.Minute
+
+// OIDCLoginState keeps what is needed to complete an OIDC login between the redirect to the identity
+// provider and the callback. The state is sent to the provider and only its hash is stored,
+// the nonce and PKCE code verifier never leave the server.
+type OIDCLoginState struct {
+	CreatedAt    time.Time `gorm:"<-:create"`
+	ID           string    `gorm:"primaryKey"`
+	StateHash    string    `gorm:"uniqueIndex"`
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time `gorm:"index"`
+}
+
+func (s *OIDCLoginState) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New().String()
+	s.CreatedAt = time.Now()
+	return
+}
+
+type OIDCLoginStateModel struct{}
+
+// Create starts a login with the provider and returns the state to be sent to the provider
+func (m OIDCLoginStateModel) Create(ctx context.Context, provider string) (state string, loginState OIDCLoginState, err error) {
+	db := db.GetDB()
+
+	state, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate oidc state for provider %s :: error: %s", provider, err.Error())
+		return "", OIDCLoginState{}, err
+	}
+	nonce, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate oidc nonce for provider %s :: error: %s", provider, err.Error())
+		return "", OIDCLoginState{}, err
+	}
+	codeVerifier, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate pkce code verifier for provider %s :: error: %s", provider, err.Error())
+		return "", OIDCLoginState{}, err
+	}
+
+	loginState = OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	}
+	if err := db.Create(&loginState).Error; err != nil {
+		log.With(ctx).Errorf("failed to create oidc login state for provider %s :: error: %s", provider, err.Error())
+		return "", OIDCLoginState{}, err
+	}
+
+	return state, loginState, nil
+}
+
+// Consume returns the login matching the state and deletes it so that a callback can't be replayed.
+//
+// Returns ErrOIDCLoginStateInvalid if the state is unknown, expired or was issued for another provider.
+func (m OIDCLoginStateModel) Consume(ctx context.Context, provider string, state string) (loginState OIDCLoginState, err error) {
+	db := db.GetDB()
+
+	result := db.Clauses(clause.Returning{}).
+		Where("state_hash = ?", utils.HashToken(state)).
+		Delete(&loginState)
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to consume oidc login state :: error: %s", result.Error.Error())
+		return OIDCLoginState{}, result.Error
+	}
+
+	if result.RowsAffected == 0 || loginState.Provider != provider || time.Now().After(loginState.ExpiresAt) {
+		return OIDCLoginState{}, ErrOIDCLoginStateInvalid
+	}
+
+	return loginState, nil
+}
+
+// CleanupExpired removes logins which were never completed
+func (m OIDCLoginStateModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ?", time.Now()).Delete(&OIDCLoginState{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired oidc login states :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired oidc login states", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -18,11 +18,29 @@ type Organization struct {
 	BaseWithId
 	Name        string `json:"name" gorm:"index"`
 	Description string `json:"description"`
-	CreatedBy   string `json:"createdBy"`
+	// Slug can be used in place of the id in routes, it is unique among all organizations
+	Slug      string `json:"slug" gorm:"uniqueIndex"`
+	CreatedBy string `json:"createdBy"`
+	// ServiceGrantsRequired is set once the organization has a team and stays set, members other than owners
+	// and admins then only have access to the services granted to their teams, see TeamModel.ServiceAccess
+	ServiceGrantsRequired bool `json:"serviceGrantsRequired" gorm:"not null;default:false"`
+	// Role is the role of the requesting user, only set when listing the organizations of the user
+	Role Role `json:"role,omitempty" gorm:"->;-:migration"`
+	// MembershipExpiresAt is when the membership of the requesting user ends, only set when listing the
+	// organizations of the user and the membership is time-bound
+	MembershipExpiresAt *time.Time `json:"membershipExpiresAt,omitempty" gorm:"->;-:migration"`
+	// ExpiringSoon is set when the membership of the requesting user ends within MembershipExpiringSoonWindow
+	ExpiringSoon bool `json:"expiringSoon,omitempty" gorm:"-"`
 	// Relationships
 	Creator User `json:"-" gorm:"foreignKey:CreatedBy"`
 }
 
+// SetMembershipExpiry sets when the membership of the requesting user ends and whether that is soon
+func (o *Organization) SetMembershipExpiry(expiresAt *time.Time) {
+	o.MembershipExpiresAt = expiresAt
+	o.ExpiringSoon = expiringSoon(expiresAt)
+}
+
 func (o *Organization) BeforeCreate(tx *gorm.DB) (err error) {
 	o.ID = uuid.New().String()
 	o.CreatedAt = time.Now()
@@ -40,6 +58,11 @@ type UserOrganizationMap struct {
 	Base
 	UserID         string `json:"userId" gorm:"primaryKey"`
 	OrganizationID string `json:"organizationId" gorm:"primaryKey"`
+	Role           Role   `json:"role" gorm:"type:varchar(20);not null;default:'viewer'"`
+	// ExpiresAt ends a time-bound membership, expired memberships are treated as absent until they are removed
+	ExpiresAt *time.Time `json:"expiresAt" gorm:"index"`
+	// RemovedReason records why a removed (soft deleted) membership ended
+	RemovedReason MembershipRemovedReason `json:"-" gorm:"type:varchar(20)"`
 }
 
 func (o *UserOrganizationMap) BeforeCreate(tx *gorm.DB) (err error) {
@@ -71,9 +94,16 @@ func (m OrganizationModel) Create(ctx context.Context, form forms.CreateOrganiza
 	// Start transaction
 	tx := db.Begin()
 
+	slug, err := (SlugModel{}).assign(ctx, tx, SlugOrganization, "", form.Slug, form.Name)
+	if err != nil {
+		tx.Rollback()
+		return Organization{}, err
+	}
+
 	organization = Organization{
 		Name:        form.Name,
 		Description: form.Description,
+		Slug:        slug,
 		CreatedBy:   createdBy,
 	}
 
@@ -83,10 +113,11 @@ func (m OrganizationModel) Create(ctx context.Context, form forms.CreateOrganiza
 		return Organization{}, err
 	}
 
-	// Add creator to organization
+	// Add creator to organization as its owner
 	userOrg := UserOrganizationMap{
 		UserID:         createdBy,
 		OrganizationID: organization.ID,
+		Role:           RoleOwner,
 	}
 
 	if err := tx.Create(&userOrg).Error; err != nil {
@@ -96,6 +127,7 @@ func (m OrganizationModel) Create(ctx context.Context, form forms.CreateOrganiza
 	}
 
 	tx.Commit()
+	invalidateMembership(organization.ID, createdBy)
 	return organization, nil
 }
 
@@ -113,8 +145,9 @@ func (m OrganizationModel) GetUserOrganizations(ctx context.Context, userID stri
 	organizations := make([]*Organization, 0)
 
 	tx := db.Model(&Organization{}).
-		Joins("JOIN user_organization_maps ON organizations.id = user_organization_maps.organization_id").
+		Joins("JOIN user_organization_maps ON organizations.id = user_organization_maps.organization_id AND user_organization_maps.deleted_at IS NULL").
 		Where("user_organization_maps.user_id = ?", userID)
+	tx = activeMemberships(tx)
 
 	// Search filter
 	if q != "" {
@@ -133,86 +166,182 @@ func (m OrganizationModel) GetUserOrganizations(ctx context.Context, userID stri
 
 	// Pagination
 	offset := page * limit
-	if err := tx.Limit(limit).Offset(offset).Find(&organizations).Error; err != nil {
+	if err := tx.Select("organizations.*, user_organization_maps.role, user_organization_maps.expires_at AS membership_expires_at").
+		Limit(limit).Offset(offset).Find(&organizations).Error; err != nil {
 		log.With(ctx).Errorf("failed to get organizations :: error: %s", err.Error())
 		return PaginatedResult[Organization]{}, err
 	}
 
+	for _, organization := range organizations {
+		organization.SetMembershipExpiry(organization.MembershipExpiresAt)
+	}
+
 	return BuildPaginatedResult(organizations, totalCount, page, limit), nil
 }
 
+// Update changes the organization, a new slug replaces the current one which keeps redirecting to the organization
+//
+// Returns ErrSlugTaken if another organization has the slug.
 func (m OrganizationModel) Update(ctx context.Context, id string, form forms.CreateOrganizationForm) (organization Organization, err error) {
 	db := db.GetDB()
+	tx := db.Begin()
 
-	if err := db.Model(&Organization{}).Where("id = ?", id).First(&organization).Error; err != nil {
+	if err := tx.Model(&Organization{}).Where("id = ?", id).First(&organization).Error; err != nil {
 		log.With(ctx).Errorf("failed to find organization with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := (SlugModel{}).change(ctx, tx, SlugOrganization, "", id, organization.Slug, form.Slug); err != nil {
+		tx.Rollback()
 		return Organization{}, err
 	}
 
 	organization.Name = form.Name
 	organization.Description = form.Description
+	if form.Slug != "" {
+		organization.Slug = form.Slug
+	}
 
-	if err := db.Save(&organization).Error; err != nil {
+	if err := tx.Save(&organization).Error; err != nil {
 		log.With(ctx).Errorf("failed to update organization with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
 		return Organization{}, err
 	}
 
+	tx.Commit()
 	return organization, nil
 }
 
+// Delete moves the organization to the trash along with its services, versions, teams and memberships.
+// Everything gets the same deleted_at so that restoring the organization brings back exactly what was
+// deleted with it, see TrashModel.RestoreOrganization. Pending invitations are deleted for good.
 func (m OrganizationModel) Delete(ctx context.Context, id string) (err error) {
 	db := db.GetDB()
+	deletedAt := time.Now()
 
 	// Start transaction
 	tx := db.Begin()
 
-	// TODO: figure out cascade deletes
-	// Delete user-organization relationships
-	if err := tx.Where("organization_id = ?", id).Delete(&UserOrganizationMap{}).Error; err != nil {
+	if err := tx.Model(&UserOrganizationMap{}).Where("organization_id = ?", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": deletedAt, "removed_reason": MembershipOrganizationDeleted}).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete user organization maps for organization with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 
-	services := []Service{}
-	// Delete org services
-	if err := tx.Where("organization_id = ?", id).Clauses(clause.Returning{}).Delete(&services).Error; err != nil {
-		log.With(ctx).Errorf("failed to delete services for organization with id %s :: error: %s", id, err.Error())
+	// Pending invitations can't be accepted anymore
+	if err := tx.Where("organization_id = ?", id).Delete(&Invitation{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete invitations for organization with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 
-	serviceIds := []string{}
-	for _, service := range services {
-		serviceIds = append(serviceIds, service.ID)
+	if err := tx.Model(&Team{}).Where("organization_id = ?", id).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete teams for organization with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
 	}
-	// Delete versions of the services
-	if err := tx.Where("service_id IN (?)", serviceIds).Delete(&ServiceVersion{}).Error; err != nil {
+
+	// versions deleted before are left alone, they keep their own deleted_at
+	if err := tx.Model(&ServiceVersion{}).Where("service_id IN (?)", tx.Model(&Service{}).Select("id").Where("organization_id = ?", id)).
+		UpdateColumn("deleted_at", deletedAt).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete versions for services of organization with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 
+	if err := tx.Model(&Service{}).Where("organization_id = ?", id).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete services for organization with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
 	// Delete organization
-	if err := tx.Where("id = ?", id).Delete(&Organization{}).Error; err != nil {
+	if err := tx.Model(&Organization{}).Where("id = ?", id).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete organization with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 
 	tx.Commit()
+	invalidateOrganizationMemberships(id)
 	return nil
 }
 
-func (m OrganizationModel) IsUserMember(ctx context.Context, orgID string, userID string) (bool, error) {
+// addMember adds the user to the organization with the role, expiresAt is nil for a membership which doesn't end.
+// A membership removed earlier is only soft deleted and keeps its primary key, it is restored with the new role
+// instead of inserting another row, the same goes for an expired membership which wasn't removed yet.
+// Callers have to call invalidateMembership once the transaction is committed.
+//
+// Returns ErrAlreadyMember if the user already is a member of the organization.
+func (m OrganizationModel) addMember(ctx context.Context, tx *gorm.DB, orgID string, userID string, role Role, expiresAt *time.Time) error {
+	var existing UserOrganizationMap
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&existing).Error
+
+	switch {
+	case err == nil && !existing.DeletedAt.Valid && !existing.expired():
+		return ErrAlreadyMember
+	case err == nil:
+		now := time.Now()
+		if err := tx.Unscoped().Model(&UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
+			UpdateColumns(map[string]interface{}{"deleted_at": nil, "removed_reason": "", "role": role, "expires_at": expiresAt,
+				"created_at": now, "updated_at": now}).Error; err != nil {
+			log.With(ctx).Errorf("failed to restore membership of user with id %s in organization with id %s :: error: %s", userID, orgID, err.Error())
+			return err
+		}
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		membership := UserOrganizationMap{
+			UserID:         userID,
+			OrganizationID: orgID,
+			Role:           role,
+			ExpiresAt:      expiresAt,
+		}
+		if err := tx.Create(&membership).Error; err != nil {
+			log.With(ctx).Errorf("failed to add user with id %s to organization with id %s :: error: %s", userID, orgID, err.Error())
+			return err
+		}
+		return nil
+	default:
+		log.With(ctx).Errorf("failed to find membership of user with id %s in organization with id %s :: error: %s", userID, orgID, err.Error())
+		return err
+	}
+}
+
+// MemberRole returns the role of the user in the organization, empty if the user is not a member or the
+// membership expired. Results are cached for a short while but never past the expiry of the membership.
+func (m OrganizationModel) MemberRole(ctx context.Context, orgID string, userID string) (Role, error) {
+	key := membershipKey{OrganizationID: orgID, UserID: userID}
+	if role, ok := authCaches().membership.Get(key); ok {
+		return role, nil
+	}
+
 	db := db.GetDB()
-	var count int64
+	var membership UserOrganizationMap
 
-	err := db.Model(&UserOrganizationMap{}).
+	err := activeMemberships(db.Model(&UserOrganizationMap{})).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
-		Count(&count).Error
-	if err != nil {
+		First(&membership).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.With(ctx).Errorf("failed to check user organization mapping for organization with id %s and user with id %s :: error: %s", orgID, userID, err.Error())
+		return "", err
 	}
-	return count > 0, err
+
+	// an expiring membership must not outlive its expiry in the cache
+	var deadline time.Time
+	if membership.ExpiresAt != nil {
+		deadline = *membership.ExpiresAt
+	}
+	authCaches().membership.SetUntil(key, membership.Role, deadline)
+	return membership.Role, nil
+}
+
+// IsUserMember returns whether the user is a member of the organization, results are cached for a short while
+func (m OrganizationModel) IsUserMember(ctx context.Context, orgID string, userID string) (bool, error) {
+	role, err := m.MemberRole(ctx, orgID, userID)
+	return role != "", err
 }
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPasswordResetTokenInvalid is returned when a reset token is unknown, expired or already used
+var ErrPasswordResetTokenInvalid = errors.New("invalid password reset token")
+
+// PasswordResetToken is a single use token emailed to a user to set a new password,
+// only the hash of the token is stored
+type PasswordResetToken struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	ID        string    `gorm:"primaryKey"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserID    string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+}
+
+func (prt *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	prt.ID = uuid.New().String()
+	prt.CreatedAt = time.Now()
+	prt.UpdatedAt = time.Now()
+	return
+}
+
+func (prt *PasswordResetToken) BeforeUpdate(tx *gorm.DB) (err error) {
+	prt.UpdatedAt = time.Now()
+	return
+}
+
+type PasswordResetTokenModel struct{}
+
+// PasswordResetTokenTTL returns how long a reset token can be used for (default: 30 minutes)
+func PasswordResetTokenTTL() time.Duration {
+	minutes, err := strconv.Atoi(utils.GetEnv("PASSWORD_RESET_TOKEN_TTL_MINUTES", "30"))
+	if err != nil || minutes < 1 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Create issues a new reset token for the user and returns the raw token to be emailed,
+// tokens issued earlier for the user which were not used yet are invalidated
+func (m PasswordResetTokenModel) Create(ctx context.Context, userID string) (token string, err error) {
+	return m.create(ctx, userID, true)
+}
+
+// CreateAdditional issues a new reset token for the user like Create but keeps the tokens issued earlier, used for
+// resets the user didn't ask for so that whoever triggers them can't invalidate a link the user requested
+func (m PasswordResetTokenModel) CreateAdditional(ctx context.Context, userID string) (token string, err error) {
+	return m.create(ctx, userID, false)
+}
+
+func (m PasswordResetTokenModel) create(ctx context.Context, userID string, invalidatePending bool) (token string, err error) {
+	db := db.GetDB()
+
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate password reset token for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	tx := db.Begin()
+
+	// only the latest requested link should work
+	if invalidatePending {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&PasswordResetToken{}).Error; err != nil {
+			log.With(ctx).Errorf("failed to invalidate password reset tokens for user with id %s :: error: %s", userID, err.Error())
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	resetToken := PasswordResetToken{
+		TokenHash: utils.HashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(PasswordResetTokenTTL()),
+	}
+	if err := tx.Create(&resetToken).Error; err != nil {
+		log.With(ctx).Errorf("failed to create password reset token for user with id %s :: error: %s", userID, err.Error())
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+	return token, nil
+}
+
+// Reset consumes the reset token and sets the new password of the user it was issued for.
+// All sessions and personal access tokens of the user are revoked so that other logins have to authenticate with
+// the new password, and a login lockout of the account is lifted.
+//
+// Returns ErrPasswordResetTokenInvalid if the token is unknown, expired or already used.
+func (m PasswordResetTokenModel) Reset(ctx context.Context, token string, password string) (userID string, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var resetToken PasswordResetToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&resetToken).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrPasswordResetTokenInvalid
+		}
+		log.With(ctx).Errorf("failed to find password reset token :: error: %s", err.Error())
+		return "", err
+	}
+
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		tx.Rollback()
+		return "", ErrPasswordResetTokenInvalid
+	}
+
+	if err := tx.Model(&resetToken).Update("used_at", time.Now()).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark password reset token %s as used :: error: %s", resetToken.ID, err.Error())
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := (UserModel{}).updatePassword(ctx, tx, resetToken.UserID, password); err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrPasswordResetTokenInvalid
+		}
+		return "", err
+	}
+
+	// the reset link was delivered to the email, so the user owns it
+	if err := (UserModel{}).markVerified(ctx, tx, resetToken.UserID); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := (SessionModel{}).revokeAll(ctx, tx, resetToken.UserID); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := (PersonalAccessTokenModel{}).revokeAll(ctx, tx, resetToken.UserID); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	// a new password ends a lockout caused by someone guessing the old one
+	if err := (LoginAttemptModel{}).unlockUser(ctx, tx, resetToken.UserID); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+	invalidateUserSessions(resetToken.UserID)
+	return resetToken.UserID, nil
+}
+
+// CleanupExpired removes expired and used password reset tokens
+func (m PasswordResetTokenModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ? OR used_at IS NOT NULL", time.Now()).Delete(&PasswordResetToken{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired password reset tokens :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired password reset tokens", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenPrefix is prepended to personal access tokens so that they can be told apart from JWTs
+const PersonalAccessTokenPrefix = "kpat_"
+
+// personalAccessTokenContextKey is the gin context key the authenticated personal access token is stored under
+const personalAccessTokenContextKey = "personal_access_token"
+
+// Scopes which can be granted to a personal access token, a write scope includes the read scope of the same resource
+const (
+	ScopeOrgsRead      = "orgs:read"
+	ScopeOrgsWrite     = "orgs:write"
+	ScopeServicesRead  = "services:read"
+	ScopeServicesWrite = "services:write"
+	ScopeVersionsRead  = "versions:read"
+	ScopeVersionsWrite = "versions:write"
+)
+
+var (
+	// ErrPersonalAccessTokenInvalid is returned when a personal access token is unknown, revoked or expired
+	ErrPersonalAccessTokenInvalid = errors.New("invalid personal access token")
+	// ErrPersonalAccessTokenNotFound is returned when revoking a token the user doesn't have
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+)
+
+// PersonalAccessToken is a long lived token a user creates for automation (CI etc.). It can only access the
+// organizations it was created for, with the scopes it was granted, and never more than its user can.
+// Only the hash of the token is stored, revoking a token soft deletes it.
+type PersonalAccessToken struct {
+	BaseWithId
+	UserID          string     `json:"-" gorm:"index"`
+	Name            string     `json:"name"`
+	TokenHash       string     `json:"-" gorm:"uniqueIndex"`
+	OrganizationIDs []string   `json:"organizationIds" gorm:"serializer:json;type:jsonb"`
+	Scopes          []string   `json:"scopes" gorm:"serializer:json;type:jsonb"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt"`
+}
+
+func (pat *PersonalAccessToken) BeforeCreate(tx *gorm.DB) (err error) {
+	pat.ID = uuid.New().String()
+	pat.CreatedAt = time.Now()
+	pat.UpdatedAt = time.Now()
+	return
+}
+
+func (pat *PersonalAccessToken) BeforeUpdate(tx *gorm.DB) (err error) {
+	pat.UpdatedAt = time.Now()
+	return
+}
+
+// HasScope returns whether the token was granted the scope, a write scope also grants read
+func (pat *PersonalAccessToken) HasScope(scope string) bool {
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, s := range pat.Scopes {
+		if s == scope || (strings.HasSuffix(scope, ":read") && s == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOrganization returns whether the token was created for the organization
+func (pat *PersonalAccessToken) AllowsOrganization(orgID string) bool {
+	for _, id := range pat.OrganizationIDs {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedPersonalAccessTokenResponse is returned when a token is created, the token itself is only shown once
+type CreatedPersonalAccessTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// SetPersonalAccessToken stores the token a request was authenticated with in the gin context
+func SetPersonalAccessToken(c *gin.Context, pat *PersonalAccessToken) {
+	c.Set(personalAccessTokenContextKey, pat)
+}
+
+// GetPersonalAccessToken returns the token the request was authenticated with,
+// nil if the request was authenticated with a JWT
+func GetPersonalAccessToken(c *gin.Context) *PersonalAccessToken {
+	pat, exists := c.Get(personalAccessTokenContextKey)
+	if !exists {
+		return nil
+	}
+	return pat.(*PersonalAccessToken)
+}
+
+type PersonalAccessTokenModel struct{}
+
+// Create issues a personal access token for the user and returns the raw token to be shown to the user,
+// membership of the organizations must be checked by the caller
+func (m PersonalAccessTokenModel) Create(ctx context.Context, userID string, form forms.CreatePersonalAccessTokenForm) (pat PersonalAccessToken, token string, err error) {
+	db := db.GetDB()
+
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate personal access token for user with id %s :: error: %s", userID, err.Error())
+		return PersonalAccessToken{}, "", err
+	}
+	token = PersonalAccessTokenPrefix + token
+
+	pat = PersonalAccessToken{
+		UserID:          userID,
+		Name:            form.Name,
+		TokenHash:       utils.HashToken(token),
+		OrganizationIDs: form.OrganizationIDs,
+		Scopes:          form.Scopes,
+		ExpiresAt:       time.Now().AddDate(0, 0, form.ExpiresInDays),
+	}
+	if err := db.Create(&pat).Error; err != nil {
+		log.With(ctx).Errorf("failed to create personal access token for user with id %s :: error: %s", userID, err.Error())
+		return PersonalAccessToken{}, "", err
+	}
+
+	return pat, token, nil
+}
+
+// All returns the tokens of the user which are not revoked, including expired ones
+func (m PersonalAccessTokenModel) All(ctx context.Context, userID string) (tokens []PersonalAccessToken, err error) {
+	db := db.GetDB()
+
+	tokens = make([]PersonalAccessToken, 0)
+	if err := db.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error; err != nil {
+		log.With(ctx).Errorf("failed to get personal access tokens of user with id %s :: error: %s", userID, err.Error())
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke revokes a token of the user, returns ErrPersonalAccessTokenNotFound if the user has no such token
+func (m PersonalAccessTokenModel) Revoke(ctx context.Context, userID string, id string) error {
+	db := db.GetDB()
+
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&PersonalAccessToken{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to revoke personal access token with id %s :: error: %s", id, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// revokeAll revokes every token of the user, used when the password changes so that a leaked password can't
+// keep access through tokens created with it
+func (m PersonalAccessTokenModel) revokeAll(ctx context.Context, tx *gorm.DB, userID string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&PersonalAccessToken{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to revoke personal access tokens of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Authenticate returns the token matching the raw token and records its use.
+//
+// Returns ErrPersonalAccessTokenInvalid if the token is unknown, revoked or expired.
+func (m PersonalAccessTokenModel) Authenticate(ctx context.Context, token string) (pat PersonalAccessToken, err error) {
+	db := db.GetDB()
+
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&pat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return PersonalAccessToken{}, ErrPersonalAccessTokenInvalid
+		}
+		log.With(ctx).Errorf("failed to find personal access token :: error: %s", err.Error())
+		return PersonalAccessToken{}, err
+	}
+
+	if time.Now().After(pat.ExpiresAt) {
+		return PersonalAccessToken{}, ErrPersonalAccessTokenInvalid
+	}
+
+	// last use doesn't need to be exact, avoid a write on every request of a busy CI job
+	now := time.Now()
+	if err := db.Model(&PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", pat.ID, now.Add(-time.Minute)).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		log.With(ctx).Errorf("failed to update last use of personal access token with id %s :: error: %s", pat.ID, err.Error())
+	}
+
+	return pat, nil
+}
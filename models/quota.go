@@ -0,0 +1,277 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaResource is a resource of an organization which is limited by a quota
+type QuotaResource string
+
+const (
+	QuotaServices           QuotaResource = "services"
+	QuotaVersionsPerService QuotaResource = "versions"
+	QuotaMembers            QuotaResource = "members"
+)
+
+// QuotaExceededError is returned when creating a resource would exceed the quota of the organization
+type QuotaExceededError struct {
+	Resource QuotaResource `json:"resource"`
+	Limit    int           `json:"limit"`
+	Usage    int           `json:"usage"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota of %d %s exceeded", e.Limit, e.Resource)
+}
+
+// IsQuotaExceeded returns the QuotaExceededError in the chain of err
+func IsQuotaExceeded(err error) (*QuotaExceededError, bool) {
+	var quotaErr *QuotaExceededError
+	ok := errors.As(err, &quotaErr)
+	return quotaErr, ok
+}
+
+// QuotaLimits are the limits of an organization, 0 means unlimited
+type QuotaLimits struct {
+	MaxServices           int `json:"maxServices"`
+	MaxVersionsPerService int `json:"maxVersionsPerService"`
+	MaxMembers            int `json:"maxMembers"`
+}
+
+// OrganizationQuota overrides the default limits for an organization, nil fields use the default
+type OrganizationQuota struct {
+	CreatedAt             time.Time `gorm:"<-:create"`
+	UpdatedAt             time.Time
+	OrganizationID        string `gorm:"primaryKey"`
+	MaxServices           *int
+	MaxVersionsPerService *int
+	MaxMembers            *int
+}
+
+// QuotaUsage is the current usage of a resource against its limit, a limit of 0 means unlimited
+type QuotaUsage struct {
+	Limit int `json:"limit"`
+	Usage int `json:"usage"`
+}
+
+// OrganizationUsage reports the usage of an organization against each of its limits
+type OrganizationUsage struct {
+	Services QuotaUsage `json:"services"`
+	// VersionsPerService usage is the version count of the service with the most versions
+	VersionsPerService QuotaUsage `json:"versionsPerService"`
+	Members            QuotaUsage `json:"members"`
+}
+
+type QuotaModel struct{}
+
+// DefaultQuotaLimits returns the limits of organizations without an override
+func DefaultQuotaLimits() QuotaLimits {
+	return QuotaLimits{
+		MaxServices:           quotaLimitFromEnv("QUOTA_MAX_SERVICES", 1000),
+		MaxVersionsPerService: quotaLimitFromEnv("QUOTA_MAX_VERSIONS_PER_SERVICE", 1000),
+		MaxMembers:            quotaLimitFromEnv("QUOTA_MAX_MEMBERS", 500),
+	}
+}
+
+func quotaLimitFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(utils.GetEnv(key, strconv.Itoa(fallback)))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// Limits returns the limits of the organization, the defaults with its override applied
+func (m QuotaModel) Limits(ctx context.Context, orgID string) (QuotaLimits, error) {
+	return m.limits(ctx, db.GetDB(), orgID)
+}
+
+func (m QuotaModel) limits(ctx context.Context, tx *gorm.DB, orgID string) (QuotaLimits, error) {
+	limits := DefaultQuotaLimits()
+
+	var quota OrganizationQuota
+	if err := tx.Where("organization_id = ?", orgID).First(&quota).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return limits, nil
+		}
+		log.With(ctx).Errorf("failed to find quota of organization with id %s :: error: %s", orgID, err.Error())
+		return QuotaLimits{}, err
+	}
+
+	if quota.MaxServices != nil {
+		limits.MaxServices = *quota.MaxServices
+	}
+	if quota.MaxVersionsPerService != nil {
+		limits.MaxVersionsPerService = *quota.MaxVersionsPerService
+	}
+	if quota.MaxMembers != nil {
+		limits.MaxMembers = *quota.MaxMembers
+	}
+	return limits, nil
+}
+
+// Override replaces the override of the organization, fields left out of the form go back to the default
+func (m QuotaModel) Override(ctx context.Context, orgID string, form forms.UpdateQuotaForm) (QuotaLimits, error) {
+	db := db.GetDB()
+
+	now := time.Now()
+	quota := OrganizationQuota{
+		CreatedAt:             now,
+		UpdatedAt:             now,
+		OrganizationID:        orgID,
+		MaxServices:           form.MaxServices,
+		MaxVersionsPerService: form.MaxVersionsPerService,
+		MaxMembers:            form.MaxMembers,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_services", "max_versions_per_service", "max_members", "updated_at"}),
+	}).Create(&quota).Error; err != nil {
+		log.With(ctx).Errorf("failed to override quota of organization with id %s :: error: %s", orgID, err.Error())
+		return QuotaLimits{}, err
+	}
+
+	return m.limits(ctx, db, orgID)
+}
+
+// Usage returns the usage of the organization against each of its limits
+func (m QuotaModel) Usage(ctx context.Context, orgID string) (usage OrganizationUsage, err error) {
+	db := db.GetDB()
+
+	limits, err := m.limits(ctx, db, orgID)
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+
+	services, err := m.countServices(ctx, db, orgID)
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+
+	var maxVersions int64
+	versionCounts := db.Model(&ServiceVersion{}).
+		Joins("JOIN services ON services.id = service_versions.service_id AND services.deleted_at IS NULL").
+		Where("services.organization_id = ?", orgID).
+		Group("service_versions.service_id").
+		Select("COUNT(*) AS version_count")
+	if err := db.Table("(?) AS counts", versionCounts).Select("COALESCE(MAX(version_count), 0)").Scan(&maxVersions).Error; err != nil {
+		log.With(ctx).Errorf("failed to count versions of services of organization with id %s :: error: %s", orgID, err.Error())
+		return OrganizationUsage{}, err
+	}
+
+	members, err := m.countMembers(ctx, db, orgID)
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+
+	return OrganizationUsage{
+		Services:           QuotaUsage{Limit: limits.MaxServices, Usage: services},
+		VersionsPerService: QuotaUsage{Limit: limits.MaxVersionsPerService, Usage: int(maxVersions)},
+		Members:            QuotaUsage{Limit: limits.MaxMembers, Usage: members},
+	}, nil
+}
+
+// reserveService returns a QuotaExceededError if the organization can't have another service. The organization
+// row is locked so that concurrent creations in the organization are counted one after another, tx has to be
+// the transaction creating the service.
+func (m QuotaModel) reserveService(ctx context.Context, tx *gorm.DB, orgID string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orgID).First(&Organization{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock organization with id %s :: error: %s", orgID, err.Error())
+		return err
+	}
+
+	limits, err := m.limits(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxServices == 0 {
+		return nil
+	}
+
+	services, err := m.countServices(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if services >= limits.MaxServices {
+		return &QuotaExceededError{Resource: QuotaServices, Limit: limits.MaxServices, Usage: services}
+	}
+	return nil
+}
+
+// reserveVersion returns a QuotaExceededError if the service can't have another version, the service row is
+// locked the same way as the organization in reserveService
+func (m QuotaModel) reserveVersion(ctx context.Context, tx *gorm.DB, serviceID string) error {
+	var service Service
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", serviceID).First(&service).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock service with id %s :: error: %s", serviceID, err.Error())
+		return err
+	}
+
+	limits, err := m.limits(ctx, tx, service.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxVersionsPerService == 0 {
+		return nil
+	}
+
+	var versions int64
+	if err := tx.Model(&ServiceVersion{}).Where("service_id = ?", serviceID).Count(&versions).Error; err != nil {
+		log.With(ctx).Errorf("failed to count versions of service with id %s :: error: %s", serviceID, err.Error())
+		return err
+	}
+	if int(versions) >= limits.MaxVersionsPerService {
+		return &QuotaExceededError{Resource: QuotaVersionsPerService, Limit: limits.MaxVersionsPerService, Usage: int(versions)}
+	}
+	return nil
+}
+
+// reserveMember returns a QuotaExceededError if the organization can't have another member, tx has to be the
+// transaction adding the member and the organization row has to be locked by it
+func (m QuotaModel) reserveMember(ctx context.Context, tx *gorm.DB, orgID string) error {
+	limits, err := m.limits(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxMembers == 0 {
+		return nil
+	}
+
+	members, err := m.countMembers(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if members >= limits.MaxMembers {
+		return &QuotaExceededError{Resource: QuotaMembers, Limit: limits.MaxMembers, Usage: members}
+	}
+	return nil
+}
+
+func (m QuotaModel) countServices(ctx context.Context, tx *gorm.DB, orgID string) (int, error) {
+	var services int64
+	if err := tx.Model(&Service{}).Where("organization_id = ?", orgID).Count(&services).Error; err != nil {
+		log.With(ctx).Errorf("failed to count services of organization with id %s :: error: %s", orgID, err.Error())
+		return 0, err
+	}
+	return int(services), nil
+}
+
+func (m QuotaModel) countMembers(ctx context.Context, tx *gorm.DB, orgID string) (int, error) {
+	var members int64
+	if err := activeMemberships(tx.Model(&UserOrganizationMap{})).Where("organization_id = ?", orgID).Count(&members).Error; err != nil {
+		log.With(ctx).Errorf("failed to count members of organization with id %s :: error: %s", orgID, err.Error())
+		return 0, err
+	}
+	return int(members), nil
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or revoked
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again,
+	// the whole token family is revoked when this happens
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshToken is an opaque, single use token which can be exchanged for a new access token.
+// Only the hash of the token is stored. Every refresh rotates the token, all tokens issued
+// from the same login share a FamilyID so that the whole chain can be revoked on reuse.
+type RefreshToken struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	ID        string    `gorm:"primaryKey"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	FamilyID  string    `gorm:"index"`
+	UserID    string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	rt.ID = uuid.New().String()
+	rt.CreatedAt = time.Now()
+	rt.UpdatedAt = time.Now()
+	return
+}
+
+func (rt *RefreshToken) BeforeUpdate(tx *gorm.DB) (err error) {
+	rt.UpdatedAt = time.Now()
+	return
+}
+
+type RefreshTokenModel struct{}
+
+// refreshTokenTTL returns the lifetime of a refresh token (default: 30 days)
+func refreshTokenTTL() time.Duration {
+	hours, err := strconv.Atoi(utils.GetEnv("REFRESH_TOKEN_TTL_HOURS", "720"))
+	if err != nil || hours < 1 {
+		hours = 720
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Create issues a new refresh token for the user, a new token family is started when familyID is empty.
+// The raw token is returned to be handed to the client, only its hash is persisted.
+func (m RefreshTokenModel) Create(ctx context.Context, userID string, familyID string) (token string, err error) {
+	return m.create(ctx, db.GetDB(), userID, familyID)
+}
+
+func (m RefreshTokenModel) create(ctx context.Context, tx *gorm.DB, userID string, familyID string) (token string, err error) {
+	token, err = utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate refresh token for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+
+	refreshToken := RefreshToken{
+		TokenHash: utils.HashToken(token),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		log.With(ctx).Errorf("failed to create refresh token for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Rotate exchanges a refresh token for a new one of the same family and returns the new raw token
+// along with the family (the session of the login) and the user it belongs to.
+//
+// Returns ErrRefreshTokenInvalid if the token is unknown, expired or revoked and ErrRefreshTokenReused
+// if the token was already rotated, in which case every token of the family is revoked.
+func (m RefreshTokenModel) Rotate(ctx context.Context, token string) (newToken string, familyID string, userID string, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	// lock the row so that concurrent refreshes with the same token cannot both succeed
+	var refreshToken RefreshToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&refreshToken).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", "", ErrRefreshTokenInvalid
+		}
+		log.With(ctx).Errorf("failed to find refresh token :: error: %s", err.Error())
+		return "", "", "", err
+	}
+
+	if refreshToken.RevokedAt != nil {
+		tx.Rollback()
+		return "", "", "", ErrRefreshTokenInvalid
+	}
+
+	if refreshToken.UsedAt != nil {
+		// token was already exchanged, either the client misbehaved or the token was stolen,
+		// either way nothing issued from this login can be trusted anymore
+		if err := m.revokeFamily(ctx, tx, refreshToken.FamilyID); err != nil {
+			tx.Rollback()
+			return "", "", "", err
+		}
+		tx.Commit()
+		log.With(ctx).Warnf("refresh token reuse detected for user with id %s, revoked token family %s", refreshToken.UserID, refreshToken.FamilyID)
+		return "", "", "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(refreshToken.ExpiresAt) {
+		tx.Rollback()
+		return "", "", "", ErrRefreshTokenInvalid
+	}
+
+	now := time.Now()
+	if err := tx.Model(&refreshToken).Update("used_at", now).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark refresh token %s as used :: error: %s", refreshToken.ID, err.Error())
+		tx.Rollback()
+		return "", "", "", err
+	}
+
+	newToken, err = m.create(ctx, tx, refreshToken.UserID, refreshToken.FamilyID)
+	if err != nil {
+		tx.Rollback()
+		return "", "", "", err
+	}
+
+	tx.Commit()
+	return newToken, refreshToken.FamilyID, refreshToken.UserID, nil
+}
+
+// RevokeFamily revokes every refresh token of the token family
+func (m RefreshTokenModel) RevokeFamily(ctx context.Context, familyID string) error {
+	return m.revokeFamily(ctx, db.GetDB(), familyID)
+}
+
+func (m RefreshTokenModel) revokeFamily(ctx context.Context, tx *gorm.DB, familyID string) error {
+	if err := tx.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.With(ctx).Errorf("failed to revoke refresh token family %s :: error: %s", familyID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every refresh token of the user, used when the credentials of the user change
+func (m RefreshTokenModel) RevokeAllForUser(ctx context.Context, userID string) error {
+	return m.revokeAllForUser(ctx, db.GetDB(), userID)
+}
+
+func (m RefreshTokenModel) revokeAllForUser(ctx context.Context, tx *gorm.DB, userID string) error {
+	if err := tx.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.With(ctx).Errorf("failed to revoke refresh tokens of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// CleanupExpired removes expired refresh tokens
+func (m RefreshTokenModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("expires_at <= ?", time.Now()).Delete(&RefreshToken{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired refresh tokens :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired refresh tokens", result.RowsAffected)
+	}
+
+	return nil
+}
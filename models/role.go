@@ -0,0 +1,170 @@
+package models
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+)
+
+// Role is the role of a user in an organization, it decides what the user can do in the organization
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// Permission is something a route of an organization requires the role of the user to allow
+type Permission string
+
+const (
+	PermissionOrgRead       Permission = "org:read"
+	PermissionOrgUpdate     Permission = "org:update"
+	PermissionOrgDelete     Permission = "org:delete"
+	PermissionOrgTransfer   Permission = "org:transfer"
+	PermissionServicesRead  Permission = "services:read"
+	PermissionServicesWrite Permission = "services:write"
+	PermissionVersionsRead  Permission = "versions:read"
+	PermissionVersionsWrite Permission = "versions:write"
+	PermissionMembersRead   Permission = "members:read"
+	PermissionMembersManage Permission = "members:manage"
+)
+
+// rolePermissions is the permission matrix, owners can do everything, admins everything but deleting and
+// transferring the organization, editors can change services and versions and viewers can only read
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleOwner: {
+		PermissionOrgRead: true, PermissionOrgUpdate: true, PermissionOrgDelete: true, PermissionOrgTransfer: true,
+		PermissionServicesRead: true, PermissionServicesWrite: true,
+		PermissionVersionsRead: true, PermissionVersionsWrite: true,
+		PermissionMembersRead: true, PermissionMembersManage: true,
+	},
+	RoleAdmin: {
+		PermissionOrgRead: true, PermissionOrgUpdate: true,
+		PermissionServicesRead: true, PermissionServicesWrite: true,
+		PermissionVersionsRead: true, PermissionVersionsWrite: true,
+		PermissionMembersRead: true, PermissionMembersManage: true,
+	},
+	RoleEditor: {
+		PermissionOrgRead:      true,
+		PermissionServicesRead: true, PermissionServicesWrite: true,
+		PermissionVersionsRead: true, PermissionVersionsWrite: true,
+		PermissionMembersRead: true,
+	},
+	RoleViewer: {
+		PermissionOrgRead:      true,
+		PermissionServicesRead: true,
+		PermissionVersionsRead: true,
+		PermissionMembersRead:  true,
+	},
+}
+
+// permissionScopes maps a permission to the personal access token scope it needs
+var permissionScopes = map[Permission]string{
+	PermissionOrgRead:       ScopeOrgsRead,
+	PermissionOrgUpdate:     ScopeOrgsWrite,
+	PermissionOrgDelete:     ScopeOrgsWrite,
+	PermissionOrgTransfer:   ScopeOrgsWrite,
+	PermissionServicesRead:  ScopeServicesRead,
+	PermissionServicesWrite: ScopeServicesWrite,
+	PermissionVersionsRead:  ScopeVersionsRead,
+	PermissionVersionsWrite: ScopeVersionsWrite,
+	PermissionMembersRead:   ScopeOrgsRead,
+	PermissionMembersManage: ScopeOrgsWrite,
+}
+
+// roleRanks orders the roles from the least to the most privileged
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// Outranks returns whether the role is more privileged than the other role
+func (r Role) Outranks(other Role) bool {
+	return roleRanks[r] > roleRanks[other]
+}
+
+// IsValid returns whether the role is one of the known roles
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can returns whether the role has the permission
+func (r Role) Can(permission Permission) bool {
+	return rolePermissions[r][permission]
+}
+
+// Scope returns the personal access token scope needed for the permission
+func (p Permission) Scope() string {
+	return permissionScopes[p]
+}
+
+// organizationRoleContextKey is the gin context key the role of the user in the organization of the request is stored under
+const organizationRoleContextKey = "organization_role"
+
+// SetOrganizationRole stores the role of the user in the organization of the request in the gin context
+func SetOrganizationRole(c *gin.Context, role Role) {
+	c.Set(organizationRoleContextKey, role)
+}
+
+// GetOrganizationRole returns the role set by OrganizationAccessMiddleware, empty outside organization routes
+func GetOrganizationRole(c *gin.Context) Role {
+	role, exists := c.Get(organizationRoleContextKey)
+	if !exists {
+		return ""
+	}
+	return role.(Role)
+}
+
+// MigrateMembershipRoles adds the role column to memberships created before roles existed. It has to run
+// before the auto migration. Every member could do everything back then, creators become owners and the
+// other members admins so that nobody loses access, organizations whose creator left get their oldest
+// member as owner.
+func MigrateMembershipRoles(ctx context.Context) error {
+	db := db.GetDB()
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&UserOrganizationMap{}) || migrator.HasColumn(&UserOrganizationMap{}, "Role") {
+		return nil
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Exec("ALTER TABLE user_organization_maps ADD COLUMN role varchar(20) NOT NULL DEFAULT 'admin'").Error; err != nil {
+		log.With(ctx).Errorf("failed to add role column to user organization maps :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Exec(`UPDATE user_organization_maps SET role = 'owner'
+		FROM organizations
+		WHERE organizations.id = user_organization_maps.organization_id
+		AND organizations.created_by = user_organization_maps.user_id`).Error; err != nil {
+		log.With(ctx).Errorf("failed to make organization creators owners :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Exec(`UPDATE user_organization_maps SET role = 'owner'
+		WHERE (organization_id, user_id) IN (
+			SELECT DISTINCT ON (organization_id) organization_id, user_id FROM user_organization_maps
+			WHERE deleted_at IS NULL AND organization_id NOT IN (
+				SELECT organization_id FROM user_organization_maps WHERE role = 'owner' AND deleted_at IS NULL
+			)
+			ORDER BY organization_id, created_at
+		)`).Error; err != nil {
+		log.With(ctx).Errorf("failed to assign owners to organizations without one :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
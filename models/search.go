@@ -0,0 +1,277 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+)
+
+// SearchKind is the kind of resource a search hit is
+type SearchKind string
+
+const (
+	SearchOrganization SearchKind = "organization"
+	SearchService      SearchKind = "service"
+	SearchVersion      SearchKind = "version"
+)
+
+// searchQuery is the full-text query of the search term, websearch syntax supports "quoted phrases", or and -excluded words
+const searchQuery = "websearch_to_tsquery('english', ?)"
+
+// searchHeadline highlights the matches of a snippet, the text has to be escaped with escapeHTML first so that only
+// the <mark> tags are markup
+const searchHeadline = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// escapeHTML returns the SQL expression escaping the HTML special characters of the text expression, the parser of
+// the full-text search skips the entities so that the escaped text matches the same words
+func escapeHTML(expr string) string {
+	return "replace(replace(replace(replace(replace(" + expr + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '\"', '&quot;'), '''', '&#39;')"
+}
+
+// SearchHit is a resource matching a search, ranked by Score
+type SearchHit struct {
+	Kind SearchKind `json:"kind"`
+	ID   string     `json:"id"`
+	// OrganizationID, OrganizationName and OrganizationSlug are the organization of the hit, or the organization itself
+	OrganizationID   string `json:"organizationId"`
+	OrganizationName string `json:"organizationName"`
+	OrganizationSlug string `json:"organizationSlug"`
+	// ServiceID is the service of a version, or the service itself
+	ServiceID string `json:"serviceId,omitempty"`
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	// Score is the relevance of the full-text match, or the trigram similarity of the name when nothing matched
+	Score float64 `json:"score"`
+	// NameSnippet and DescriptionSnippet are HTML escaped with the matching words wrapped in <mark> tags
+	NameSnippet        string `json:"nameSnippet"`
+	DescriptionSnippet string `json:"descriptionSnippet,omitempty"`
+}
+
+// SearchResult is a page of search hits, most relevant first
+type SearchResult struct {
+	PaginatedResult[SearchHit]
+	// DidYouMean is the most similar name when nothing matched the query, the hits are then the resources
+	// with a name similar to the query
+	DidYouMean string `json:"didYouMean,omitempty"`
+}
+
+type SearchModel struct{}
+
+// MigrateSearch adds the generated tsvector columns of organizations, services and versions along with the GIN
+// indexes for the full-text search and the trigram indexes for similar names. It has to run after the auto migration.
+func MigrateSearch(ctx context.Context) error {
+	db := db.GetDB()
+
+	statements := []string{
+		// https://www.postgresql.org/docs/current/pgtrgm.html
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		`ALTER TABLE organizations ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED`,
+		`ALTER TABLE services ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED`,
+		`ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(version, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED`,
+		"CREATE INDEX IF NOT EXISTS idx_organizations_search_vector ON organizations USING gin (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_services_search_vector ON services USING gin (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_service_versions_search_vector ON service_versions USING gin (search_vector)",
+		// serves the name ILIKE filters as well as the similarity fallback
+		"CREATE INDEX IF NOT EXISTS idx_organizations_name_trgm ON organizations USING gin (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_services_name_trgm ON services USING gin (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_service_versions_name_trgm ON service_versions USING gin (name gin_trgm_ops)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			log.With(ctx).Errorf("failed to migrate search :: error: %s", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchScope is what a search across organizations covers, a personal access token limits it to its
+// organizations and the kinds of resources its scopes allow
+type SearchScope struct {
+	// OrganizationIDs limits the search to these organizations when not nil
+	OrganizationIDs []string
+	Organizations   bool
+	Services        bool
+	Versions        bool
+}
+
+// searchTarget is a kind of resource which can be searched, its table has a name, a description and a search_vector
+type searchTarget struct {
+	kind  SearchKind
+	table string
+	// serviceID and version are the expressions selected for the columns of SearchHit
+	serviceID string
+	version   string
+}
+
+var (
+	organizationTarget = searchTarget{kind: SearchOrganization, table: "organizations", serviceID: "''", version: "''"}
+	serviceTarget      = searchTarget{kind: SearchService, table: "services", serviceID: "services.id", version: "''"}
+	versionTarget      = searchTarget{kind: SearchVersion, table: "service_versions", serviceID: "service_versions.service_id", version: "service_versions.version"}
+)
+
+// columns returns the select of the hits of the target, every query of a search has to select the columns in the
+// same order since UNION matches them by position. The organization of the hit has to be joined.
+func (t searchTarget) columns(score string, nameSnippet string, descriptionSnippet string) string {
+	return fmt.Sprintf(`'%s' AS kind, %s.id, organizations.id AS organization_id, organizations.name AS organization_name,
+		organizations.slug AS organization_slug, %s AS service_id, %s.name, %s AS version,
+		%s AS score, %s AS name_snippet, %s AS description_snippet`,
+		t.kind, t.table, t.serviceID, t.table, t.version, score, nameSnippet, descriptionSnippet)
+}
+
+// Organization searches the names and descriptions of the services and versions of the organization the viewer
+// can see, see TeamModel.ServiceAccess. Versions are left out when includeVersions is false. Hits are ranked by
+// relevance, when nothing matches the hits are the resources with a name similar to the query.
+func (m SearchModel) Organization(ctx context.Context, orgID string, viewer ServiceViewer, q string, includeVersions bool, page int, limit int) (result SearchResult, err error) {
+	db := db.GetDB()
+
+	queries := func(hits func(*gorm.DB, searchTarget, string) *gorm.DB) []*gorm.DB {
+		queries := []*gorm.DB{hits(visibleServices(m.services(db).Where("services.organization_id = ?", orgID), viewer), serviceTarget, q)}
+		if includeVersions {
+			queries = append(queries, hits(visibleServices(m.versions(db).Where("services.organization_id = ?", orgID), viewer), versionTarget, q))
+		}
+		return queries
+	}
+
+	return m.search(ctx, db, queries(m.matches), queries(m.similar), page, limit)
+}
+
+// Global searches the organizations the user is a member of along with their services and versions the user can
+// see, the memberships are joined in SQL so that nothing of other organizations is counted or returned. Hits are
+// ranked the same way as in Organization.
+func (m SearchModel) Global(ctx context.Context, userID string, scope SearchScope, q string, page int, limit int) (result SearchResult, err error) {
+	db := db.GetDB()
+
+	restrict := func(tx *gorm.DB) *gorm.DB {
+		if scope.OrganizationIDs != nil {
+			return tx.Where("organizations.id IN ?", scope.OrganizationIDs)
+		}
+		return tx
+	}
+	queries := func(hits func(*gorm.DB, searchTarget, string) *gorm.DB) []*gorm.DB {
+		var queries []*gorm.DB
+		if scope.Organizations {
+			organizations := joinMemberships(m.organizations(db), "organizations.id", userID)
+			queries = append(queries, hits(restrict(organizations), organizationTarget, q))
+		}
+		if scope.Services {
+			services := visibleMemberServices(joinMemberships(m.services(db), "organizations.id", userID), userID)
+			queries = append(queries, hits(restrict(services), serviceTarget, q))
+		}
+		if scope.Versions {
+			versions := visibleMemberServices(joinMemberships(m.versions(db), "organizations.id", userID), userID)
+			queries = append(queries, hits(restrict(versions), versionTarget, q))
+		}
+		return queries
+	}
+
+	return m.search(ctx, db, queries(m.matches), queries(m.similar), page, limit)
+}
+
+// organizations returns the query on the organizations which aren't deleted
+func (m SearchModel) organizations(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&Organization{})
+}
+
+// services returns the query on the services which aren't deleted joined with their organization
+func (m SearchModel) services(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&Service{}).
+		Joins("JOIN organizations ON organizations.id = services.organization_id AND organizations.deleted_at IS NULL")
+}
+
+// versions returns the query on the versions of services which aren't deleted joined with their service and organization
+func (m SearchModel) versions(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&ServiceVersion{}).
+		Joins("JOIN services ON services.id = service_versions.service_id AND services.deleted_at IS NULL").
+		Joins("JOIN organizations ON organizations.id = services.organization_id AND organizations.deleted_at IS NULL")
+}
+
+// matches restricts the query to the rows of the target matching q, ranked by ts_rank with the snippets highlighted
+func (m SearchModel) matches(query *gorm.DB, target searchTarget, q string) *gorm.DB {
+	table := target.table
+	return query.
+		Select(target.columns(
+			"ts_rank("+table+".search_vector, "+searchQuery+")",
+			"ts_headline('english', "+escapeHTML(table+".name")+", "+searchQuery+", ?)",
+			"ts_headline('english', "+escapeHTML(table+".description")+", "+searchQuery+", ?)"),
+			q, q, searchHeadline, q, searchHeadline).
+		Where(table+".search_vector @@ "+searchQuery, q)
+}
+
+// similar restricts the query to the rows of the target with a name similar to q, the % operator uses the trigram index
+func (m SearchModel) similar(query *gorm.DB, target searchTarget, q string) *gorm.DB {
+	table := target.table
+	return query.
+		Select(target.columns("similarity("+table+".name, ?)", escapeHTML(table+".name"), "''"), q).
+		Where(table+".name % ?", q)
+}
+
+// search returns a page of the hits of the matches, or of the similar names when nothing matches
+func (m SearchModel) search(ctx context.Context, tx *gorm.DB, matches []*gorm.DB, similar []*gorm.DB, page int, limit int) (SearchResult, error) {
+	if len(matches) == 0 {
+		return SearchResult{PaginatedResult: BuildPaginatedResult(make([]*SearchHit, 0), 0, page, limit)}, nil
+	}
+
+	result, err := m.page(ctx, tx, matches, page, limit)
+	if err != nil || result.Meta.TotalCount > 0 {
+		return result, err
+	}
+	return m.didYouMean(ctx, tx, similar, page, limit)
+}
+
+// hits combines the queries of the hits into one list, they all have to select the columns of SearchHit
+func (m SearchModel) hits(tx *gorm.DB, queries []*gorm.DB) *gorm.DB {
+	selects := make([]string, 0, len(queries))
+	vars := make([]interface{}, 0, len(queries))
+	for _, query := range queries {
+		selects = append(selects, "(?)")
+		vars = append(vars, query)
+	}
+	return tx.Table("("+strings.Join(selects, " UNION ALL ")+") AS hits", vars...)
+}
+
+// page returns a page of the combined hits of the queries, the most relevant first
+func (m SearchModel) page(ctx context.Context, tx *gorm.DB, queries []*gorm.DB, page int, limit int) (SearchResult, error) {
+	var totalCount int64
+	if err := m.hits(tx, queries).Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to count search hits :: error: %s", err.Error())
+		return SearchResult{}, err
+	}
+
+	hits := make([]*SearchHit, 0)
+	if err := m.hits(tx, queries).Order("score DESC, name, id").Limit(limit).Offset(page * limit).Scan(&hits).Error; err != nil {
+		log.With(ctx).Errorf("failed to get search hits :: error: %s", err.Error())
+		return SearchResult{}, err
+	}
+
+	return SearchResult{PaginatedResult: BuildPaginatedResult(hits, totalCount, page, limit)}, nil
+}
+
+// didYouMean returns a page of the hits with a name similar to the query, along with the most similar name
+func (m SearchModel) didYouMean(ctx context.Context, tx *gorm.DB, queries []*gorm.DB, page int, limit int) (SearchResult, error) {
+	result, err := m.page(ctx, tx, queries, page, limit)
+	if err != nil || result.Meta.TotalCount == 0 {
+		return result, err
+	}
+
+	var names []string
+	if err := m.hits(tx, queries).Order("score DESC, name").Limit(1).Pluck("name", &names).Error; err != nil {
+		log.With(ctx).Errorf("failed to get most similar name :: error: %s", err.Error())
+		return SearchResult{}, err
+	}
+	if len(names) > 0 {
+		result.DidYouMean = names[0]
+	}
+	return result, nil
+}
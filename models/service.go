@@ -9,16 +9,29 @@ import (
 	"github.com/google/uuid"
 	"github.com/thilak009/kong-assignment/db"
 	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/labels"
 	"github.com/thilak009/kong-assignment/pkg/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
 	BaseWithId
-	Name           string          `json:"name"`
-	Description    string          `json:"description"`
-	OrganizationID string          `json:"organizationId"`
-	Metadata       ServiceMetadata `json:"metadata" gorm:"-"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Slug can be used in place of the id in routes, it is unique within the organization
+	Slug           string `json:"slug" gorm:"uniqueIndex:idx_services_organization_slug,priority:2"`
+	OrganizationID string `json:"organizationId" gorm:"uniqueIndex:idx_services_organization_slug,priority:1"`
+	// Labels group services for label selectors, the GIN index serves the selector queries
+	Labels map[string]string `json:"labels" gorm:"serializer:json;type:jsonb;not null;default:'{}';index:,type:gin"`
+	// Attributes are free form, an organization can register a schema for them, see AttributeSchema
+	Attributes map[string]interface{} `json:"attributes" gorm:"serializer:json;type:jsonb;not null;default:'{}';index:,type:gin"`
+	// Lifecycle is changed through the transitions of CanTransition, existing services start as active
+	Lifecycle       LifecycleState `json:"lifecycle" gorm:"type:varchar(20);not null;default:'active';index"`
+	LifecycleReason string         `json:"lifecycleReason,omitempty"`
+	// SunsetAt is when a deprecated service is planned to be retired
+	SunsetAt *time.Time      `json:"sunsetAt,omitempty"`
+	Metadata ServiceMetadata `json:"metadata" gorm:"-"`
 	// Relationships
 	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
 }
@@ -51,17 +64,48 @@ func GetServiceValidSortFields() map[string]bool {
 	return serviceValidSortFields
 }
 
+// Create creates a service in the organization
+//
+// Returns a QuotaExceededError if the organization reached its limit of services, ErrSlugTaken if another
+// service of the organization has the slug and an InvalidAttributesError if the attributes don't match the schema.
 func (m ServiceModel) Create(ctx context.Context, form forms.CreateServiceForm, organizationID string) (service Service, err error) {
 	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := (QuotaModel{}).reserveService(ctx, tx, organizationID); err != nil {
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	if err := validateAttributes(ctx, tx, organizationID, AttributeServices, form.Attributes); err != nil {
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	slug, err := (SlugModel{}).assign(ctx, tx, SlugService, organizationID, form.Slug, form.Name)
+	if err != nil {
+		tx.Rollback()
+		return Service{}, err
+	}
+
 	service = Service{
 		Name:           form.Name,
 		Description:    form.Description,
+		Slug:           slug,
 		OrganizationID: organizationID,
+		Labels:         emptyLabels(form.Labels),
+		Attributes:     emptyAttributes(form.Attributes),
+		Lifecycle:      LifecycleActive,
+	}
+	if form.Lifecycle != "" {
+		service.Lifecycle = LifecycleState(form.Lifecycle)
 	}
-	if err := db.Model(&Service{}).Create(&service).Error; err != nil {
+	if err := tx.Model(&Service{}).Create(&service).Error; err != nil {
 		log.With(ctx).Errorf("failed to create service for organization with id %s :: error: %s", organizationID, err.Error())
+		tx.Rollback()
 		return Service{}, err
 	}
+	tx.Commit()
 	return service, err
 }
 
@@ -89,14 +133,22 @@ func (m ServiceModel) One(ctx context.Context, id string, organizationID string,
 	return service, true, nil
 }
 
-func (m ServiceModel) All(ctx context.Context, organizationID string, q string, sortBy string, sort string, page int, limit int, includeVersionCount bool) (result PaginatedResult[Service], err error) {
+// All returns the services of the organization the viewer can see, see TeamModel.ServiceAccess, which match
+// the label selector and attribute filters and are in one of the lifecycle states, all states when none are given
+func (m ServiceModel) All(ctx context.Context, organizationID string, viewer ServiceViewer, q string, selector labels.Selector, attributes []AttributeFilter, lifecycles []LifecycleState, sortBy string, sort string, page int, limit int, includeVersionCount bool) (result PaginatedResult[Service], err error) {
 	db := db.GetDB()
 	services := make([]*Service, 0) // Initialize as empty slice of pointers
 	tx := db.Model(&Service{}).Where("organization_id = ?", organizationID)
+	tx = visibleServices(tx, viewer)
 
-	// Search filter
+	// Search filter, matches the words of the name and description or a part of the name, see SearchModel for ranked results
 	if q != "" {
-		tx = tx.Where("name ILIKE ?", fmt.Sprintf("%%%s%%", q))
+		tx = tx.Where("(services.search_vector @@ "+searchQuery+" OR services.name ILIKE ?)", q, fmt.Sprintf("%%%s%%", q))
+	}
+	tx = withLabels(tx, "services.labels", selector)
+	tx = withAttributes(tx, "services.attributes", attributes)
+	if len(lifecycles) > 0 {
+		tx = tx.Where("services.lifecycle IN ?", lifecycles)
 	}
 
 	// Get total count for pagination
@@ -155,12 +207,38 @@ func (m ServiceModel) All(ctx context.Context, organizationID string, q string,
 	return BuildPaginatedResult(services, totalCount, page, limit), nil
 }
 
+// Update changes the provided fields of the service, a new slug replaces the current one which keeps
+// redirecting to the service and a new lifecycle state has to be allowed from the current one, see applyLifecycle
+//
+// Returns ErrSlugTaken if another service of the organization has the slug, a LifecycleTransitionError or
+// ErrSunsetNotDeprecated if the lifecycle change isn't allowed and an InvalidAttributesError if the new attributes
+// don't match the schema.
 func (m ServiceModel) Update(ctx context.Context, id string, organizationID string, form forms.UpdateServiceForm) (service Service, err error) {
 	db := db.GetDB()
+	tx := db.Begin()
 
-	// First check if service exists and belongs to organization
-	if err := db.Model(&Service{}).Where("id = ? AND organization_id = ?", id, organizationID).First(&service).Error; err != nil {
+	// First check if service exists and belongs to organization, the row is locked so that concurrent updates
+	// check their lifecycle change against the state the other one left
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&Service{}).Where("id = ? AND organization_id = ?", id, organizationID).First(&service).Error; err != nil {
 		log.With(ctx).Errorf("failed to find service with id %s for organization with id %s :: error: %s", id, organizationID, err.Error())
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	if err := applyLifecycle(&service, form); err != nil {
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	if form.Attributes != nil {
+		if err := validateAttributes(ctx, tx, organizationID, AttributeServices, form.Attributes); err != nil {
+			tx.Rollback()
+			return Service{}, err
+		}
+	}
+
+	if err := (SlugModel{}).change(ctx, tx, SlugService, organizationID, id, service.Slug, form.Slug); err != nil {
+		tx.Rollback()
 		return Service{}, err
 	}
 
@@ -171,23 +249,40 @@ func (m ServiceModel) Update(ctx context.Context, id string, organizationID stri
 	if form.Description != "" {
 		service.Description = form.Description
 	}
+	if form.Slug != "" {
+		service.Slug = form.Slug
+	}
+	// labels are replaced as a whole, an empty object removes all of them
+	if form.Labels != nil {
+		service.Labels = form.Labels
+	}
+	// so are attributes
+	if form.Attributes != nil {
+		service.Attributes = form.Attributes
+	}
 
-	if err := db.Save(&service).Error; err != nil {
+	if err := tx.Save(&service).Error; err != nil {
 		log.With(ctx).Errorf("failed to update service with id %s for organization with id %s :: error: %s", id, organizationID, err.Error())
+		tx.Rollback()
 		return Service{}, err
 	}
+	tx.Commit()
 	return service, err
 }
 
+// Delete moves the service to the trash along with its versions, both get the same deleted_at so that restoring
+// the service brings back the versions deleted with it, see TrashModel.RestoreService. Grants and previous slugs
+// are kept for the restore.
 func (m ServiceModel) Delete(ctx context.Context, id string, organizationID string) (err error) {
 	db := db.GetDB()
+	deletedAt := time.Now()
 	tx := db.Begin()
-	if err := tx.Where("service_id = ?", id).Delete(&ServiceVersion{}).Error; err != nil {
+	if err := tx.Model(&ServiceVersion{}).Where("service_id = ?", id).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete service versions for service with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
-	if err := tx.Where("id = ? AND organization_id = ?", id, organizationID).Delete(&Service{}).Error; err != nil {
+	if err := tx.Model(&Service{}).Where("id = ? AND organization_id = ?", id, organizationID).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete service with id %s for organization with id %s :: error: %s", id, organizationID, err.Error())
 		tx.Rollback()
 		return err
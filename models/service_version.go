@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/thilak009/kong-assignment/db"
 	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/labels"
 	"github.com/thilak009/kong-assignment/pkg/log"
 	"gorm.io/gorm"
 )
@@ -19,7 +20,11 @@ type ServiceVersion struct {
 	Version     string `json:"version" gorm:"uniqueIndex:idx_service_version"`
 	Description string `json:"description"`
 	ServiceID   string `json:"serviceId" gorm:"uniqueIndex:idx_service_version"`
-	Service     Service `gorm:"foreignKey:ServiceID" json:"-"`
+	// Labels group versions for label selectors, the GIN index serves the selector queries
+	Labels map[string]string `json:"labels" gorm:"serializer:json;type:jsonb;not null;default:'{}';index:,type:gin"`
+	// Attributes are free form, an organization can register a schema for them, see AttributeSchema
+	Attributes map[string]interface{} `json:"attributes" gorm:"serializer:json;type:jsonb;not null;default:'{}';index:,type:gin"`
+	Service    Service                `gorm:"foreignKey:ServiceID" json:"-"`
 }
 
 func (sv *ServiceVersion) BeforeCreate(tx *gorm.DB) (err error) {
@@ -46,18 +51,49 @@ func GetServiceVersionValidSortFields() map[string]bool {
 	return serviceVersionValidSortFields
 }
 
+// Create creates a version of the service
+//
+// Returns a QuotaExceededError if the service reached the limit of versions of its organization,
+// ErrServiceRetired if the service is retired and an InvalidAttributesError if the attributes don't match the schema.
 func (m ServiceVersionModel) Create(ctx context.Context, serviceID string, form forms.CreateServiceVersionForm) (serviceVersion ServiceVersion, err error) {
 	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := (QuotaModel{}).reserveVersion(ctx, tx, serviceID); err != nil {
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
+	if err := ensureNotRetired(ctx, tx, serviceID); err != nil {
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
+	var service Service
+	if err := tx.Select("organization_id").Where("id = ?", serviceID).First(&service).Error; err != nil {
+		log.With(ctx).Errorf("failed to find organization of service with id %s :: error: %s", serviceID, err.Error())
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+	if err := validateAttributes(ctx, tx, service.OrganizationID, AttributeVersions, form.Attributes); err != nil {
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
 	serviceVersion = ServiceVersion{
 		Name:        form.Name,
 		Version:     form.Version,
 		Description: form.Description,
 		ServiceID:   serviceID,
+		Labels:      emptyLabels(form.Labels),
+		Attributes:  emptyAttributes(form.Attributes),
 	}
-	if err := db.Model(&ServiceVersion{}).Create(&serviceVersion).Error; err != nil {
+	if err := tx.Model(&ServiceVersion{}).Create(&serviceVersion).Error; err != nil {
 		log.With(ctx).Errorf("failed to create service version for service with id %s :: error: %s", serviceID, err.Error())
+		tx.Rollback()
 		return ServiceVersion{}, err
 	}
+	tx.Commit()
 	return serviceVersion, err
 }
 
@@ -78,7 +114,8 @@ func (m ServiceVersionModel) One(ctx context.Context, serviceID string, organiza
 	return serviceVersion, true, nil
 }
 
-func (m ServiceVersionModel) All(ctx context.Context, serviceID string, organizationID string, q string, sortBy string, sort string, page int, limit int) (result PaginatedResult[ServiceVersion], err error) {
+// All returns the versions of the service which match the label selector and attribute filters
+func (m ServiceVersionModel) All(ctx context.Context, serviceID string, organizationID string, q string, selector labels.Selector, attributes []AttributeFilter, sortBy string, sort string, page int, limit int) (result PaginatedResult[ServiceVersion], err error) {
 	db := db.GetDB()
 	serviceVersions := make([]*ServiceVersion, 0) // Initialize as empty slice of pointers
 
@@ -91,6 +128,8 @@ func (m ServiceVersionModel) All(ctx context.Context, serviceID string, organiza
 	if q != "" {
 		tx = tx.Where("version ILIKE ?", fmt.Sprintf("%s%%", q))
 	}
+	tx = withLabels(tx, "service_versions.labels", selector)
+	tx = withAttributes(tx, "service_versions.attributes", attributes)
 
 	// Get total count for pagination
 	var totalCount int64
@@ -131,6 +170,17 @@ func (m ServiceVersionModel) Update(ctx context.Context, serviceID string, organ
 	if form.Description != "" {
 		serviceVersion.Description = form.Description
 	}
+	// labels are replaced as a whole, an empty object removes all of them
+	if form.Labels != nil {
+		serviceVersion.Labels = form.Labels
+	}
+	// so are attributes, they have to match the schema of the organization
+	if form.Attributes != nil {
+		if err := validateAttributes(ctx, db, organizationID, AttributeVersions, form.Attributes); err != nil {
+			return ServiceVersion{}, err
+		}
+		serviceVersion.Attributes = form.Attributes
+	}
 
 	if err := db.Save(&serviceVersion).Error; err != nil {
 		log.With(ctx).Errorf("failed to update service version with id with id %s for service with id %s :: error: %s", id, serviceID, err.Error())
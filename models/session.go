@@ -0,0 +1,236 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSessionInvalid is returned when the session of an access token is unknown, revoked or from an older token epoch
+	ErrSessionInvalid = errors.New("invalid session")
+	// ErrSessionNotFound is returned when revoking a session which doesn't exist or belongs to another user
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Session is a login of a user. Access tokens reference it with the sid claim and the refresh tokens
+// of the login are the token family with the ID of the session.
+type Session struct {
+	CreatedAt  time.Time `json:"createdAt" gorm:"<-:create"`
+	ID         string    `json:"id" gorm:"primaryKey"`
+	UserID     string    `json:"-" gorm:"index"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ipAddress"`
+	UserAgent  string    `json:"userAgent"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// Epoch is the token epoch of the user when the session was created
+	Epoch     int        `json:"-"`
+	RevokedAt *time.Time `json:"-"`
+	// Current is set when listing sessions for the session making the request
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New().String()
+	s.CreatedAt = time.Now()
+	s.LastSeenAt = time.Now()
+	return
+}
+
+// SessionInfo describes the client a session is created for
+type SessionInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type SessionModel struct{}
+
+// Create records a new login of the user at the current token epoch of the user
+func (m SessionModel) Create(ctx context.Context, userID string, info SessionInfo) (session Session, err error) {
+	db := db.GetDB()
+
+	var user User
+	if err := db.Select("token_epoch").Where("id = ?", userID).First(&user).Error; err != nil {
+		log.With(ctx).Errorf("failed to find token epoch of user with id %s :: error: %s", userID, err.Error())
+		return Session{}, err
+	}
+
+	session = Session{
+		UserID:    userID,
+		Device:    utils.DescribeDevice(info.UserAgent),
+		IPAddress: info.IPAddress,
+		UserAgent: info.UserAgent,
+		Epoch:     user.TokenEpoch,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		log.With(ctx).Errorf("failed to create session for user with id %s :: error: %s", userID, err.Error())
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// Authenticate checks that the session of an access token is still valid, i.e. it is not revoked and
+// the token is from the current token epoch of the user. The last seen time of the session is updated.
+// Valid sessions are cached for a short while.
+//
+// Returns ErrSessionInvalid if the token can't be used anymore.
+func (m SessionModel) Authenticate(ctx context.Context, sessionID string, userID string, epoch int) (err error) {
+	if cached, ok := authCaches().sessions.Get(sessionID); ok && cached.UserID == userID && cached.Epoch == epoch {
+		return nil
+	}
+
+	db := db.GetDB()
+
+	var session Session
+	if err := db.Model(&Session{}).Select("sessions.*").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.revoked_at IS NULL AND users.token_epoch = ?", sessionID, userID, epoch).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionInvalid
+		}
+		log.With(ctx).Errorf("failed to find session with id %s :: error: %s", sessionID, err.Error())
+		return err
+	}
+
+	// the last seen time is only updated on a cache miss, the cache expires well within its one minute precision
+	m.touch(ctx, sessionID)
+	authCaches().sessions.Set(sessionID, cachedSession{UserID: userID, Epoch: epoch})
+	return nil
+}
+
+// Refresh returns the session of a refresh token family for issuing a new access token
+//
+// Returns ErrSessionInvalid if the session is unknown or revoked.
+func (m SessionModel) Refresh(ctx context.Context, sessionID string) (session Session, err error) {
+	db := db.GetDB()
+
+	if err := db.Where("id = ? AND revoked_at IS NULL", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Session{}, ErrSessionInvalid
+		}
+		log.With(ctx).Errorf("failed to find session with id %s :: error: %s", sessionID, err.Error())
+		return Session{}, err
+	}
+
+	m.touch(ctx, sessionID)
+	return session, nil
+}
+
+// touch updates the last seen time, it doesn't need to be exact so it is written at most once a minute
+func (m SessionModel) touch(ctx context.Context, sessionID string) {
+	db := db.GetDB()
+
+	now := time.Now()
+	if err := db.Model(&Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, now.Add(-time.Minute)).
+		UpdateColumn("last_seen_at", now).Error; err != nil {
+		log.With(ctx).Errorf("failed to update last seen of session with id %s :: error: %s", sessionID, err.Error())
+	}
+}
+
+// All returns the active sessions of the user, most recently used first
+func (m SessionModel) All(ctx context.Context, userID string) (sessions []Session, err error) {
+	db := db.GetDB()
+	sessions = make([]Session, 0)
+
+	// a session nobody refreshed for the lifetime of a refresh token can't be used anymore
+	if err := db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-refreshTokenTTL())).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+		log.With(ctx).Errorf("failed to get sessions of user with id %s :: error: %s", userID, err.Error())
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Revoke logs out a session of the user, its access tokens and refresh tokens stop working
+//
+// Returns ErrSessionNotFound if the session doesn't exist, is already revoked or belongs to another user.
+func (m SessionModel) Revoke(ctx context.Context, userID string, sessionID string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	result := tx.Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to revoke session with id %s :: error: %s", sessionID, result.Error.Error())
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrSessionNotFound
+	}
+
+	if err := (RefreshTokenModel{}).revokeFamily(ctx, tx, sessionID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	authCaches().sessions.Delete(sessionID)
+	return nil
+}
+
+// RevokeAll logs out every session of the user by moving the user to the next token epoch
+func (m SessionModel) RevokeAll(ctx context.Context, userID string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := m.revokeAll(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	invalidateUserSessions(userID)
+	return nil
+}
+
+// revokeAll bumps the token epoch of the user which invalidates every access token issued so far without
+// having to know them, the sessions and refresh tokens are revoked as well. Used when the credentials change.
+// Callers have to call invalidateUserSessions once the transaction is committed.
+func (m SessionModel) revokeAll(ctx context.Context, tx *gorm.DB, userID string) error {
+	if err := tx.Model(&User{}).Where("id = ?", userID).
+		UpdateColumn("token_epoch", gorm.Expr("token_epoch + 1")).Error; err != nil {
+		log.With(ctx).Errorf("failed to bump token epoch of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+
+	if err := tx.Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.With(ctx).Errorf("failed to revoke sessions of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+
+	return (RefreshTokenModel{}).revokeAllForUser(ctx, tx, userID)
+}
+
+// CleanupExpired removes revoked sessions and sessions which can't be refreshed anymore
+func (m SessionModel) CleanupExpired(ctx context.Context) error {
+	db := db.GetDB()
+
+	result := db.Where("revoked_at IS NOT NULL OR last_seen_at <= ?", time.Now().Add(-refreshTokenTTL())).
+		Delete(&Session{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to cleanup expired sessions :: error: %s", result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.With(ctx).Infof("cleaned up %d expired sessions", result.RowsAffected)
+	}
+
+	return nil
+}
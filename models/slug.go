@@ -0,0 +1,296 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSlugTaken is returned when another organization, or service of the organization, has the slug. Deleted
+// resources keep their slug so that they can be restored.
+var ErrSlugTaken = errors.New("slug is already taken")
+
+// SlugResource is a resource which can be addressed by its slug in place of its id
+type SlugResource string
+
+const (
+	SlugOrganization SlugResource = "organization"
+	SlugService      SlugResource = "service"
+)
+
+// maxGeneratedSlugLength leaves room for a collision suffix within the 60 characters a slug can have
+const maxGeneratedSlugLength = 50
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SlugRedirect keeps a previous slug of a resource so that links using it keep working
+type SlugRedirect struct {
+	CreatedAt time.Time    `gorm:"<-:create"`
+	Resource  SlugResource `gorm:"primaryKey;type:varchar(20)"`
+	// Scope is the organization of a service slug, empty for organization slugs
+	Scope    string `gorm:"primaryKey"`
+	Slug     string `gorm:"primaryKey"`
+	TargetID string `gorm:"index"`
+}
+
+// SlugTarget is the resource a slug resolves to, Moved is set when the slug is a previous slug of the resource
+type SlugTarget struct {
+	ID    string
+	Slug  string
+	Moved bool
+}
+
+type SlugModel struct{}
+
+// IsID returns whether value is an id rather than a slug, slugs can't have the format of an id
+func IsID(value string) bool {
+	return uuid.Validate(value) == nil
+}
+
+// slugify turns a name into a slug, e.g. "Payments API v2" becomes "payments-api-v2"
+func slugify(name string, fallback string) string {
+	slug := strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > maxGeneratedSlugLength {
+		slug = strings.TrimRight(slug[:maxGeneratedSlugLength], "-")
+	}
+	if slug == "" {
+		return fallback
+	}
+	return slug
+}
+
+// slugs returns the query for the resources which share the slugs of scope
+func (m SlugModel) slugs(tx *gorm.DB, resource SlugResource, scope string) *gorm.DB {
+	if resource == SlugService {
+		return tx.Model(&Service{}).Where("organization_id = ?", scope)
+	}
+	return tx.Model(&Organization{})
+}
+
+// lock serializes the slug changes of scope until tx ends, the unique indexes would otherwise fail
+// one of two resources created with the same name at the same time
+func (m SlugModel) lock(ctx context.Context, tx *gorm.DB, resource SlugResource, scope string) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("slugs:%s:%s", resource, scope)).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock %s slugs of scope %s :: error: %s", resource, scope, err.Error())
+		return err
+	}
+	return nil
+}
+
+// generate returns a slug for the name which no resource of scope has or had before, a numeric suffix is added on collisions
+func (m SlugModel) generate(ctx context.Context, tx *gorm.DB, resource SlugResource, scope string, name string) (string, error) {
+	base := slugify(name, string(resource))
+
+	// deleted resources keep their slug so that restoring them doesn't collide
+	var current, previous []string
+	if err := m.slugs(tx.Unscoped(), resource, scope).Where("slug = ? OR slug LIKE ?", base, base+"-%").Pluck("slug", &current).Error; err != nil {
+		log.With(ctx).Errorf("failed to find %s slugs like %s :: error: %s", resource, base, err.Error())
+		return "", err
+	}
+	if err := tx.Model(&SlugRedirect{}).Where("resource = ? AND scope = ? AND (slug = ? OR slug LIKE ?)", resource, scope, base, base+"-%").
+		Pluck("slug", &previous).Error; err != nil {
+		log.With(ctx).Errorf("failed to find previous %s slugs like %s :: error: %s", resource, base, err.Error())
+		return "", err
+	}
+
+	taken := make(map[string]bool, len(current)+len(previous))
+	for _, slug := range append(current, previous...) {
+		taken[slug] = true
+	}
+
+	slug := base
+	for i := 2; taken[slug] || IsID(slug); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug, nil
+}
+
+// assign returns the slug for a new resource of scope, the requested slug or one generated from the name when empty
+//
+// Returns ErrSlugTaken if another resource has the requested slug, a previous slug of another resource is given up.
+func (m SlugModel) assign(ctx context.Context, tx *gorm.DB, resource SlugResource, scope string, requested string, name string) (string, error) {
+	if err := m.lock(ctx, tx, resource, scope); err != nil {
+		return "", err
+	}
+
+	if requested == "" {
+		return m.generate(ctx, tx, resource, scope, name)
+	}
+
+	if err := m.claim(ctx, tx, resource, scope, requested, ""); err != nil {
+		return "", err
+	}
+	return requested, nil
+}
+
+// change moves the resource to the slug, its current slug keeps redirecting to it
+//
+// Returns ErrSlugTaken if another resource has the slug, a previous slug of another resource is given up.
+func (m SlugModel) change(ctx context.Context, tx *gorm.DB, resource SlugResource, scope string, targetID string, current string, slug string) error {
+	if slug == "" || slug == current {
+		return nil
+	}
+
+	if err := m.lock(ctx, tx, resource, scope); err != nil {
+		return err
+	}
+
+	if err := m.claim(ctx, tx, resource, scope, slug, targetID); err != nil {
+		return err
+	}
+
+	if current == "" {
+		return nil
+	}
+	redirect := SlugRedirect{CreatedAt: time.Now(), Resource: resource, Scope: scope, Slug: current, TargetID: targetID}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource"}, {Name: "scope"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_id", "created_at"}),
+	}).Create(&redirect).Error; err != nil {
+		log.With(ctx).Errorf("failed to keep %s slug %s of %s :: error: %s", resource, current, targetID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// claim returns ErrSlugTaken if a resource other than targetID has the slug, otherwise the slug stops redirecting
+// in case it is a previous slug of another resource
+func (m SlugModel) claim(ctx context.Context, tx *gorm.DB, resource SlugResource, scope string, slug string, targetID string) error {
+	var count int64
+	if err := m.slugs(tx.Unscoped(), resource, scope).Where("slug = ? AND id <> ?", slug, targetID).Count(&count).Error; err != nil {
+		log.With(ctx).Errorf("failed to check %s slug %s :: error: %s", resource, slug, err.Error())
+		return err
+	}
+	if count > 0 {
+		return ErrSlugTaken
+	}
+
+	if err := tx.Where("resource = ? AND scope = ? AND slug = ?", resource, scope, slug).Delete(&SlugRedirect{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to release previous %s slug %s :: error: %s", resource, slug, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Resolve returns the organization, or service of the organization in scope, with the slug. A previous slug
+// resolves to the resource it was moved from with Moved set.
+//
+// isFound is false when no resource has the slug or had it before.
+func (m SlugModel) Resolve(ctx context.Context, resource SlugResource, scope string, slug string) (target SlugTarget, isFound bool, err error) {
+	db := db.GetDB()
+
+	var ids []string
+	if err := m.slugs(db, resource, scope).Where("slug = ?", slug).Pluck("id", &ids).Error; err != nil {
+		log.With(ctx).Errorf("failed to resolve %s slug %s :: error: %s", resource, slug, err.Error())
+		return SlugTarget{}, false, err
+	}
+	if len(ids) > 0 {
+		return SlugTarget{ID: ids[0], Slug: slug}, true, nil
+	}
+
+	var redirect SlugRedirect
+	if err := db.Where("resource = ? AND scope = ? AND slug = ?", resource, scope, slug).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SlugTarget{}, false, nil
+		}
+		log.With(ctx).Errorf("failed to resolve previous %s slug %s :: error: %s", resource, slug, err.Error())
+		return SlugTarget{}, false, err
+	}
+
+	var slugs []string
+	if err := m.slugs(db, resource, scope).Where("id = ?", redirect.TargetID).Pluck("slug", &slugs).Error; err != nil {
+		log.With(ctx).Errorf("failed to find slug of %s with id %s :: error: %s", resource, redirect.TargetID, err.Error())
+		return SlugTarget{}, false, err
+	}
+	if len(slugs) == 0 {
+		return SlugTarget{}, false, nil
+	}
+	return SlugTarget{ID: redirect.TargetID, Slug: slugs[0], Moved: true}, true, nil
+}
+
+// MigrateSlugs adds the slug column to organizations and services created before slugs existed and generates
+// their slugs from the names. It has to run before the auto migration creates the unique indexes.
+func MigrateSlugs(ctx context.Context) error {
+	db := db.GetDB()
+	migrator := db.Migrator()
+	m := SlugModel{}
+
+	// generating slugs checks the previous slugs as well
+	if err := migrator.AutoMigrate(&SlugRedirect{}); err != nil {
+		log.With(ctx).Errorf("failed to migrate slug redirects :: error: %s", err.Error())
+		return err
+	}
+
+	if migrator.HasTable(&Organization{}) && !migrator.HasColumn(&Organization{}, "Slug") {
+		tx := db.Begin()
+
+		if err := tx.Exec("ALTER TABLE organizations ADD COLUMN slug text").Error; err != nil {
+			log.With(ctx).Errorf("failed to add slug column to organizations :: error: %s", err.Error())
+			tx.Rollback()
+			return err
+		}
+
+		var organizations []Organization
+		if err := tx.Unscoped().Select("id", "name").Order("created_at").Find(&organizations).Error; err != nil {
+			log.With(ctx).Errorf("failed to find organizations without slugs :: error: %s", err.Error())
+			tx.Rollback()
+			return err
+		}
+		for _, organization := range organizations {
+			slug, err := m.generate(ctx, tx, SlugOrganization, "", organization.Name)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Unscoped().Model(&Organization{}).Where("id = ?", organization.ID).UpdateColumn("slug", slug).Error; err != nil {
+				log.With(ctx).Errorf("failed to set slug of organization with id %s :: error: %s", organization.ID, err.Error())
+				tx.Rollback()
+				return err
+			}
+		}
+
+		tx.Commit()
+	}
+
+	if migrator.HasTable(&Service{}) && !migrator.HasColumn(&Service{}, "Slug") {
+		tx := db.Begin()
+
+		if err := tx.Exec("ALTER TABLE services ADD COLUMN slug text").Error; err != nil {
+			log.With(ctx).Errorf("failed to add slug column to services :: error: %s", err.Error())
+			tx.Rollback()
+			return err
+		}
+
+		var services []Service
+		if err := tx.Unscoped().Select("id", "name", "organization_id").Order("created_at").Find(&services).Error; err != nil {
+			log.With(ctx).Errorf("failed to find services without slugs :: error: %s", err.Error())
+			tx.Rollback()
+			return err
+		}
+		for _, service := range services {
+			slug, err := m.generate(ctx, tx, SlugService, service.OrganizationID, service.Name)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Unscoped().Model(&Service{}).Where("id = ?", service.ID).UpdateColumn("slug", slug).Error; err != nil {
+				log.With(ctx).Errorf("failed to set slug of service with id %s :: error: %s", service.ID, err.Error())
+				tx.Rollback()
+				return err
+			}
+		}
+
+		tx.Commit()
+	}
+
+	return nil
+}
@@ -0,0 +1,473 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/forms"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTeamNameTaken is returned when another team of the organization has the name
+	ErrTeamNameTaken = errors.New("team name is already taken")
+	// ErrTeamMemberNotFound is returned when removing a user who is not a member of the team
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	// ErrServiceGrantNotFound is returned when revoking a grant the team doesn't have
+	ErrServiceGrantNotFound = errors.New("service grant not found")
+)
+
+// ServiceAccess is the access to a single service and its versions, each level includes the ones before it
+type ServiceAccess string
+
+const (
+	// ServiceAccessRead allows reading the service and its versions
+	ServiceAccessRead ServiceAccess = "read"
+	// ServiceAccessWrite additionally allows updating the service and managing its versions
+	ServiceAccessWrite ServiceAccess = "write"
+	// ServiceAccessAdmin additionally allows deleting the service
+	ServiceAccessAdmin ServiceAccess = "admin"
+)
+
+var serviceAccessRanks = map[ServiceAccess]int{
+	ServiceAccessRead:  1,
+	ServiceAccessWrite: 2,
+	ServiceAccessAdmin: 3,
+}
+
+// Includes returns whether the access is at least the required access
+func (a ServiceAccess) Includes(required ServiceAccess) bool {
+	return serviceAccessRanks[a] >= serviceAccessRanks[required]
+}
+
+// roleServiceAccess is the access a role has to the services of an organization without teams, owners and admins
+// have it on every service
+var roleServiceAccess = map[Role]ServiceAccess{
+	RoleOwner:  ServiceAccessAdmin,
+	RoleAdmin:  ServiceAccessAdmin,
+	RoleEditor: ServiceAccessAdmin,
+	RoleViewer: ServiceAccessRead,
+}
+
+// seesAllServices returns whether the role has access to the services no team of the member was granted access to
+func seesAllServices(role Role) bool {
+	return role == RoleOwner || role == RoleAdmin
+}
+
+// Team is a group of members of an organization which can be granted access to specific services
+type Team struct {
+	BaseWithId
+	OrganizationID string `json:"organizationId" gorm:"index"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	// Members and Grants are only set when getting a single team
+	Members []Member       `json:"members,omitempty" gorm:"-"`
+	Grants  []ServiceGrant `json:"grants,omitempty" gorm:"-"`
+	// Relationships
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
+
+func (t *Team) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New().String()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+	return
+}
+
+func (t *Team) BeforeUpdate(tx *gorm.DB) (err error) {
+	t.UpdatedAt = time.Now()
+	return
+}
+
+// TeamMember is a member of the organization in a team
+type TeamMember struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	TeamID    string    `gorm:"primaryKey"`
+	UserID    string    `gorm:"primaryKey;index"`
+}
+
+// ServiceGrant gives the members of a team access to a service. Once the organization has a team only owners,
+// admins and the members of teams with a grant can see a service, see Organization.ServiceGrantsRequired.
+type ServiceGrant struct {
+	CreatedAt time.Time     `json:"createdAt" gorm:"<-:create"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	TeamID    string        `json:"teamId" gorm:"primaryKey"`
+	ServiceID string        `json:"serviceId" gorm:"primaryKey;index"`
+	Access    ServiceAccess `json:"access" gorm:"type:varchar(20);not null"`
+}
+
+// MigrateServiceGrants adds the service_grants_required column of organizations, organizations which already have
+// teams require grants from then on. It has to run before the auto migration, which would add the column for every
+// organization as not requiring grants.
+func MigrateServiceGrants(ctx context.Context) error {
+	db := db.GetDB()
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&Organization{}) || migrator.HasColumn(&Organization{}, "ServiceGrantsRequired") {
+		return nil
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Exec("ALTER TABLE organizations ADD COLUMN service_grants_required boolean NOT NULL DEFAULT false").Error; err != nil {
+		log.With(ctx).Errorf("failed to add service grants required column to organizations :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if migrator.HasTable(&Team{}) {
+		if err := tx.Exec("UPDATE organizations SET service_grants_required = true WHERE id IN (SELECT organization_id FROM teams WHERE deleted_at IS NULL)").Error; err != nil {
+			log.With(ctx).Errorf("failed to require service grants for organizations with teams :: error: %s", err.Error())
+			tx.Rollback()
+			return err
+		}
+	}
+
+	tx.Commit()
+	return nil
+}
+
+type TeamModel struct{}
+
+var teamValidSortFields = map[string]bool{
+	"name":       true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+func GetTeamValidSortFields() map[string]bool {
+	return teamValidSortFields
+}
+
+// ensureNameAvailable returns ErrTeamNameTaken if another team of the organization has the name
+func (m TeamModel) ensureNameAvailable(ctx context.Context, tx *gorm.DB, orgID string, name string, exceptID string) error {
+	var count int64
+	if err := tx.Model(&Team{}).
+		Where("organization_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", orgID, name, exceptID).
+		Count(&count).Error; err != nil {
+		log.With(ctx).Errorf("failed to check name of teams in organization with id %s :: error: %s", orgID, err.Error())
+		return err
+	}
+	if count > 0 {
+		return ErrTeamNameTaken
+	}
+	return nil
+}
+
+// Create adds a team to the organization, from then on services have to be granted to the teams of members
+// other than owners and admins, see Organization.ServiceGrantsRequired
+func (m TeamModel) Create(ctx context.Context, orgID string, form forms.CreateTeamForm) (team Team, err error) {
+	db := db.GetDB()
+
+	if err := m.ensureNameAvailable(ctx, db, orgID, form.Name, ""); err != nil {
+		return Team{}, err
+	}
+
+	tx := db.Begin()
+
+	team = Team{
+		OrganizationID: orgID,
+		Name:           form.Name,
+		Description:    form.Description,
+	}
+	if err := tx.Create(&team).Error; err != nil {
+		log.With(ctx).Errorf("failed to create team for organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Team{}, err
+	}
+
+	if err := tx.Model(&Organization{}).Where("id = ?", orgID).UpdateColumn("service_grants_required", true).Error; err != nil {
+		log.With(ctx).Errorf("failed to require service grants for organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Team{}, err
+	}
+
+	tx.Commit()
+	return team, nil
+}
+
+// returns isFound as false when there is either an error running the query or if the record is not found
+// caller must first check if err is not nil to know whether it is a record not found error
+// or some other error and not directly rely on isFound for record not found case
+func (m TeamModel) One(ctx context.Context, id string, orgID string) (team Team, isFound bool, err error) {
+	db := db.GetDB()
+
+	if err := db.Where("id = ? AND organization_id = ?", id, orgID).First(&team).Error; err != nil {
+		log.With(ctx).Errorf("failed to find team with id %s for organization with id %s :: error: %s", id, orgID, err.Error())
+		return Team{}, !errors.Is(err, gorm.ErrRecordNotFound), err
+	}
+
+	team.Members = make([]Member, 0)
+	if err := (MemberModel{}).query(db, orgID).
+		Joins("JOIN team_members ON team_members.user_id = user_organization_maps.user_id").
+		Where("team_members.team_id = ?", id).
+		Select(memberColumns).
+		Order("users.name").
+		Scan(&team.Members).Error; err != nil {
+		log.With(ctx).Errorf("failed to get members of team with id %s :: error: %s", id, err.Error())
+		return Team{}, true, err
+	}
+
+	team.Grants = make([]ServiceGrant, 0)
+	// grants on services in the trash are kept for restoring them
+	if err := db.Where("team_id = ? AND service_id IN (?)", id, db.Model(&Service{}).Select("id")).Order("created_at").Find(&team.Grants).Error; err != nil {
+		log.With(ctx).Errorf("failed to get grants of team with id %s :: error: %s", id, err.Error())
+		return Team{}, true, err
+	}
+
+	return team, true, nil
+}
+
+func (m TeamModel) All(ctx context.Context, orgID string, q string, sortBy string, sort string, page int, limit int) (result PaginatedResult[Team], err error) {
+	db := db.GetDB()
+	teams := make([]*Team, 0)
+
+	tx := db.Model(&Team{}).Where("organization_id = ?", orgID)
+
+	// Search filter
+	if q != "" {
+		tx = tx.Where("name ILIKE ?", fmt.Sprintf("%%%s%%", q))
+	}
+
+	// Get total count for pagination
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to get count of teams for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[Team]{}, err
+	}
+
+	// Apply sorting, validation and defaults are handled at API layer
+	tx = tx.Order(fmt.Sprintf("%s %s", sortBy, sort))
+
+	// Pagination
+	offset := page * limit
+	if err := tx.Limit(limit).Offset(offset).Find(&teams).Error; err != nil {
+		log.With(ctx).Errorf("failed to get teams for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[Team]{}, err
+	}
+
+	return BuildPaginatedResult(teams, totalCount, page, limit), nil
+}
+
+func (m TeamModel) Update(ctx context.Context, id string, orgID string, form forms.CreateTeamForm) (team Team, err error) {
+	db := db.GetDB()
+
+	if err := db.Where("id = ? AND organization_id = ?", id, orgID).First(&team).Error; err != nil {
+		log.With(ctx).Errorf("failed to find team with id %s for organization with id %s :: error: %s", id, orgID, err.Error())
+		return Team{}, err
+	}
+
+	if err := m.ensureNameAvailable(ctx, db, orgID, form.Name, id); err != nil {
+		return Team{}, err
+	}
+
+	team.Name = form.Name
+	team.Description = form.Description
+
+	if err := db.Save(&team).Error; err != nil {
+		log.With(ctx).Errorf("failed to update team with id %s for organization with id %s :: error: %s", id, orgID, err.Error())
+		return Team{}, err
+	}
+
+	return team, nil
+}
+
+// Delete removes the team along with its members and grants, services only the team had access to are left
+// to owners and admins until they are granted to another team
+func (m TeamModel) Delete(ctx context.Context, id string, orgID string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := tx.Where("team_id = ?", id).Delete(&TeamMember{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete members of team with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("team_id = ?", id).Delete(&ServiceGrant{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete grants of team with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("id = ? AND organization_id = ?", id, orgID).Delete(&Team{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete team with id %s for organization with id %s :: error: %s", id, orgID, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// AddMember adds a member of the organization to the team, adding a member twice is not an error
+//
+// Returns ErrMemberNotFound if the user is not a member of the organization of the team.
+func (m TeamModel) AddMember(ctx context.Context, team Team, userID string) error {
+	db := db.GetDB()
+
+	if _, isFound, err := (MemberModel{}).One(ctx, team.OrganizationID, userID); err != nil {
+		return err
+	} else if !isFound {
+		return ErrMemberNotFound
+	}
+
+	member := TeamMember{TeamID: team.ID, UserID: userID, CreatedAt: time.Now()}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		log.With(ctx).Errorf("failed to add user with id %s to team with id %s :: error: %s", userID, team.ID, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RemoveMember takes the user out of the team
+//
+// Returns ErrTeamMemberNotFound if the user is not a member of the team.
+func (m TeamModel) RemoveMember(ctx context.Context, teamID string, userID string) error {
+	db := db.GetDB()
+
+	result := db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&TeamMember{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to remove user with id %s from team with id %s :: error: %s", userID, teamID, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTeamMemberNotFound
+	}
+
+	return nil
+}
+
+// Grant gives the team access to a service of its organization, an existing grant is changed to the new access
+func (m TeamModel) Grant(ctx context.Context, teamID string, serviceID string, access ServiceAccess) (grant ServiceGrant, err error) {
+	db := db.GetDB()
+
+	now := time.Now()
+	grant = ServiceGrant{
+		CreatedAt: now,
+		UpdatedAt: now,
+		TeamID:    teamID,
+		ServiceID: serviceID,
+		Access:    access,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "service_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"access", "updated_at"}),
+	}).Create(&grant).Error; err != nil {
+		log.With(ctx).Errorf("failed to grant team with id %s access to service with id %s :: error: %s", teamID, serviceID, err.Error())
+		return ServiceGrant{}, err
+	}
+
+	return grant, nil
+}
+
+// Revoke removes the access of the team to the service, without any grant the service is left to owners and admins
+//
+// Returns ErrServiceGrantNotFound if the team has no access to the service.
+func (m TeamModel) Revoke(ctx context.Context, teamID string, serviceID string) error {
+	db := db.GetDB()
+
+	result := db.Where("team_id = ? AND service_id = ?", teamID, serviceID).Delete(&ServiceGrant{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to revoke access of team with id %s to service with id %s :: error: %s", teamID, serviceID, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrServiceGrantNotFound
+	}
+
+	return nil
+}
+
+// ServiceAccess returns the access of a member with the role to the service, empty if the member can't see it.
+// In an organization without teams services are accessible according to the role, once it has a team only to
+// owners, admins and the members of the teams with a grant. A grant can give more access than the role.
+func (m TeamModel) ServiceAccess(ctx context.Context, serviceID string, userID string, role Role) (ServiceAccess, error) {
+	if seesAllServices(role) {
+		return roleServiceAccess[role], nil
+	}
+
+	db := db.GetDB()
+
+	var grants []struct {
+		Access ServiceAccess
+		Own    bool
+	}
+	if err := db.Model(&ServiceGrant{}).
+		Select("service_grants.access, EXISTS (SELECT 1 FROM team_members WHERE team_members.team_id = service_grants.team_id AND team_members.user_id = ?) AS own", userID).
+		Where("service_grants.service_id = ?", serviceID).
+		Scan(&grants).Error; err != nil {
+		log.With(ctx).Errorf("failed to get grants of service with id %s :: error: %s", serviceID, err.Error())
+		return "", err
+	}
+
+	var access ServiceAccess
+	for _, grant := range grants {
+		if grant.Own && !access.Includes(grant.Access) {
+			access = grant.Access
+		}
+	}
+	if access != "" || len(grants) > 0 {
+		return access, nil
+	}
+
+	// services in the trash are checked too so that they are accessible the same way
+	var required []bool
+	if err := db.Unscoped().Model(&Service{}).
+		Joins("JOIN organizations ON organizations.id = services.organization_id").
+		Where("services.id = ?", serviceID).
+		Pluck("organizations.service_grants_required", &required).Error; err != nil {
+		log.With(ctx).Errorf("failed to check whether service with id %s requires grants :: error: %s", serviceID, err.Error())
+		return "", err
+	}
+	if len(required) > 0 && required[0] {
+		return "", nil
+	}
+	return roleServiceAccess[role], nil
+}
+
+// ServiceViewer is the member a list of services is for, in organizations with teams services are only listed
+// for the members allowed to see them
+type ServiceViewer struct {
+	UserID string
+	Role   Role
+}
+
+// serviceVisibleToUser is the condition of a service of an organization without teams or granted to a team of the user
+const serviceVisibleToUser = `(NOT EXISTS (SELECT 1 FROM organizations AS grant_orgs
+			WHERE grant_orgs.id = services.organization_id AND grant_orgs.service_grants_required)
+		OR EXISTS (SELECT 1 FROM service_grants JOIN team_members ON team_members.team_id = service_grants.team_id
+			WHERE service_grants.service_id = services.id AND team_members.user_id = ?))`
+
+// visibleServices restricts a query on services to the ones the viewer can see, it is applied before
+// counting so that pagination only counts visible services
+func visibleServices(tx *gorm.DB, viewer ServiceViewer) *gorm.DB {
+	if seesAllServices(viewer.Role) {
+		return tx
+	}
+	return tx.Where(serviceVisibleToUser, viewer.UserID)
+}
+
+// visibleMemberServices is visibleServices for queries across organizations, the role in the organization of each
+// service comes from the membership of the user joined by joinMemberships
+func visibleMemberServices(tx *gorm.DB, userID string) *gorm.DB {
+	// the roles seesAllServices is true for
+	return tx.Where("(user_organization_maps.role IN ? OR "+serviceVisibleToUser+")", []Role{RoleOwner, RoleAdmin}, userID)
+}
+
+// removeUserFromTeams takes the user out of the teams of the organization, used when the user leaves it
+func (m TeamModel) removeUserFromTeams(ctx context.Context, tx *gorm.DB, orgID string, userID string) error {
+	if err := tx.Where("user_id = ? AND team_id IN (?)", userID, tx.Model(&Team{}).Select("id").Where("organization_id = ?", orgID)).
+		Delete(&TeamMember{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to remove user with id %s from teams of organization with id %s :: error: %s", userID, orgID, err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotInTrash is returned when restoring something which isn't deleted, was purged or was deleted along with its parent
+var ErrNotInTrash = errors.New("not found in the trash")
+
+// DeletedService is a service in the trash
+type DeletedService struct {
+	Service
+	DeletedAt time.Time `json:"deletedAt"`
+	// PurgeAt is when the service is deleted for good
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// DeletedServiceVersion is a version in the trash which was deleted on its own
+type DeletedServiceVersion struct {
+	ServiceVersion
+	DeletedAt time.Time `json:"deletedAt"`
+	// PurgeAt is when the version is deleted for good
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// DeletedOrganization is an organization in the trash
+type DeletedOrganization struct {
+	Organization
+	DeletedAt time.Time `json:"deletedAt"`
+	// PurgeAt is when the organization is deleted for good
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+type TrashModel struct{}
+
+// TrashRetention returns for how long deleted organizations, services and versions can be restored
+// before they are deleted for good (default: 30 days)
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(utils.GetEnv("TRASH_RETENTION_DAYS", "30"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Services returns the services in the trash of the organization, most recently deleted first. The versions
+// deleted with a service are restored with it and not listed on their own.
+func (m TrashModel) Services(ctx context.Context, orgID string, viewer ServiceViewer, q string, page int, limit int) (result PaginatedResult[DeletedService], err error) {
+	db := db.GetDB()
+	services := make([]*Service, 0)
+	tx := visibleServices(db.Unscoped().Model(&Service{}).Where("organization_id = ? AND deleted_at IS NOT NULL", orgID), viewer)
+
+	if q != "" {
+		tx = tx.Where("name ILIKE ?", fmt.Sprintf("%%%s%%", q))
+	}
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to get count of deleted services for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[DeletedService]{}, err
+	}
+
+	if err := tx.Order("deleted_at DESC").Limit(limit).Offset(page * limit).Find(&services).Error; err != nil {
+		log.With(ctx).Errorf("failed to get deleted services for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[DeletedService]{}, err
+	}
+
+	deleted := make([]*DeletedService, 0, len(services))
+	for _, service := range services {
+		deleted = append(deleted, &DeletedService{
+			Service:   *service,
+			DeletedAt: service.DeletedAt.Time,
+			PurgeAt:   service.DeletedAt.Time.Add(TrashRetention()),
+		})
+	}
+	return BuildPaginatedResult(deleted, totalCount, page, limit), nil
+}
+
+// Versions returns the versions in the trash of services of the organization which aren't deleted themselves,
+// most recently deleted first
+func (m TrashModel) Versions(ctx context.Context, orgID string, viewer ServiceViewer, page int, limit int) (result PaginatedResult[DeletedServiceVersion], err error) {
+	db := db.GetDB()
+	versions := make([]*ServiceVersion, 0)
+	tx := visibleServices(db.Unscoped().Model(&ServiceVersion{}).
+		Joins("JOIN services ON services.id = service_versions.service_id AND services.deleted_at IS NULL").
+		Where("services.organization_id = ? AND service_versions.deleted_at IS NOT NULL", orgID), viewer)
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to get count of deleted versions for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[DeletedServiceVersion]{}, err
+	}
+
+	if err := tx.Select("service_versions.*").Order("service_versions.deleted_at DESC").Limit(limit).Offset(page * limit).Find(&versions).Error; err != nil {
+		log.With(ctx).Errorf("failed to get deleted versions for organization with id %s :: error: %s", orgID, err.Error())
+		return PaginatedResult[DeletedServiceVersion]{}, err
+	}
+
+	deleted := make([]*DeletedServiceVersion, 0, len(versions))
+	for _, version := range versions {
+		deleted = append(deleted, &DeletedServiceVersion{
+			ServiceVersion: *version,
+			DeletedAt:      version.DeletedAt.Time,
+			PurgeAt:        version.DeletedAt.Time.Add(TrashRetention()),
+		})
+	}
+	return BuildPaginatedResult(deleted, totalCount, page, limit), nil
+}
+
+// Organizations returns the organizations in the trash the user was an owner of when they were deleted,
+// most recently deleted first
+func (m TrashModel) Organizations(ctx context.Context, userID string, page int, limit int) (result PaginatedResult[DeletedOrganization], err error) {
+	db := db.GetDB()
+	organizations := make([]*Organization, 0)
+	tx := m.ownedOrganizations(db, userID)
+
+	var totalCount int64
+	if err := tx.Count(&totalCount).Error; err != nil {
+		log.With(ctx).Errorf("failed to get count of deleted organizations for user with id %s :: error: %s", userID, err.Error())
+		return PaginatedResult[DeletedOrganization]{}, err
+	}
+
+	if err := tx.Select("organizations.*").Order("organizations.deleted_at DESC").Limit(limit).Offset(page * limit).Find(&organizations).Error; err != nil {
+		log.With(ctx).Errorf("failed to get deleted organizations for user with id %s :: error: %s", userID, err.Error())
+		return PaginatedResult[DeletedOrganization]{}, err
+	}
+
+	deleted := make([]*DeletedOrganization, 0, len(organizations))
+	for _, organization := range organizations {
+		deleted = append(deleted, &DeletedOrganization{
+			Organization: *organization,
+			DeletedAt:    organization.DeletedAt.Time,
+			PurgeAt:      organization.DeletedAt.Time.Add(TrashRetention()),
+		})
+	}
+	return BuildPaginatedResult(deleted, totalCount, page, limit), nil
+}
+
+// ownedOrganizations returns the query for the deleted organizations the user owned when they were deleted
+func (m TrashModel) ownedOrganizations(tx *gorm.DB, userID string) *gorm.DB {
+	return tx.Unscoped().Model(&Organization{}).
+		Joins(`JOIN user_organization_maps ON user_organization_maps.organization_id = organizations.id
+			AND user_organization_maps.deleted_at = organizations.deleted_at`).
+		Where("organizations.deleted_at IS NOT NULL AND user_organization_maps.user_id = ? AND user_organization_maps.role = ? AND user_organization_maps.removed_reason = ?",
+			userID, RoleOwner, MembershipOrganizationDeleted)
+}
+
+// RestoreService brings back the service from the trash along with the versions deleted with it
+//
+// Returns ErrNotInTrash if the service isn't in the trash of the organization and a QuotaExceededError
+// if the organization reached its limit of services.
+func (m TrashModel) RestoreService(ctx context.Context, orgID string, serviceID string) (service Service, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	// reserving locks the organization, which also keeps it from being deleted while restoring
+	if err := (QuotaModel{}).reserveService(ctx, tx, orgID); err != nil {
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND organization_id = ? AND deleted_at IS NOT NULL", serviceID, orgID).First(&service).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Service{}, ErrNotInTrash
+		}
+		log.With(ctx).Errorf("failed to find deleted service with id %s for organization with id %s :: error: %s", serviceID, orgID, err.Error())
+		return Service{}, err
+	}
+
+	if err := tx.Unscoped().Model(&ServiceVersion{}).Where("service_id = ? AND deleted_at = ?", serviceID, service.DeletedAt.Time).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore versions of service with id %s :: error: %s", serviceID, err.Error())
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	if err := tx.Unscoped().Model(&service).UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore service with id %s :: error: %s", serviceID, err.Error())
+		tx.Rollback()
+		return Service{}, err
+	}
+
+	tx.Commit()
+	return service, nil
+}
+
+// RestoreVersion brings back a version of the service which was deleted on its own from the trash
+//
+// Returns ErrNotInTrash if the version isn't in the trash of the service, a QuotaExceededError
+// if the service reached the limit of versions of its organization and ErrServiceRetired if the service is retired.
+func (m TrashModel) RestoreVersion(ctx context.Context, serviceID string, versionID string) (version ServiceVersion, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := (QuotaModel{}).reserveVersion(ctx, tx, serviceID); err != nil {
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
+	if err := ensureNotRetired(ctx, tx, serviceID); err != nil {
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
+	if err := tx.Unscoped().Where("id = ? AND service_id = ? AND deleted_at IS NOT NULL", versionID, serviceID).First(&version).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ServiceVersion{}, ErrNotInTrash
+		}
+		log.With(ctx).Errorf("failed to find deleted version with id %s of service with id %s :: error: %s", versionID, serviceID, err.Error())
+		return ServiceVersion{}, err
+	}
+
+	if err := tx.Unscoped().Model(&version).UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore version with id %s :: error: %s", versionID, err.Error())
+		tx.Rollback()
+		return ServiceVersion{}, err
+	}
+
+	tx.Commit()
+	return version, nil
+}
+
+// RestoreOrganization brings back the organization from the trash along with the services, versions, teams
+// and memberships deleted with it. Only owners of the organization at the time it was deleted can restore it,
+// members who deleted their account since don't come back.
+//
+// Returns ErrNotInTrash if the organization isn't in the trash or the user wasn't an owner of it.
+func (m TrashModel) RestoreOrganization(ctx context.Context, orgID string, userID string) (organization Organization, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := m.ownedOrganizations(tx, userID).Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "organizations"}}).
+		Where("organizations.id = ?", orgID).Select("organizations.*").First(&organization).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Organization{}, ErrNotInTrash
+		}
+		log.With(ctx).Errorf("failed to find deleted organization with id %s :: error: %s", orgID, err.Error())
+		return Organization{}, err
+	}
+	deletedAt := organization.DeletedAt.Time
+
+	if err := tx.Unscoped().Model(&UserOrganizationMap{}).
+		Where("organization_id = ? AND deleted_at = ? AND removed_reason = ?", orgID, deletedAt, MembershipOrganizationDeleted).
+		Where("user_id IN (?)", tx.Model(&User{}).Select("id")).
+		UpdateColumns(map[string]interface{}{"deleted_at": nil, "removed_reason": ""}).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore memberships of organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := tx.Unscoped().Model(&Team{}).Where("organization_id = ? AND deleted_at = ?", orgID, deletedAt).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore teams of organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := tx.Unscoped().Model(&ServiceVersion{}).
+		Where("deleted_at = ? AND service_id IN (?)", deletedAt, tx.Unscoped().Model(&Service{}).Select("id").Where("organization_id = ?", orgID)).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore versions of organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := tx.Unscoped().Model(&Service{}).Where("organization_id = ? AND deleted_at = ?", orgID, deletedAt).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore services of organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	if err := tx.Unscoped().Model(&Organization{}).Where("id = ?", orgID).UpdateColumn("deleted_at", nil).Error; err != nil {
+		log.With(ctx).Errorf("failed to restore organization with id %s :: error: %s", orgID, err.Error())
+		tx.Rollback()
+		return Organization{}, err
+	}
+
+	tx.Commit()
+	invalidateOrganizationMemberships(orgID)
+	organization.DeletedAt = gorm.DeletedAt{}
+	return organization, nil
+}
+
+// Purge deletes everything which is in the trash for longer than TrashRetention for good: organizations with
+// everything that belongs to them, services with their versions, versions, teams and removed memberships, along
+// with the dependencies of purged versions and on purged services
+func (m TrashModel) Purge(ctx context.Context) error {
+	db := db.GetDB()
+	cutoff := time.Now().Add(-TrashRetention())
+	tx := db.Begin()
+
+	organizations := tx.Unscoped().Model(&Organization{}).Select("id").Where("deleted_at < ?", cutoff)
+	// services of purged organizations are purged regardless of when they were deleted
+	services := tx.Unscoped().Model(&Service{}).Select("id").Where("deleted_at < ? OR organization_id IN (?)", cutoff, organizations)
+	teams := tx.Unscoped().Model(&Team{}).Select("id").Where("deleted_at < ? OR organization_id IN (?)", cutoff, organizations)
+	versions := tx.Unscoped().Model(&ServiceVersion{}).Select("id").Where("deleted_at < ? OR service_id IN (?)", cutoff, services)
+
+	// children are deleted before what they belong to
+	steps := []struct {
+		name  string
+		query *gorm.DB
+		model interface{}
+	}{
+		{"dependencies", tx.Where("version_id IN (?) OR service_id IN (?) OR depends_on_service_id IN (?)", versions, services, services), &ServiceDependency{}},
+		{"versions", tx.Where("deleted_at < ? OR service_id IN (?)", cutoff, services), &ServiceVersion{}},
+		{"service grants", tx.Where("service_id IN (?) OR team_id IN (?)", services, teams), &ServiceGrant{}},
+		{"team members", tx.Where("team_id IN (?)", teams), &TeamMember{}},
+		{"slug redirects", tx.Where("(resource = ? AND (target_id IN (?) OR scope IN (?))) OR (resource = ? AND target_id IN (?))",
+			SlugService, services, organizations, SlugOrganization, organizations), &SlugRedirect{}},
+		{"teams", tx.Where("id IN (?)", teams), &Team{}},
+		{"services", tx.Where("id IN (?)", services), &Service{}},
+		{"invitations", tx.Where("organization_id IN (?)", organizations), &Invitation{}},
+		{"memberships", tx.Where("deleted_at < ? OR organization_id IN (?)", cutoff, organizations), &UserOrganizationMap{}},
+		{"quotas", tx.Where("organization_id IN (?)", organizations), &OrganizationQuota{}},
+		{"attribute schemas", tx.Where("organization_id IN (?)", organizations), &AttributeSchema{}},
+		{"organizations", tx.Where("id IN (?)", organizations), &Organization{}},
+	}
+
+	purged := map[string]int64{}
+	for _, step := range steps {
+		result := step.query.Unscoped().Delete(step.model)
+		if result.Error != nil {
+			log.With(ctx).Errorf("failed to purge %s deleted before %s :: error: %s", step.name, cutoff.Format(time.RFC3339), result.Error.Error())
+			tx.Rollback()
+			return result.Error
+		}
+		purged[step.name] = result.RowsAffected
+	}
+
+	tx.Commit()
+	if purged["organizations"] > 0 || purged["services"] > 0 || purged["versions"] > 0 {
+		log.With(ctx).Infof("purged %d organizations, %d services and %d versions from the trash",
+			purged["organizations"], purged["services"], purged["versions"])
+	}
+	return nil
+}
@@ -4,18 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/thilak009/kong-assignment/db"
 	"github.com/thilak009/kong-assignment/forms"
 	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TokenResponse struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expiresIn,omitempty"`
+}
+
+// MFAChallengeResponse is returned by login instead of tokens when MFA is enabled for the user,
+// the challenge token has to be exchanged for tokens along with a code at /users/login/mfa
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfaRequired"`
+	ChallengeToken string `json:"challengeToken"`
+	// ExpiresIn is the lifetime of the challenge token in seconds
+	ExpiresIn int `json:"expiresIn"`
 }
 
 type User struct {
@@ -23,6 +38,10 @@ type User struct {
 	Email    string `json:"email" gorm:"uniqueIndex"`
 	Name     string `json:"name"`
 	Password string `json:"-"`
+	// VerifiedAt is set once the user proves they own the email, nil until then
+	VerifiedAt *time.Time `json:"verifiedAt"`
+	// TokenEpoch is bumped to invalidate all access tokens of the user at once, see SessionModel.RevokeAll
+	TokenEpoch int `json:"-" gorm:"not null;default:0"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -30,20 +49,78 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
 	//turn password into hash
-	// NOTE: would need to handle this if a password reset API is exposed
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	// NOTE: password updates don't go through this hook, they are hashed in UserModel.UpdatePassword
+	hashedPassword, err := hashPassword(u.Password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return
 }
 
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
 func (u *User) BeforeUpdate(tx *gorm.DB) (err error) {
 	u.UpdatedAt = time.Now()
 	return
 }
 
+// UnverifiedLoginGracePeriod returns for how long after registration a user can log in
+// without verifying their email (default: 0, verification is required right away)
+func UnverifiedLoginGracePeriod() time.Duration {
+	hours, err := strconv.Atoi(utils.GetEnv("UNVERIFIED_LOGIN_GRACE_HOURS", "0"))
+	if err != nil || hours < 0 {
+		hours = 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// IsVerified returns whether the user has verified their email
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
+// CanAuthenticate returns whether tokens can be issued to the user, unverified users
+// can only authenticate within the grace period after registration
+func (u *User) CanAuthenticate() bool {
+	return u.IsVerified() || time.Now().Before(u.CreatedAt.Add(UnverifiedLoginGracePeriod()))
+}
+
+// MigrateUserVerification adds the verified_at column to users created before email verification existed and
+// marks them as verified when they registered, otherwise none of them could log in anymore once the grace
+// period is over. It has to run before the auto migration, users registered after it have to verify their email.
+func MigrateUserVerification(ctx context.Context) error {
+	db := db.GetDB()
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&User{}) || migrator.HasColumn(&User{}, "VerifiedAt") {
+		return nil
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Exec("ALTER TABLE users ADD COLUMN verified_at timestamptz").Error; err != nil {
+		log.With(ctx).Errorf("failed to add verified at column to users :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Exec("UPDATE users SET verified_at = created_at WHERE verified_at IS NULL").Error; err != nil {
+		log.With(ctx).Errorf("failed to mark existing users as verified :: error: %s", err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
 type UserModel struct{}
 
 func GetUserValidSortFields() map[string]bool {
@@ -57,7 +134,6 @@ func (m UserModel) Create(ctx context.Context, form forms.CreateUserForm) (user
 		Name:     form.Name,
 		Password: form.Password,
 	}
-	fmt.Println("in create user")
 	if err := db.Model(&User{}).Create(&user).Error; err != nil {
 		log.With(ctx).Errorf("failed to create user with email %s :: error: %s", form.Email, err.Error())
 		return User{}, err
@@ -85,7 +161,7 @@ func (m UserModel) All(ctx context.Context, q string, sortBy string, sort string
 
 	// Search filter
 	if q != "" {
-		tx = tx.Where("email ILIKE ?", fmt.Sprintf("%%%u%%", q))
+		tx = tx.Where("email ILIKE ?", fmt.Sprintf("%%%s%%", q))
 	}
 
 	// Get total count for pagination
@@ -96,7 +172,7 @@ func (m UserModel) All(ctx context.Context, q string, sortBy string, sort string
 	}
 
 	// Apply sorting, validation and defaults are handled at API layer
-	tx = tx.Order(fmt.Sprintf("%u %u", sortBy, sort))
+	tx = tx.Order(fmt.Sprintf("%s %s", sortBy, sort))
 
 	// Pagination
 	offset := page * limit
@@ -108,35 +184,165 @@ func (m UserModel) All(ctx context.Context, q string, sortBy string, sort string
 	return BuildPaginatedResult(services, totalCount, page, limit), nil
 }
 
+// Update changes the profile of the user, the email and password are changed through their own flows
 func (m UserModel) Update(ctx context.Context, id string, form forms.UpdateUserForm) (user User, err error) {
 	db := db.GetDB()
-	user = User{
-		Password: form.Password,
+
+	if err := db.Model(&User{}).Where("id = ?", id).First(&user).Error; err != nil {
+		log.With(ctx).Errorf("failed to find user with id %s :: error: %s", id, err.Error())
+		return User{}, err
 	}
-	user.ID = id
-	if err := db.Model(&User{}).Where("id = ?", id).Save(&user).Error; err != nil {
+
+	user.Name = form.Name
+
+	if err := db.Save(&user).Error; err != nil {
 		log.With(ctx).Errorf("failed to update user with id %s :: error: %s", id, err.Error())
 		return User{}, err
 	}
-	return user, err
+	return user, nil
+}
+
+// ChangePassword sets a new password for the user and revokes all their sessions and personal access tokens
+// so that other logins have to authenticate with the new password
+func (m UserModel) ChangePassword(ctx context.Context, id string, password string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	if err := m.updatePassword(ctx, tx, id, password); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := (SessionModel{}).revokeAll(ctx, tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := (PersonalAccessTokenModel{}).revokeAll(ctx, tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	invalidateUserSessions(id)
+	return nil
+}
+
+// updatePassword hashes the password and updates only the password column of the user,
+// tx can be a transaction the update should be part of
+func (m UserModel) updatePassword(ctx context.Context, tx *gorm.DB, id string, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		log.With(ctx).Errorf("failed to hash password for user with id %s :: error: %s", id, err.Error())
+		return err
+	}
+
+	result := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":   hashedPassword,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to update password for user with id %s :: error: %s", id, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
+// markVerified marks the email of the user as verified if it was not already
+func (m UserModel) markVerified(ctx context.Context, tx *gorm.DB, id string) error {
+	if err := tx.Model(&User{}).Where("id = ? AND verified_at IS NULL", id).Updates(map[string]interface{}{
+		"verified_at": time.Now(),
+		"updated_at":  time.Now(),
+	}).Error; err != nil {
+		log.With(ctx).Errorf("failed to mark user with id %s as verified :: error: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Delete removes the user along with their memberships and credentials.
+// The email is released so that it can be used to register again.
+//
+// Returns ErrSoleMember if the user is the only member of an organization and ErrLastOwner if an organization
+// would be left without an owner.
 func (m UserModel) Delete(ctx context.Context, id string) (err error) {
 	db := db.GetDB()
 	tx := db.Begin()
 
-	if err := tx.Where("user_id = ?", id).Delete(&UserOrganizationMap{}).Error; err != nil {
+	var memberships []UserOrganizationMap
+	if err := activeMemberships(tx).Where("user_id = ?", id).Find(&memberships).Error; err != nil {
+		log.With(ctx).Errorf("failed to find memberships of user with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	// lock the organizations of the user so that no other member leaves while checking
+	orgIDs := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		orgIDs = append(orgIDs, membership.OrganizationID)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", orgIDs).Find(&[]Organization{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to lock organizations of user with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	for _, membership := range memberships {
+		if err := (MemberModel{}).ensureAnotherMember(ctx, tx, membership.OrganizationID, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if membership.Role != RoleOwner {
+			continue
+		}
+		if err := (MemberModel{}).ensureAnotherOwner(ctx, tx, membership.OrganizationID, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := removeMemberships(tx.Where("user_id = ?", id), MembershipAccountDeleted); err != nil {
 		log.With(ctx).Errorf("failed to delete user organization maps for user with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 
+	credentials := []interface{}{&PersonalAccessToken{}, &UserMFA{}, &MFARecoveryCode{}, &MFAChallenge{}, &UserIdentity{},
+		&PasswordResetToken{}, &EmailVerificationToken{}, &TeamMember{}}
+	for _, credential := range credentials {
+		if err := tx.Where("user_id = ?", id).Delete(credential).Error; err != nil {
+			log.With(ctx).Errorf("failed to delete %T of user with id %s :: error: %s", credential, id, err.Error())
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := (SessionModel{}).revokeAll(ctx, tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the row is kept for the organizations the user created, the unique email is replaced to free it
+	if err := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":      fmt.Sprintf("deleted+%s@deleted.invalid", id),
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		log.With(ctx).Errorf("failed to release email of user with id %s :: error: %s", id, err.Error())
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Where("id = ?", id).Delete(&User{}).Error; err != nil {
 		log.With(ctx).Errorf("failed to delete user with id %s :: error: %s", id, err.Error())
 		tx.Rollback()
 		return err
 	}
 	tx.Commit()
+	invalidateUserSessions(id)
+	invalidateUserMemberships(id)
 	return err
 }
 
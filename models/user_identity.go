@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIdentityEmailNotVerified is returned when the identity provider has not verified the email of a new identity,
+// such an identity can't be linked to an account by its email
+var ErrIdentityEmailNotVerified = errors.New("identity email not verified")
+
+// UserIdentity links a user to their account at an external identity provider
+type UserIdentity struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"index"`
+	Provider  string `gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	// Subject is the stable identifier of the user at the provider (the sub claim)
+	Subject string `gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	Email   string
+}
+
+func (ui *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	ui.ID = uuid.New().String()
+	ui.CreatedAt = time.Now()
+	ui.UpdatedAt = time.Now()
+	return
+}
+
+func (ui *UserIdentity) BeforeUpdate(tx *gorm.DB) (err error) {
+	ui.UpdatedAt = time.Now()
+	return
+}
+
+// ExternalIdentity is a user as identified by an identity provider
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type UserIdentityModel struct{}
+
+// FindOrProvision returns the user linked to the identity. An identity seen for the first time is linked
+// to the account with the same email, or a new verified account is created for it (just in time provisioning).
+// An unverified account is only linked after whoever registered it is locked out, see lockOutRegistrant.
+//
+// Returns ErrIdentityEmailNotVerified if the identity has to be linked but the provider has not verified the email.
+func (m UserIdentityModel) FindOrProvision(ctx context.Context, identity ExternalIdentity) (user User, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var existing UserIdentity
+	err = tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+	if err == nil {
+		if err := tx.Where("id = ?", existing.UserID).First(&user).Error; err != nil {
+			log.With(ctx).Errorf("failed to find user with id %s linked to %s identity :: error: %s", existing.UserID, identity.Provider, err.Error())
+			tx.Rollback()
+			return User{}, err
+		}
+		tx.Commit()
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.With(ctx).Errorf("failed to find %s identity :: error: %s", identity.Provider, err.Error())
+		tx.Rollback()
+		return User{}, err
+	}
+
+	// linking by email is only safe when the provider vouches for the email
+	if !identity.EmailVerified || identity.Email == "" {
+		tx.Rollback()
+		return User{}, ErrIdentityEmailNotVerified
+	}
+
+	locked := false
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("email = ?", identity.Email).First(&user).Error
+	switch {
+	case err == nil:
+		// anyone can register an email they don't own, whoever did is locked out before the owner gets the account
+		if !user.IsVerified() {
+			if err := m.lockOutRegistrant(ctx, tx, user.ID); err != nil {
+				tx.Rollback()
+				return User{}, err
+			}
+			locked = true
+		}
+		if err := (UserModel{}).markVerified(ctx, tx, user.ID); err != nil {
+			tx.Rollback()
+			return User{}, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user, err = m.provisionUser(ctx, tx, identity)
+		if err != nil {
+			tx.Rollback()
+			return User{}, err
+		}
+	default:
+		log.With(ctx).Errorf("failed to find user with email %s :: error: %s", identity.Email, err.Error())
+		tx.Rollback()
+		return User{}, err
+	}
+
+	link := UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := tx.Create(&link).Error; err != nil {
+		log.With(ctx).Errorf("failed to link %s identity to user with id %s :: error: %s", identity.Provider, user.ID, err.Error())
+		tx.Rollback()
+		return User{}, err
+	}
+
+	tx.Commit()
+	if locked {
+		invalidateUserSessions(user.ID)
+	}
+	log.With(ctx).Infof("linked %s identity to user with id %s", identity.Provider, user.ID)
+	return user, nil
+}
+
+// lockOutRegistrant takes the credentials of an unverified account away from whoever registered it: the password
+// is replaced by a random one nobody knows and the sessions, refresh tokens, personal access tokens and MFA
+// enrollment are removed. The owner of the email can set a password with the password reset flow.
+func (m UserIdentityModel) lockOutRegistrant(ctx context.Context, tx *gorm.DB, userID string) error {
+	password, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate password for user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+	if err := (UserModel{}).updatePassword(ctx, tx, userID, password); err != nil {
+		return err
+	}
+
+	credentials := []interface{}{&PersonalAccessToken{}, &UserMFA{}, &MFARecoveryCode{}, &MFAChallenge{}}
+	for _, credential := range credentials {
+		if err := tx.Where("user_id = ?", userID).Delete(credential).Error; err != nil {
+			log.With(ctx).Errorf("failed to delete %T of user with id %s :: error: %s", credential, userID, err.Error())
+			return err
+		}
+	}
+
+	return (SessionModel{}).revokeAll(ctx, tx, userID)
+}
+
+// provisionUser creates a verified account for an identity, the account gets a random password
+// which nobody knows, a password can be set with the password reset flow
+func (m UserIdentityModel) provisionUser(ctx context.Context, tx *gorm.DB, identity ExternalIdentity) (User, error) {
+	password, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate password for %s identity :: error: %s", identity.Provider, err.Error())
+		return User{}, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	now := time.Now()
+	user := User{
+		Email:      identity.Email,
+		Name:       name,
+		Password:   password,
+		VerifiedAt: &now,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		log.With(ctx).Errorf("failed to create user with email %s for %s identity :: error: %s", identity.Email, identity.Provider, err.Error())
+		return User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,296 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thilak009/kong-assignment/db"
+	"github.com/thilak009/kong-assignment/pkg/log"
+	"github.com/thilak009/kong-assignment/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrMFAAlreadyEnabled is returned when enrolling or confirming while MFA is already enabled
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	// ErrMFANotEnrolled is returned when confirming without a pending enrollment
+	ErrMFANotEnrolled = errors.New("mfa not enrolled")
+	// ErrMFANotEnabled is returned when disabling MFA which is not enabled
+	ErrMFANotEnabled = errors.New("mfa not enabled")
+	// ErrMFACodeInvalid is returned when neither a TOTP code nor an unused recovery code matches
+	ErrMFACodeInvalid = errors.New("invalid mfa code")
+)
+
+// recoveryCodeCount is the number of recovery codes issued when MFA is enabled
+const recoveryCodeCount = 10
+
+// UserMFA holds the TOTP secret of a user. The row is created on enrollment and MFA is only
+// enforced once the user confirms the enrollment with a first code, which sets EnabledAt.
+type UserMFA struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	UpdatedAt time.Time
+	UserID    string `gorm:"primaryKey"`
+	Secret    string
+	EnabledAt *time.Time
+	// LastUsedStep is the TOTP time step of the last accepted code, codes can't be replayed
+	LastUsedStep int64
+}
+
+func (um *UserMFA) BeforeCreate(tx *gorm.DB) (err error) {
+	um.CreatedAt = time.Now()
+	um.UpdatedAt = time.Now()
+	return
+}
+
+func (um *UserMFA) BeforeUpdate(tx *gorm.DB) (err error) {
+	um.UpdatedAt = time.Now()
+	return
+}
+
+// MFARecoveryCode is a one time code which can be used instead of a TOTP code,
+// only the hash of the code is stored
+type MFARecoveryCode struct {
+	CreatedAt time.Time `gorm:"<-:create"`
+	ID        string    `gorm:"primaryKey"`
+	UserID    string    `gorm:"index"`
+	CodeHash  string    `gorm:"index"`
+	UsedAt    *time.Time
+}
+
+func (rc *MFARecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	rc.ID = uuid.New().String()
+	rc.CreatedAt = time.Now()
+	return
+}
+
+// MFAEnrollmentResponse is returned when enrolling, the secret has to be added to an authenticator app
+type MFAEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	// OTPAuthURI is the otpauth:// URI of the secret, usually rendered as a QR code
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// RecoveryCodesResponse holds the recovery codes, they are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type UserMFAModel struct{}
+
+// MFAIssuer returns the issuer shown in authenticator apps (default: Konnect)
+func MFAIssuer() string {
+	return utils.GetEnv("MFA_ISSUER", "Konnect")
+}
+
+// IsEnabled returns whether the user has confirmed MFA enrollment
+func (m UserMFAModel) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	db := db.GetDB()
+
+	var count int64
+	if err := db.Model(&UserMFA{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&count).Error; err != nil {
+		log.With(ctx).Errorf("failed to check mfa of user with id %s :: error: %s", userID, err.Error())
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Enroll generates a new TOTP secret for the user, MFA is not enforced until the enrollment is confirmed.
+// Enrolling again before confirming replaces the pending secret.
+//
+// Returns ErrMFAAlreadyEnabled if MFA is already enabled for the user.
+func (m UserMFAModel) Enroll(ctx context.Context, userID string) (secret string, err error) {
+	db := db.GetDB()
+
+	enabled, err := m.IsEnabled(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if enabled {
+		return "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err = utils.GenerateTOTPSecret()
+	if err != nil {
+		log.With(ctx).Errorf("failed to generate totp secret for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	mfa := UserMFA{UserID: userID, Secret: secret}
+	// the enabled_at condition makes sure a concurrent confirmation is never overwritten
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "user_mfas.enabled_at IS NULL"}}},
+	}).Create(&mfa).Error; err != nil {
+		log.With(ctx).Errorf("failed to save totp secret for user with id %s :: error: %s", userID, err.Error())
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// Confirm enables MFA for the user if the code matches the pending secret and returns the recovery codes,
+// the raw recovery codes are only available here.
+//
+// Returns ErrMFANotEnrolled if there is no pending enrollment, ErrMFAAlreadyEnabled if MFA is already
+// enabled and ErrMFACodeInvalid if the code doesn't match.
+func (m UserMFAModel) Confirm(ctx context.Context, userID string, code string) (recoveryCodes []string, err error) {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	var mfa UserMFA
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		log.With(ctx).Errorf("failed to find mfa of user with id %s :: error: %s", userID, err.Error())
+		return nil, err
+	}
+
+	if mfa.EnabledAt != nil {
+		tx.Rollback()
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		tx.Rollback()
+		return nil, ErrMFACodeInvalid
+	}
+
+	if err := tx.Model(&mfa).Updates(map[string]interface{}{
+		"enabled_at":     time.Now(),
+		"last_used_step": step,
+	}).Error; err != nil {
+		log.With(ctx).Errorf("failed to enable mfa of user with id %s :: error: %s", userID, err.Error())
+		tx.Rollback()
+		return nil, err
+	}
+
+	recoveryCodes, err = m.createRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	tx.Commit()
+	return recoveryCodes, nil
+}
+
+// createRecoveryCodes replaces the recovery codes of the user with new ones and returns the raw codes
+func (m UserMFAModel) createRecoveryCodes(ctx context.Context, tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&MFARecoveryCode{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete recovery codes of user with id %s :: error: %s", userID, err.Error())
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]MFARecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			log.With(ctx).Errorf("failed to generate recovery code for user with id %s :: error: %s", userID, err.Error())
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, MFARecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalizeRecoveryCode(code))})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		log.With(ctx).Errorf("failed to create recovery codes for user with id %s :: error: %s", userID, err.Error())
+		return nil, err
+	}
+	return codes, nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCode returns a random code of the form xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode makes recovery codes match regardless of case and separators
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// verify checks a TOTP code or a recovery code for the user, a matched code can't be used again.
+//
+// Returns ErrMFACodeInvalid if neither matches.
+func (m UserMFAModel) verify(ctx context.Context, tx *gorm.DB, userID string, code string) error {
+	var mfa UserMFA
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND enabled_at IS NOT NULL", userID).
+		First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMFACodeInvalid
+		}
+		log.With(ctx).Errorf("failed to find mfa of user with id %s :: error: %s", userID, err.Error())
+		return err
+	}
+
+	if step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now(), mfa.LastUsedStep); ok {
+		if err := tx.Model(&mfa).Update("last_used_step", step).Error; err != nil {
+			log.With(ctx).Errorf("failed to update mfa of user with id %s :: error: %s", userID, err.Error())
+			return err
+		}
+		return nil
+	}
+
+	result := tx.Model(&MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to use recovery code of user with id %s :: error: %s", userID, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFACodeInvalid
+	}
+
+	log.With(ctx).Infof("recovery code used by user with id %s", userID)
+	return nil
+}
+
+// Disable removes the TOTP secret and recovery codes of the user
+//
+// Returns ErrMFANotEnabled if MFA is not enabled for the user.
+func (m UserMFAModel) Disable(ctx context.Context, userID string) error {
+	db := db.GetDB()
+	tx := db.Begin()
+
+	result := tx.Where("user_id = ? AND enabled_at IS NOT NULL", userID).Delete(&UserMFA{})
+	if result.Error != nil {
+		log.With(ctx).Errorf("failed to disable mfa of user with id %s :: error: %s", userID, result.Error.Error())
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrMFANotEnabled
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&MFARecoveryCode{}).Error; err != nil {
+		log.With(ctx).Errorf("failed to delete recovery codes of user with id %s :: error: %s", userID, err.Error())
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
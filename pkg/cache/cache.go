@@ -0,0 +1,158 @@
+// Package cache contains a small in-process cache used to avoid database round trips on hot paths
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the counters of a cache since it was created
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// TTLCache is a cache bounded in size whose entries expire after a fixed time to live,
+// the least recently used entry is evicted when the cache is full. It is safe for concurrent use.
+type TTLCache[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	items      map[K]*list.Element
+	// order has the most recently used entry at the front
+	order  *list.List
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// New returns a cache keeping at most maxEntries entries for ttl each, a ttl of zero disables the cache
+func New[K comparable, V any](ttl time.Duration, maxEntries int) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      map[K]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get returns the value of the key if it is cached and not expired
+func (c *TTLCache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.items[key]
+	if !found {
+		c.misses.Add(1)
+		return value, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if time.Now().After(e.expiresAt) {
+		c.removeElement(element)
+		c.misses.Add(1)
+		return value, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+// Set caches the value of the key for the time to live of the cache
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.SetUntil(key, value, time.Time{})
+}
+
+// SetUntil caches the value of the key for the time to live of the cache but not past deadline,
+// a zero deadline doesn't limit the entry and a deadline in the past doesn't cache the value
+func (c *TTLCache[K, V]) SetUntil(key K, value V, deadline time.Time) {
+	if c.ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if !deadline.IsZero() && deadline.Before(expiresAt) {
+		if !deadline.After(time.Now()) {
+			return
+		}
+		expiresAt = deadline
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.items[key]; found {
+		e := element.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	for c.order.Len() >= c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Delete removes the key from the cache
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.items[key]; found {
+		c.removeElement(element)
+	}
+}
+
+// DeleteFunc removes every entry for which match returns true
+func (c *TTLCache[K, V]) DeleteFunc(match func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		e := element.Value.(*entry[K, V])
+		if match(e.key, e.value) {
+			c.removeElement(element)
+		}
+		element = next
+	}
+}
+
+// Purge removes every entry, the counters are kept
+func (c *TTLCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = map[K]*list.Element{}
+	c.order.Init()
+}
+
+// Stats returns the hit and miss counters and the number of cached entries
+func (c *TTLCache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+// removeElement drops the entry from the map and the list, c.mu must be held
+func (c *TTLCache[K, V]) removeElement(element *list.Element) {
+	e := c.order.Remove(element).(*entry[K, V])
+	delete(c.items, e.key)
+}
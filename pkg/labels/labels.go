@@ -0,0 +1,66 @@
+// Package labels validates key/value labels and parses label selectors, both follow the syntax of Kubernetes labels
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxLabels is the number of labels a resource can have
+const MaxLabels = 64
+
+const (
+	maxNameLength   = 63
+	maxPrefixLength = 253
+	maxValueLength  = 63
+)
+
+var (
+	namePattern   = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`)
+	prefixPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateKey validates a label key, an optional DNS subdomain prefix and a name separated by a slash,
+// e.g. "tier" or "example.com/team"
+func ValidateKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) == 0 || len(prefix) > maxPrefixLength || !prefixPattern.MatchString(prefix) {
+			return fmt.Errorf("prefix of label key %q must be a DNS subdomain of at most %d characters", key, maxPrefixLength)
+		}
+	}
+	if len(name) == 0 || len(name) > maxNameLength || !namePattern.MatchString(name) {
+		return fmt.Errorf("name of label key %q must be 1 to %d alphanumeric characters, '-', '_' or '.' starting and ending with an alphanumeric character", key, maxNameLength)
+	}
+	return nil
+}
+
+// ValidateValue validates a label value, it can be empty
+func ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxValueLength || !namePattern.MatchString(value) {
+		return fmt.Errorf("label value %q must be at most %d alphanumeric characters, '-', '_' or '.' starting and ending with an alphanumeric character", value, maxValueLength)
+	}
+	return nil
+}
+
+// Validate validates the keys and values of labels and that there are at most MaxLabels of them
+func Validate(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("at most %d labels are allowed", MaxLabels)
+	}
+	for key, value := range labels {
+		if err := ValidateKey(key); err != nil {
+			return err
+		}
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
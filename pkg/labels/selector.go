@@ -0,0 +1,144 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxRequirements is the number of requirements a selector can have, each of them adds a condition to the query
+const MaxRequirements = 20
+
+// Operator is the comparison of a requirement
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a condition on one label, Values has one value for Equals and NotEquals, at least one for In
+// and NotIn and none for Exists and DoesNotExist
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Selector matches the resources whose labels meet all of its requirements
+type Selector []Requirement
+
+var setPattern = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Parse parses a selector of comma separated requirements, e.g. "env in (prod,staging),tier!=db,!deprecated".
+// Supported requirements are "key=value" (or "key==value"), "key!=value", "key in (v1,v2)", "key notin (v1,v2)",
+// "key" when the label exists and "!key" when it doesn't. An empty selector matches everything.
+func Parse(selector string) (Selector, error) {
+	if strings.TrimSpace(selector) == "" {
+		return Selector{}, nil
+	}
+
+	parts, err := splitRequirements(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > MaxRequirements {
+		return nil, fmt.Errorf("label selector can have at most %d requirements", MaxRequirements)
+	}
+
+	requirements := make(Selector, 0, len(parts))
+	for _, part := range parts {
+		requirement, err := parseRequirement(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// splitRequirements splits the selector on the commas which aren't within the parentheses of a set
+func splitRequirements(selector string) ([]string, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("label selector %q has nested parentheses", selector)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("label selector %q has unbalanced parentheses", selector)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("label selector %q has unbalanced parentheses", selector)
+	}
+	return append(parts, selector[start:]), nil
+}
+
+func parseRequirement(requirement string) (Requirement, error) {
+	if requirement == "" {
+		return Requirement{}, fmt.Errorf("label selector has an empty requirement")
+	}
+
+	if strings.HasPrefix(requirement, "!") && !strings.Contains(requirement, "=") {
+		key := strings.TrimSpace(requirement[1:])
+		if err := ValidateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: DoesNotExist}, nil
+	}
+
+	if match := setPattern.FindStringSubmatch(requirement); match != nil {
+		key := match[1]
+		if err := ValidateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		var values []string
+		for _, value := range strings.Split(match[3], ",") {
+			value = strings.TrimSpace(value)
+			if err := ValidateValue(value); err != nil {
+				return Requirement{}, err
+			}
+			values = append(values, value)
+		}
+		return Requirement{Key: key, Operator: Operator(match[2]), Values: values}, nil
+	}
+
+	operator := Equals
+	key, value, found := strings.Cut(requirement, "!=")
+	if found {
+		operator = NotEquals
+	} else if key, value, found = strings.Cut(requirement, "=="); !found {
+		key, value, found = strings.Cut(requirement, "=")
+	}
+	if !found {
+		if err := ValidateKey(requirement); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: requirement, Operator: Exists}, nil
+	}
+
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if err := ValidateKey(key); err != nil {
+		return Requirement{}, err
+	}
+	if err := ValidateValue(value); err != nil {
+		return Requirement{}, err
+	}
+	return Requirement{Key: key, Operator: operator, Values: []string{value}}, nil
+}
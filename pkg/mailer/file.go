@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thilak009/kong-assignment/pkg/log"
+)
+
+// FileMailer writes every mail as a .eml file to a directory instead of sending it,
+// useful for local development and for tests which need to read the mails back
+type FileMailer struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	seq  int
+}
+
+// NewFileMailer creates a FileMailer writing to dir, the directory is created if it does not exist
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		log.With(ctx).Errorf("failed to create mail outbox %s :: error: %s", m.dir, err.Error())
+		return err
+	}
+
+	// sequence keeps the files ordered even when written within the same nanosecond
+	m.seq++
+	name := fmt.Sprintf("%d-%06d.eml", time.Now().UnixNano(), m.seq)
+	if err := os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o644); err != nil {
+		log.With(ctx).Errorf("failed to write mail to %s :: error: %s", m.dir, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Messages reads back every mail written to the outbox in the order they were sent
+func (m *FileMailer) Messages() ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.eml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	messages := make([]Message, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(parsed.Body)
+		if err != nil {
+			return nil, err
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, Message{
+			To:      parsed.Header.Get("To"),
+			Subject: subject,
+			Body:    strings.ReplaceAll(string(body), "\r\n", "\n"),
+		})
+	}
+	return messages, nil
+}
+
+// format renders the message in RFC 5322 format, the header values can't add headers, see headerAddress and
+// headerText
+func format(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerAddress(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerAddress(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerText(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// headerAddress returns the address without line breaks
+func headerAddress(address string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(address)
+}
+
+// headerText returns the text encoded as RFC 2047 words when it has line breaks or characters other than
+// printable ASCII, plain text is left as it is
+func headerText(text string) string {
+	return mime.QEncoding.Encode("UTF-8", text)
+}
@@ -0,0 +1,22 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/thilak009/kong-assignment/pkg/log"
+)
+
+// LogMailer writes mails to the application log instead of sending them
+type LogMailer struct {
+	from string
+}
+
+// NewLogMailer creates a LogMailer
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.With(ctx, "mail_from", m.from, "mail_to", msg.To, "mail_subject", msg.Subject).Infof("outgoing mail:\n%s", msg.Body)
+	return nil
+}
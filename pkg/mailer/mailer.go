@@ -0,0 +1,103 @@
+// Package mailer provides a pluggable way of sending emails to users.
+package mailer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email to be delivered to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails, implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	_mailer Mailer
+	mu      sync.RWMutex
+)
+
+// Get returns the configured mailer, creating it from the environment on first use
+func Get() Mailer {
+	mu.RLock()
+	m := _mailer
+	mu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _mailer == nil {
+		_mailer = New()
+	}
+	return _mailer
+}
+
+// Set replaces the mailer used by the application, mainly useful for tests to inspect outgoing mail
+func Set(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	_mailer = m
+}
+
+// Send is a convenience function that calls Get().Send()
+func Send(ctx context.Context, msg Message) error {
+	return Get().Send(ctx, msg)
+}
+
+// asyncSendTimeout bounds how long a mail sent with SendAsync can take
+const asyncSendTimeout = time.Minute
+
+var pending sync.WaitGroup
+
+// SendAsync sends the mail in the background so that the response doesn't wait for the mail server, errors are only
+// logged by the mailer. The mail is sent with the values of ctx, e.g. the request id, but not its cancellation.
+func SendAsync(ctx context.Context, msg Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncSendTimeout)
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		defer cancel()
+		Get().Send(ctx, msg)
+	}()
+}
+
+// Wait blocks until the mails sent with SendAsync so far are sent
+func Wait() {
+	pending.Wait()
+}
+
+// New creates a mailer based on the MAILER environment variable
+//
+// smtp: sends mails through SMTP_HOST:SMTP_PORT authenticating with SMTP_USERNAME/SMTP_PASSWORD
+// file: writes every mail as a .eml file to MAIL_OUTBOX_DIR (default: ./outbox)
+// log:  writes every mail to the application log, default
+func New() Mailer {
+	from := getEnv("MAIL_FROM", "no-reply@konnect.local")
+
+	switch strings.ToLower(os.Getenv("MAILER")) {
+	case "smtp":
+		return NewSMTPMailer(os.Getenv("SMTP_HOST"), getEnv("SMTP_PORT", "587"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	case "file":
+		return NewFileMailer(getEnv("MAIL_OUTBOX_DIR", "outbox"), from)
+	default:
+		return NewLogMailer(from)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -110,6 +111,28 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Extract the token
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// Personal access tokens are opaque, they are looked up instead of being validated as a JWT
+		if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+			pat, err := models.PersonalAccessTokenModel{}.Authenticate(c.Request.Context(), tokenString)
+			if err != nil {
+				if errors.Is(err, models.ErrPersonalAccessTokenInvalid) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+						Message: "Invalid token",
+					})
+					return
+				}
+				models.AbortWithError(c, http.StatusInternalServerError, "Failed to validate token")
+				return
+			}
+
+			// organization and scope limits of the token are enforced by OrganizationAccessMiddleware
+			c.Set("user_id", pat.UserID)
+			models.SetPersonalAccessToken(c, &pat)
+
+			c.Next()
+			return
+		}
+
 		// Validate the token
 		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
@@ -119,57 +142,317 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if token is blacklisted
-		blacklistModel := models.BlacklistedTokenModel{}
-		tokenHash := utils.HashToken(tokenString)
-		if blacklistModel.IsBlacklisted(c.Request.Context(), tokenHash) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
-				Message: "Invalid token",
-			})
-			return
+		if claims.SessionID != "" {
+			// a revoked session or a bumped token epoch invalidates the token
+			if err := (models.SessionModel{}).Authenticate(c.Request.Context(), claims.SessionID, claims.UserID, claims.Epoch); err != nil {
+				if errors.Is(err, models.ErrSessionInvalid) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+						Message: "Invalid token",
+					})
+					return
+				}
+				models.AbortWithError(c, http.StatusInternalServerError, "Failed to validate token")
+				return
+			}
+		} else {
+			// Check if token is blacklisted, tokens issued before sessions were introduced are revoked this way
+			blacklistModel := models.BlacklistedTokenModel{}
+			tokenHash := utils.HashToken(tokenString)
+			if blacklistModel.IsBlacklisted(c.Request.Context(), tokenHash) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+					Message: "Invalid token",
+				})
+				return
+			}
 		}
 
 		// Store user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
+		c.Set("session_id", claims.SessionID)
+
+		c.Next()
+	}
+}
+
+// SlugMiddleware lets routes take the slug of an organization or service in place of its id in the URL
+// parameters 'orgId' and 'serviceId', the parameters are replaced with the ids for the following handlers.
+// A previous slug redirects to the URL with the current slug with 308 so that the method and body are kept.
+// Slugs nothing has are left as they are, the following handlers don't find them. Previous slugs of what
+// the user can't see are left as they are too, so that the redirect doesn't reveal the current slug and id.
+//
+// Prerequisites:
+//   - AuthMiddleware must be applied before this middleware
+func SlugMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slugModel := models.SlugModel{}
+		// location has the value of each parameter in the URL the request is redirected to
+		location := map[string]string{}
+		// slugs has the value of each parameter that was resolved from a slug
+		slugs := map[string]string{}
+		moved := false
+
+		resolve := func(param string, resource models.SlugResource, scope string) (ok bool) {
+			value := c.Param(param)
+			if value == "" || models.IsID(value) {
+				return true
+			}
+
+			target, isFound, err := slugModel.Resolve(c.Request.Context(), resource, scope, value)
+			if err != nil {
+				models.AbortWithError(c, http.StatusInternalServerError, "Failed to resolve slug")
+				return false
+			}
+			if !isFound {
+				return true
+			}
+
+			location[param] = target.Slug
+			slugs[param] = value
+			moved = moved || target.Moved
+			setParam(c, param, target.ID)
+			return true
+		}
+
+		if !resolve("orgId", models.SlugOrganization, "") {
+			return
+		}
+		// services are only resolved within a known organization
+		if orgID := c.Param("orgId"); models.IsID(orgID) && !resolve("serviceId", models.SlugService, orgID) {
+			return
+		}
+
+		if moved {
+			visible, err := slugTargetVisible(c)
+			if err != nil {
+				models.AbortWithError(c, http.StatusInternalServerError, "Failed to resolve slug")
+				return
+			}
+			if !visible {
+				for param, value := range slugs {
+					setParam(c, param, value)
+				}
+				c.Next()
+				return
+			}
+
+			segments := strings.Split(c.FullPath(), "/")
+			for i, segment := range segments {
+				if !strings.HasPrefix(segment, ":") {
+					continue
+				}
+				name := strings.TrimPrefix(segment, ":")
+				if value, ok := location[name]; ok {
+					segments[i] = value
+				} else {
+					segments[i] = c.Param(name)
+				}
+			}
+
+			url := strings.Join(segments, "/")
+			if c.Request.URL.RawQuery != "" {
+				url += "?" + c.Request.URL.RawQuery
+			}
+			c.Redirect(http.StatusPermanentRedirect, url)
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// slugTargetVisible returns whether the authenticated user is a member of the organization in the URL
+// parameter 'orgId' and can see the service in 'serviceId' when the route has a known one
+func slugTargetVisible(c *gin.Context) (bool, error) {
+	userID := utils.GetUserID(c)
+
+	orgModel := models.OrganizationModel{}
+	role, err := orgModel.MemberRole(c.Request.Context(), c.Param("orgId"), userID)
+	if err != nil || role == "" {
+		return false, err
+	}
+
+	serviceID := c.Param("serviceId")
+	if !models.IsID(serviceID) {
+		return true, nil
+	}
+
+	teamModel := models.TeamModel{}
+	granted, err := teamModel.ServiceAccess(c.Request.Context(), serviceID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return granted != "", nil
+}
+
+func setParam(c *gin.Context, key string, value string) {
+	for i := range c.Params {
+		if c.Params[i].Key == key {
+			c.Params[i].Value = value
+		}
+	}
+}
+
 // OrganizationAccessMiddleware validates that the authenticated user has access to the organization
-// specified in the URL parameter 'orgId'. This middleware should be applied to routes that require
-// organization membership validation.
+// specified in the URL parameter 'orgId' and that the role of the user in it grants the permission
+// the route requires. This middleware should be applied to routes that require organization membership validation.
 //
 // Prerequisites:
 //   - AuthMiddleware must be applied before this middleware to ensure user is authenticated
 //   - Route must have 'orgId' parameter in the URL path
 //
 // On success:
-//   - Sets "user_id" and "org_id" in gin context for use by handlers
+//   - Sets the role of the user in the organization in gin context, see models.GetOrganizationRole
 //   - Calls c.Next() to continue to the next handler
 //
 // On failure:
 //   - Returns appropriate HTTP error response and aborts the request
-func OrganizationAccessMiddleware() gin.HandlerFunc {
+func OrganizationAccessMiddleware(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := utils.GetUserID(c)
-		orgID := c.Param("orgId")
+		role, ok := authorizeOrganization(c, permission)
+		if !ok {
+			return
+		}
 
-		if userID == "" || orgID == "" {
-			models.AbortWithError(c, http.StatusBadRequest, "Missing user or organization information")
+		if !role.Can(permission) {
+			models.AbortWithError(c, http.StatusForbidden, fmt.Sprintf("The %s role is not allowed to perform the request", role))
 			return
 		}
 
-		orgModel := models.OrganizationModel{}
-		isMember, err := orgModel.IsUserMember(c.Request.Context(), orgID, userID)
+		models.SetOrganizationRole(c, role)
+		c.Next()
+	}
+}
+
+// ServiceAccessMiddleware validates that the authenticated user has at least the access to the service
+// specified in the URL parameter 'serviceId', which comes from the role of the user in the organization
+// and the grants of the teams of the user, see models.TeamModel.ServiceAccess. The permission is only used
+// for the scope a personal access token needs.
+//
+// Prerequisites:
+//   - AuthMiddleware must be applied before this middleware to ensure user is authenticated
+//   - Route must have 'orgId' and 'serviceId' parameters in the URL path
+//
+// On success:
+//   - Sets the role of the user in the organization in gin context, see models.GetOrganizationRole
+//   - Calls c.Next() to continue to the next handler
+//
+// On failure:
+//   - Returns appropriate HTTP error response and aborts the request, services the user can't see are not found
+func ServiceAccessMiddleware(permission models.Permission, access models.ServiceAccess) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := authorizeOrganization(c, permission)
+		if !ok {
+			return
+		}
+
+		teamModel := models.TeamModel{}
+		granted, err := teamModel.ServiceAccess(c.Request.Context(), c.Param("serviceId"), utils.GetUserID(c), role)
 		if err != nil {
-			models.AbortWithError(c, http.StatusInternalServerError, "Failed to check organization access")
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to check service access")
 			return
 		}
 
-		if !isMember {
-			models.AbortWithError(c, http.StatusForbidden, "You are not authorized to perform the request")
+		if granted == "" {
+			models.AbortWithError(c, http.StatusNotFound, "Service not found")
+			return
+		}
+
+		if !granted.Includes(access) {
+			models.AbortWithError(c, http.StatusForbidden, fmt.Sprintf("The %s access to the service is not allowed to perform the request", granted))
+			return
+		}
+
+		models.SetOrganizationRole(c, role)
+		c.Next()
+	}
+}
+
+// authorizeOrganization returns the role of the authenticated user in the organization after checking the
+// limits of a personal access token, the request is aborted when ok is false
+func authorizeOrganization(c *gin.Context, permission models.Permission) (role models.Role, ok bool) {
+	userID := utils.GetUserID(c)
+	orgID := c.Param("orgId")
+
+	if userID == "" || orgID == "" {
+		models.AbortWithError(c, http.StatusBadRequest, "Missing user or organization information")
+		return "", false
+	}
+
+	orgModel := models.OrganizationModel{}
+	role, err := orgModel.MemberRole(c.Request.Context(), orgID, userID)
+	if err != nil {
+		models.AbortWithError(c, http.StatusInternalServerError, "Failed to check organization access")
+		return "", false
+	}
+
+	if role == "" {
+		models.AbortWithError(c, http.StatusForbidden, "You are not authorized to perform the request")
+		return "", false
+	}
+
+	// a personal access token only gets a subset of what its user can do
+	if pat := models.GetPersonalAccessToken(c); pat != nil {
+		if !pat.AllowsOrganization(orgID) {
+			models.AbortWithError(c, http.StatusForbidden, "Token is not authorized for this organization")
+			return "", false
+		}
+		if scope := permission.Scope(); !pat.HasScope(scope) {
+			models.AbortWithError(c, http.StatusForbidden, fmt.Sprintf("Token is missing the %s scope", scope))
+			return "", false
+		}
+	}
+
+	return role, true
+}
+
+// OperatorMiddleware only lets operators of the deployment through, operators are the verified users with an
+// email listed in OPERATOR_EMAILS (comma separated). No one is an operator when it is not set.
+//
+// Prerequisites:
+//   - AuthMiddleware must be applied before this middleware
+func OperatorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userModel := models.UserModel{}
+		user, isFound, err := userModel.One(c.Request.Context(), utils.GetUserID(c))
+		if err != nil {
+			if !isFound {
+				models.AbortWithError(c, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+			models.AbortWithError(c, http.StatusInternalServerError, "Failed to check operator access")
+			return
+		}
+
+		if !user.IsVerified() || !isOperatorEmail(user.Email) {
+			models.AbortWithError(c, http.StatusForbidden, "Only operators can perform the request")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isOperatorEmail(email string) bool {
+	for _, operator := range strings.Split(utils.GetEnv("OPERATOR_EMAILS", ""), ",") {
+		operator = strings.TrimSpace(operator)
+		if operator != "" && strings.EqualFold(operator, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserSessionMiddleware rejects requests authenticated with a personal access token, it is applied to
+// routes which manage the account itself (tokens, MFA etc.) or aren't bound to a single organization.
+//
+// Prerequisites:
+//   - AuthMiddleware must be applied before this middleware
+func UserSessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if models.GetPersonalAccessToken(c) != nil {
+			models.AbortWithError(c, http.StatusForbidden, "This request can't be made with a personal access token")
 			return
 		}
 
@@ -0,0 +1,131 @@
+// Package oidc implements the relying party side of OpenID Connect single sign-on using the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultScopes are requested when a provider doesn't configure its own, email is needed to provision users
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Provider is an OpenID Connect identity provider users can sign in with
+type Provider struct {
+	// Name identifies the provider in the login URLs, e.g. /v1/auth/oidc/<name>/start
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+	// RedirectURL is the callback URL registered with the provider,
+	// defaults to APP_BASE_URL/v1/auth/oidc/<name>/callback
+	RedirectURL string `json:"redirectUrl"`
+
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	metadataAt    time.Time
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+var (
+	providers map[string]*Provider
+	mu        sync.RWMutex
+)
+
+// Init (re)loads the providers from the environment, it must be called once the environment is loaded.
+//
+// Providers are read from the JSON file at OIDC_PROVIDERS_FILE, a list of Provider objects, and from
+// OIDC_PROVIDERS, a comma separated list of names each configured with OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_SCOPES (comma separated)
+// and OIDC_<NAME>_REDIRECT_URL.
+func Init() error {
+	loaded, err := load()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	providers = loaded
+	return nil
+}
+
+// Get returns the provider with the given name
+func Get(name string) (*Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+func load() (map[string]*Provider, error) {
+	var list []*Provider
+
+	if file := os.Getenv("OIDC_PROVIDERS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OIDC providers file %s: %w", file, err)
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse OIDC providers file %s: %w", file, err)
+		}
+	}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		p := &Provider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		for _, scope := range strings.Split(os.Getenv(prefix+"SCOPES"), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				p.Scopes = append(p.Scopes, scope)
+			}
+		}
+		list = append(list, p)
+	}
+
+	loaded := map[string]*Provider{}
+	for _, p := range list {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("OIDC provider %q must have a name, issuer and client id", p.Name)
+		}
+		if _, exists := loaded[p.Name]; exists {
+			return nil, fmt.Errorf("OIDC provider %q is configured more than once", p.Name)
+		}
+		p.Issuer = strings.TrimSuffix(p.Issuer, "/")
+		if len(p.Scopes) == 0 {
+			p.Scopes = defaultScopes
+		}
+		if p.RedirectURL == "" {
+			p.RedirectURL = strings.TrimSuffix(getEnv("APP_BASE_URL", "http://localhost:9000"), "/") + "/v1/auth/oidc/" + p.Name + "/callback"
+		}
+		p.client = &http.Client{Timeout: 10 * time.Second}
+		loaded[p.Name] = p
+	}
+
+	return loaded, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -3,53 +3,171 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/thilak009/kong-assignment/controllers"
+	"github.com/thilak009/kong-assignment/models"
 	"github.com/thilak009/kong-assignment/pkg/middleware"
 )
 
 // SetupRoutes configures all API routes for the given router
 func SetupRoutes(r *gin.Engine) {
+	/*** Public keys for verifying access tokens ***/
+	wellKnownController := new(controllers.WellKnownController)
+	r.GET("/.well-known/jwks.json", wellKnownController.JWKS)
+
 	v1 := r.Group("/v1")
 	{
 		/*** User Authentication - No auth required ***/
 		userController := new(controllers.UserController)
 
 		v1.POST("/users/register", userController.Register)
+		v1.POST("/users/verify", userController.VerifyEmail)
+		v1.POST("/users/verify/resend", userController.ResendVerification)
 		v1.POST("/users/login", userController.Login)
+		v1.POST("/users/login/mfa", userController.LoginMFA)
+		v1.POST("/users/token/refresh", userController.RefreshToken)
+		v1.POST("/users/password/forgot", userController.ForgotPassword)
+		v1.POST("/users/password/reset", userController.ResetPassword)
+
+		/*** Single sign-on - No auth required ***/
+		oidcController := new(controllers.OIDCController)
+
+		v1.GET("/auth/oidc/:provider/start", oidcController.Start)
+		v1.GET("/auth/oidc/:provider/callback", oidcController.Callback)
+
+		/*** Invitations - No auth required to decline ***/
+		invitationController := new(controllers.InvitationController)
+
+		v1.POST("/invitations/decline", invitationController.DeclineInvitation)
 
 		/*** Protected routes - require authentication ***/
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.SlugMiddleware())
 		{
+			/*** Routes not available to personal access tokens ***/
+			session := protected.Group("/")
+			session.Use(middleware.UserSessionMiddleware())
+
 			/*** User Authentication - Auth required ***/
-			protected.POST("/users/logout", userController.Logout)
+			session.POST("/users/logout", userController.Logout)
+			session.GET("/users/me", userController.GetMe)
+			session.PATCH("/users/me", userController.UpdateMe)
+			session.DELETE("/users/me", userController.DeleteMe)
+			session.POST("/users/me/password", userController.ChangePassword)
+			session.GET("/users/me/sessions", userController.GetSessions)
+			session.DELETE("/users/me/sessions", userController.RevokeAllSessions)
+			session.DELETE("/users/me/sessions/:sessionId", userController.RevokeSession)
+			session.POST("/users/me/mfa/enroll", userController.EnrollMFA)
+			session.POST("/users/me/mfa/confirm", userController.ConfirmMFA)
+			session.POST("/users/me/mfa/disable", userController.DisableMFA)
+
+			/*** Personal Access Tokens ***/
+			personalAccessTokenController := new(controllers.PersonalAccessTokenController)
+
+			session.POST("/users/me/tokens", personalAccessTokenController.CreateToken)
+			session.GET("/users/me/tokens", personalAccessTokenController.GetTokens)
+			session.DELETE("/users/me/tokens/:tokenId", personalAccessTokenController.RevokeToken)
 
 			/*** Organizations ***/
 			orgController := new(controllers.OrganizationController)
 
-			protected.POST("/orgs", orgController.CreateOrganization)
-			protected.GET("/orgs", orgController.GetOrganizations)
+			session.POST("/orgs", orgController.CreateOrganization)
+			session.GET("/orgs", orgController.GetOrganizations)
+			session.POST("/invitations/accept", invitationController.AcceptInvitation)
+
+			/*** Trash of the user - deleted organizations aren't accessible through the organization routes ***/
+			trashController := new(controllers.TrashController)
+
+			session.GET("/trash/orgs", trashController.GetDeletedOrganizations)
+			session.POST("/orgs/:orgId/restore", trashController.RestoreOrganization)
 			/*** Organization routes - require organization access ***/
-			protected.GET("/orgs/:orgId", middleware.OrganizationAccessMiddleware(), orgController.GetOrganization)
-			protected.PUT("/orgs/:orgId", middleware.OrganizationAccessMiddleware(), orgController.UpdateOrganization)
-			protected.DELETE("/orgs/:orgId", middleware.OrganizationAccessMiddleware(), orgController.DeleteOrganization)
+			protected.GET("/orgs/:orgId", middleware.OrganizationAccessMiddleware(models.PermissionOrgRead), orgController.GetOrganization)
+			protected.PUT("/orgs/:orgId", middleware.OrganizationAccessMiddleware(models.PermissionOrgUpdate), orgController.UpdateOrganization)
+			protected.DELETE("/orgs/:orgId", middleware.OrganizationAccessMiddleware(models.PermissionOrgDelete), orgController.DeleteOrganization)
+
+			/*** Organization Quotas - usage requires organization access, overrides are operator only ***/
+			quotaController := new(controllers.QuotaController)
+
+			protected.GET("/orgs/:orgId/usage", middleware.OrganizationAccessMiddleware(models.PermissionOrgRead), quotaController.GetUsage)
+
+			operator := session.Group("/operator")
+			operator.Use(middleware.OperatorMiddleware())
+			operator.GET("/orgs/:orgId/quotas", quotaController.GetQuota)
+			operator.PUT("/orgs/:orgId/quotas", quotaController.UpdateQuota)
+
+			/*** Organization Attribute Schemas - require organization access, changes are for admins and owners ***/
+			attributeSchemaController := new(controllers.AttributeSchemaController)
+
+			protected.GET("/orgs/:orgId/attribute-schemas/:resource", middleware.OrganizationAccessMiddleware(models.PermissionOrgRead), attributeSchemaController.GetAttributeSchema)
+			protected.PUT("/orgs/:orgId/attribute-schemas/:resource", middleware.OrganizationAccessMiddleware(models.PermissionOrgUpdate), attributeSchemaController.UpdateAttributeSchema)
+			protected.DELETE("/orgs/:orgId/attribute-schemas/:resource", middleware.OrganizationAccessMiddleware(models.PermissionOrgUpdate), attributeSchemaController.DeleteAttributeSchema)
+
+			/*** Organization Members - require organization access ***/
+			memberController := new(controllers.MemberController)
+
+			protected.GET("/orgs/:orgId/members", middleware.OrganizationAccessMiddleware(models.PermissionMembersRead), memberController.GetMembers)
+			protected.PATCH("/orgs/:orgId/members/:userId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), memberController.UpdateMember)
+			protected.DELETE("/orgs/:orgId/members/:userId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), memberController.RemoveMember)
+			protected.POST("/orgs/:orgId/members/:userId/extend", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), memberController.ExtendMembership)
+			protected.POST("/orgs/:orgId/transfer", middleware.OrganizationAccessMiddleware(models.PermissionOrgTransfer), memberController.TransferOwnership)
+			session.POST("/orgs/:orgId/leave", middleware.OrganizationAccessMiddleware(models.PermissionOrgRead), memberController.LeaveOrganization)
+
+			/*** Organization Invitations - require organization access ***/
+			protected.POST("/orgs/:orgId/invitations", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), invitationController.CreateInvitation)
+			protected.GET("/orgs/:orgId/invitations", middleware.OrganizationAccessMiddleware(models.PermissionMembersRead), invitationController.GetInvitations)
+			protected.DELETE("/orgs/:orgId/invitations/:invitationId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), invitationController.RevokeInvitation)
+
+			/*** Organization Teams - require organization access ***/
+			teamController := new(controllers.TeamController)
+
+			protected.POST("/orgs/:orgId/teams", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.CreateTeam)
+			protected.GET("/orgs/:orgId/teams", middleware.OrganizationAccessMiddleware(models.PermissionMembersRead), teamController.GetTeams)
+			protected.GET("/orgs/:orgId/teams/:teamId", middleware.OrganizationAccessMiddleware(models.PermissionMembersRead), teamController.GetTeam)
+			protected.PUT("/orgs/:orgId/teams/:teamId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.UpdateTeam)
+			protected.DELETE("/orgs/:orgId/teams/:teamId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.DeleteTeam)
+			protected.PUT("/orgs/:orgId/teams/:teamId/members/:userId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.AddTeamMember)
+			protected.DELETE("/orgs/:orgId/teams/:teamId/members/:userId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.RemoveTeamMember)
+			protected.PUT("/orgs/:orgId/teams/:teamId/services/:serviceId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.GrantService)
+			protected.DELETE("/orgs/:orgId/teams/:teamId/services/:serviceId", middleware.OrganizationAccessMiddleware(models.PermissionMembersManage), teamController.RevokeService)
 
 			/*** Organization Services - require organization access ***/
 			orgServiceController := new(controllers.ServiceController)
 
-			protected.POST("/orgs/:orgId/services", middleware.OrganizationAccessMiddleware(), orgServiceController.CreateService)
-			protected.GET("/orgs/:orgId/services", middleware.OrganizationAccessMiddleware(), orgServiceController.GetServices)
-			protected.GET("/orgs/:orgId/services/:serviceId", middleware.OrganizationAccessMiddleware(), orgServiceController.GetService)
-			protected.PATCH("/orgs/:orgId/services/:serviceId", middleware.OrganizationAccessMiddleware(), orgServiceController.UpdateService)
-			protected.DELETE("/orgs/:orgId/services/:serviceId", middleware.OrganizationAccessMiddleware(), orgServiceController.DeleteService)
+			protected.POST("/orgs/:orgId/services", middleware.OrganizationAccessMiddleware(models.PermissionServicesWrite), orgServiceController.CreateService)
+			protected.GET("/orgs/:orgId/services", middleware.OrganizationAccessMiddleware(models.PermissionServicesRead), orgServiceController.GetServices)
+			protected.GET("/orgs/:orgId/services/:serviceId", middleware.ServiceAccessMiddleware(models.PermissionServicesRead, models.ServiceAccessRead), orgServiceController.GetService)
+			protected.PATCH("/orgs/:orgId/services/:serviceId", middleware.ServiceAccessMiddleware(models.PermissionServicesWrite, models.ServiceAccessWrite), orgServiceController.UpdateService)
+			protected.DELETE("/orgs/:orgId/services/:serviceId", middleware.ServiceAccessMiddleware(models.PermissionServicesWrite, models.ServiceAccessAdmin), orgServiceController.DeleteService)
 
 			/*** Organization Service Versions - require organization access ***/
 			orgServiceVersionController := new(controllers.ServiceVersionController)
 
-			protected.POST("/orgs/:orgId/services/:serviceId/versions", middleware.OrganizationAccessMiddleware(), orgServiceVersionController.CreateServiceVersion)
-			protected.GET("/orgs/:orgId/services/:serviceId/versions", middleware.OrganizationAccessMiddleware(), orgServiceVersionController.GetServiceVersions)
-			protected.GET("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.OrganizationAccessMiddleware(), orgServiceVersionController.GetServiceVersion)
-			protected.PATCH("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.OrganizationAccessMiddleware(), orgServiceVersionController.UpdateServiceVersion)
-			protected.DELETE("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.OrganizationAccessMiddleware(), orgServiceVersionController.DeleteServiceVersion)
+			protected.POST("/orgs/:orgId/services/:serviceId/versions", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), orgServiceVersionController.CreateServiceVersion)
+			protected.GET("/orgs/:orgId/services/:serviceId/versions", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), orgServiceVersionController.GetServiceVersions)
+			protected.GET("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), orgServiceVersionController.GetServiceVersion)
+			protected.PATCH("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), orgServiceVersionController.UpdateServiceVersion)
+			protected.DELETE("/orgs/:orgId/services/:serviceId/versions/:versionId", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), orgServiceVersionController.DeleteServiceVersion)
+
+			/*** Service Dependencies - require service access, services of other organizations are only shown when the user can read them ***/
+			dependencyController := new(controllers.DependencyController)
+
+			protected.GET("/orgs/:orgId/services/:serviceId/versions/:versionId/dependencies", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), dependencyController.GetDependencies)
+			protected.GET("/orgs/:orgId/services/:serviceId/versions/:versionId/dependencies/closure", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), dependencyController.GetDependencyClosure)
+			protected.PUT("/orgs/:orgId/services/:serviceId/versions/:versionId/dependencies/:dependsOnId", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), dependencyController.SetDependency)
+			protected.DELETE("/orgs/:orgId/services/:serviceId/versions/:versionId/dependencies/:dependsOnId", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), dependencyController.DeleteDependency)
+			protected.GET("/orgs/:orgId/services/:serviceId/versions/:versionId/dependents", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), dependencyController.GetDependents)
+			protected.GET("/orgs/:orgId/services/:serviceId/impact", middleware.ServiceAccessMiddleware(models.PermissionVersionsRead, models.ServiceAccessRead), dependencyController.GetImpact)
+			protected.GET("/orgs/:orgId/dependency-graph", middleware.OrganizationAccessMiddleware(models.PermissionVersionsRead), dependencyController.GetDependencyGraph)
+
+			/*** Search - across the organizations of the user, or within one with organization access ***/
+			searchController := new(controllers.SearchController)
+
+			protected.GET("/search", searchController.Search)
+			protected.GET("/orgs/:orgId/search", middleware.OrganizationAccessMiddleware(models.PermissionServicesRead), searchController.SearchOrganization)
+
+			/*** Organization Trash - require organization access ***/
+			protected.GET("/orgs/:orgId/trash/services", middleware.OrganizationAccessMiddleware(models.PermissionServicesRead), trashController.GetDeletedServices)
+			protected.GET("/orgs/:orgId/trash/versions", middleware.OrganizationAccessMiddleware(models.PermissionVersionsRead), trashController.GetDeletedServiceVersions)
+			protected.POST("/orgs/:orgId/services/:serviceId/restore", middleware.ServiceAccessMiddleware(models.PermissionServicesWrite, models.ServiceAccessAdmin), trashController.RestoreService)
+			protected.POST("/orgs/:orgId/services/:serviceId/versions/:versionId/restore", middleware.ServiceAccessMiddleware(models.PermissionVersionsWrite, models.ServiceAccessWrite), trashController.RestoreServiceVersion)
 		}
 	}
 }
@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestAttributes tests the attributes of services and versions, their validation against the attribute schemas of
+// the organization and filtering by attribute
+func TestAttributes(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, ownerToken := helpers.CreateTestUser("attributes-owner@example.com", "Owner", TestPassword)
+	editor, editorToken := helpers.CreateTestUser("attributes-editor@example.com", "Editor", TestPassword)
+	org := helpers.CreateTestOrganization(ownerToken, "Attributes Org", "Test organization description")
+	helpers.AddTestMember(org.ID, editor.ID, models.RoleEditor)
+
+	serviceSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"costCenter"},
+		"properties": map[string]interface{}{
+			"costCenter": map[string]interface{}{"type": "string", "pattern": "^CC-[0-9]+$"},
+			"tier":       map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 3},
+			"oncall": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"team": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+
+	createService := func(name string, attributes map[string]interface{}) (int, models.Service, models.ErrorResponse) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":       name,
+			"attributes": attributes,
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+
+		var service models.Service
+		var errorResponse models.ErrorResponse
+		if resp.Code == http.StatusOK {
+			helpers.AssertJSONResponse(resp, &service)
+		} else {
+			helpers.AssertJSONResponse(resp, &errorResponse)
+		}
+		return resp.Code, service, errorResponse
+	}
+
+	listServices := func(filters ...string) []string {
+		query := url.Values{"attribute": filters, "sort_by": {"name"}, "sort": {"asc"}}
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?%s", org.ID, query.Encode()), nil, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Service]
+		helpers.AssertJSONResponse(resp, &result)
+		names := make([]string, 0, len(result.Data))
+		for _, service := range result.Data {
+			names = append(names, service.Name)
+		}
+		return names
+	}
+
+	t.Run("FreeFormWithoutSchema", func(t *testing.T) {
+		code, service, _ := createService("Legacy Billing", map[string]interface{}{"anything": []string{"goes"}})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []interface{}{"goes"}, service.Attributes["anything"])
+
+		code, service, _ = createService("No Attributes", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotNil(t, service.Attributes)
+		assert.Empty(t, service.Attributes)
+	})
+
+	t.Run("OnlyAdminsRegisterSchemas", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/services", org.ID), map[string]interface{}{
+			"schema": serviceSchema,
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/services", org.ID), map[string]interface{}{
+			"schema": serviceSchema,
+		}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		// members can read the schema to know what to send
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/services", org.ID), nil, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var schema models.AttributeSchema
+		helpers.AssertJSONResponse(resp, &schema)
+		assert.Equal(t, models.AttributeServices, schema.Resource)
+		assert.Equal(t, "object", schema.Schema["type"])
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/versions", org.ID), nil, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+
+	t.Run("InvalidSchemas", func(t *testing.T) {
+		for _, schema := range []map[string]interface{}{
+			{"type": "text"},
+			{"$ref": "file:///etc/passwd"},
+			{"$ref": "https://example.com/schema.json"},
+		} {
+			resp, err := helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/versions", org.ID), map[string]interface{}{
+				"schema": schema,
+			}, ownerToken)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		}
+
+		resp, err := helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/teams", org.ID), map[string]interface{}{
+			"schema": serviceSchema,
+		}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+
+	t.Run("FieldLevelErrors", func(t *testing.T) {
+		code, _, errorResponse := createService("Payments", map[string]interface{}{
+			"costCenter": "finance",
+			"tier":       5,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "invalid_attributes", errorResponse.Type)
+
+		details, ok := errorResponse.Details.(map[string]interface{})
+		if assert.True(t, ok) {
+			paths := []string{}
+			for _, violation := range details["errors"].([]interface{}) {
+				paths = append(paths, violation.(map[string]interface{})["path"].(string))
+			}
+			assert.Equal(t, []string{"/costCenter", "/tier"}, paths)
+		}
+
+		// required attributes are enforced on creation
+		code, _, errorResponse = createService("Payments", nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "invalid_attributes", errorResponse.Type)
+	})
+
+	t.Run("ValidatedOnUpdate", func(t *testing.T) {
+		code, service, _ := createService("Checkout", map[string]interface{}{"costCenter": "CC-42", "tier": 1})
+		assert.Equal(t, http.StatusOK, code)
+
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), map[string]interface{}{
+			"attributes": map[string]interface{}{"tier": 2},
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), map[string]interface{}{
+			"attributes": map[string]interface{}{"costCenter": "CC-42", "tier": 2, "oncall": map[string]interface{}{"team": "payments"}},
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var updated models.Service
+		helpers.AssertJSONResponse(resp, &updated)
+		assert.Equal(t, float64(2), updated.Attributes["tier"])
+	})
+
+	t.Run("FilterByAttribute", func(t *testing.T) {
+		code, _, _ := createService("Refunds", map[string]interface{}{"costCenter": "CC-42", "tier": 1, "oncall": map[string]interface{}{"team": "finance"}})
+		assert.Equal(t, http.StatusOK, code)
+
+		assert.Equal(t, []string{"Checkout", "Refunds"}, listServices("costCenter=CC-42"))
+		assert.Equal(t, []string{"Checkout"}, listServices("oncall.team=payments"))
+		assert.Equal(t, []string{"Refunds"}, listServices("costCenter=CC-42", "tier=1"))
+		// a quoted value only matches strings
+		assert.Empty(t, listServices(`tier="1"`))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?attribute=tier", org.ID), nil, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+	})
+
+	t.Run("VersionSchema", func(t *testing.T) {
+		service := helpers.CreateTestService(ownerToken, org.ID, "Inventory", "Test service description")
+
+		resp, err := helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/versions", org.ID), map[string]interface{}{
+			"schema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"canary": map[string]interface{}{"type": "boolean"}},
+			},
+		}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":       "Release 1",
+			"version":    "1.0.0",
+			"attributes": map[string]interface{}{"canary": "yes"},
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":       "Release 1",
+			"version":    "1.0.0",
+			"attributes": map[string]interface{}{"canary": true},
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		helpers.CreateTestServiceVersion(ownerToken, org.ID, service.ID, "Release 2", "2.0.0", "Test version description")
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions?attribute=canary=true", org.ID, service.ID), nil, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.ServiceVersion]
+		helpers.AssertJSONResponse(resp, &result)
+		if assert.Len(t, result.Data, 1) {
+			assert.Equal(t, "1.0.0", result.Data[0].Version)
+		}
+
+		// without a schema anything goes again
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", fmt.Sprintf("/v1/orgs/%s/attribute-schemas/versions", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":       "Release 3",
+			"version":    "3.0.0",
+			"attributes": map[string]interface{}{"canary": "yes"},
+		}, editorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+	})
+}
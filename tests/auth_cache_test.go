@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/cache"
+)
+
+// TestAuthCache tests the caching of the token and membership checks of protected routes
+func TestAuthCache(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	get := func(path string, token string) int {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", path, nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	t.Run("RepeatedRequestsHitTheCache", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("cache-hits@example.com", "Test User", TestPassword)
+		org := helpers.CreateTestOrganization(token, "Cached Org", "Test org description")
+
+		before := models.AuthCacheStats()
+		assert.Equal(t, http.StatusOK, get("/v1/orgs/"+org.ID, token))
+		assert.Equal(t, http.StatusOK, get("/v1/orgs/"+org.ID, token))
+		after := models.AuthCacheStats()
+
+		assert.GreaterOrEqual(t, after["sessions"].Hits-before["sessions"].Hits, uint64(2), "Session checks should be served from the cache")
+		assert.GreaterOrEqual(t, after["membership"].Hits-before["membership"].Hits, uint64(1), "Membership checks should be served from the cache")
+	})
+
+	t.Run("LogoutInvalidatesCachedSession", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("cache-logout@example.com", "Test User", TestPassword)
+		assert.Equal(t, http.StatusOK, get("/v1/orgs", token))
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/logout", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		assert.Equal(t, http.StatusUnauthorized, get("/v1/orgs", token))
+	})
+
+	t.Run("DeletingOrganizationInvalidatesCachedMembership", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("cache-org@example.com", "Test User", TestPassword)
+		org := helpers.CreateTestOrganization(token, "Short Lived Org", "Test org description")
+		assert.Equal(t, http.StatusOK, get("/v1/orgs/"+org.ID, token))
+
+		resp, err := helpers.MakeAuthenticatedRequest("DELETE", "/v1/orgs/"+org.ID, nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		assert.Equal(t, http.StatusForbidden, get("/v1/orgs/"+org.ID, token))
+	})
+
+	t.Run("ExpiringMembershipIsNotCachedPastExpiry", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("cache-expiry-owner@example.com", "Test User", TestPassword)
+		member, memberToken := helpers.CreateTestUser("cache-expiry-member@example.com", "Test User", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Expiring Org", "Test org description")
+		helpers.AddTestMember(org.ID, member.ID, models.RoleViewer)
+		GetTestDB().Model(&models.UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", org.ID, member.ID).
+			Update("expires_at", time.Now().Add(time.Second))
+		models.PurgeAuthCaches()
+
+		assert.Equal(t, http.StatusOK, get("/v1/orgs/"+org.ID, memberToken))
+
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, http.StatusForbidden, get("/v1/orgs/"+org.ID, memberToken), "Cached membership should not outlive its expiry")
+	})
+
+	t.Run("TTLCache", func(t *testing.T) {
+		c := cache.New[string, int](50*time.Millisecond, 2)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		_, _ = c.Get("a")
+		c.Set("c", 3)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok, "Least recently used entry should be evicted when the cache is full")
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+
+		time.Sleep(60 * time.Millisecond)
+		_, ok = c.Get("c")
+		assert.False(t, ok, "Entries should expire after the time to live")
+
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(2), stats.Misses)
+
+		c = cache.New[string, int](time.Minute, 2)
+		c.SetUntil("a", 1, time.Now().Add(50*time.Millisecond))
+		c.SetUntil("b", 2, time.Now().Add(-time.Millisecond))
+		_, ok = c.Get("b")
+		assert.False(t, ok, "Entries past their deadline should not be cached")
+		_, ok = c.Get("a")
+		assert.True(t, ok)
+		time.Sleep(60 * time.Millisecond)
+		_, ok = c.Get("a")
+		assert.False(t, ok, "Entries should expire at their deadline")
+	})
+}
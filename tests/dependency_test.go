@@ -0,0 +1,303 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestDependencies tests the dependencies of versions on other services, the cycle check, dependents, the transitive
+// closure, impact analysis and the export of the dependency graph
+func TestDependencies(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, ownerToken := helpers.CreateTestUser("dependencies-owner@example.com", "Owner", TestPassword)
+	outsider, outsiderToken := helpers.CreateTestUser("dependencies-outsider@example.com", "Outsider", TestPassword)
+	org := helpers.CreateTestOrganization(ownerToken, "Dependencies Org", "Test organization description")
+	platformOrg := helpers.CreateTestOrganization(ownerToken, "Platform Org", "Test organization description")
+	otherOrg := helpers.CreateTestOrganization(outsiderToken, "Other Org", "Test organization description")
+	helpers.AddTestMember(org.ID, outsider.ID, models.RoleEditor)
+
+	gateway := helpers.CreateTestService(ownerToken, org.ID, "Gateway", "Routes requests")
+	billing := helpers.CreateTestService(ownerToken, org.ID, "Billing", "Bills customers")
+	auth := helpers.CreateTestService(ownerToken, platformOrg.ID, "Auth", "Authenticates users")
+	secret := helpers.CreateTestService(outsiderToken, otherOrg.ID, "Secret", "Not readable by the owner")
+
+	gateway2 := helpers.CreateTestServiceVersion(ownerToken, org.ID, gateway.ID, "Gateway", "2.1.0", "")
+	billing1 := helpers.CreateTestServiceVersion(ownerToken, org.ID, billing.ID, "Billing", "1.4.2", "")
+	billing2 := helpers.CreateTestServiceVersion(ownerToken, org.ID, billing.ID, "Billing", "2.0.0", "")
+	auth1 := helpers.CreateTestServiceVersion(ownerToken, platformOrg.ID, auth.ID, "Auth", "1.0.0", "")
+	helpers.CreateTestServiceVersion(outsiderToken, otherOrg.ID, secret.ID, "Secret", "1.0.0", "")
+
+	dependencyPath := func(orgID, serviceID, versionID, dependsOnID string) string {
+		return fmt.Sprintf("/v1/orgs/%s/services/%s/versions/%s/dependencies/%s", orgID, serviceID, versionID, dependsOnID)
+	}
+
+	setDependency := func(token, orgID, serviceID, versionID, dependsOnID, constraint string) (int, models.ServiceDependency, models.ErrorResponse) {
+		resp, err := helpers.MakeAuthenticatedRequest("PUT", dependencyPath(orgID, serviceID, versionID, dependsOnID), map[string]interface{}{
+			"constraint": constraint,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+
+		var dependency models.ServiceDependency
+		var errorResponse models.ErrorResponse
+		if resp.Code == http.StatusOK {
+			helpers.AssertJSONResponse(resp, &dependency)
+		} else {
+			helpers.AssertJSONResponse(resp, &errorResponse)
+		}
+		return resp.Code, dependency, errorResponse
+	}
+
+	t.Run("SetAndList", func(t *testing.T) {
+		code, dependency, _ := setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, billing.ID, "^1.4.0")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "^1.4.0", dependency.Constraint)
+		if assert.NotNil(t, dependency.Resolved) {
+			assert.Equal(t, billing1.ID, dependency.Resolved.ID)
+		}
+
+		// depending on a service of another organization of the user
+		code, _, _ = setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, auth.ID, "1.x")
+		assert.Equal(t, http.StatusOK, code)
+
+		// the range of an existing dependency is replaced
+		code, dependency, _ = setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, billing.ID, ">=1.0.0")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, dependency.Resolved) {
+			assert.Equal(t, billing2.ID, dependency.Resolved.ID)
+		}
+		code, _, _ = setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, billing.ID, "^1.4.0")
+		assert.Equal(t, http.StatusOK, code)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions/%s/dependencies", org.ID, gateway.ID, gateway2.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var dependencies []models.ServiceDependency
+		helpers.AssertJSONResponse(resp, &dependencies)
+		assert.Len(t, dependencies, 2)
+	})
+
+	t.Run("InvalidConstraint", func(t *testing.T) {
+		code, _, errorResponse := setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, billing.ID, "latest")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, errorResponse.Message, "range of semantic versions")
+	})
+
+	t.Run("UnreadableServiceNotFound", func(t *testing.T) {
+		code, _, _ := setDependency(ownerToken, org.ID, gateway.ID, gateway2.ID, secret.ID, "^1.0.0")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("RejectsCycles", func(t *testing.T) {
+		code, _, _ := setDependency(ownerToken, platformOrg.ID, auth.ID, auth1.ID, billing.ID, "*")
+		assert.Equal(t, http.StatusOK, code)
+
+		// billing -> gateway would close gateway -> billing
+		code, _, errorResponse := setDependency(ownerToken, org.ID, billing.ID, billing1.ID, gateway.ID, "^2.0.0")
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, "dependency_cycle", errorResponse.Type)
+		details, ok := errorResponse.Details.(map[string]interface{})
+		if assert.True(t, ok) {
+			assert.Equal(t, []interface{}{billing.ID, gateway.ID, billing.ID}, details["cycle"])
+		}
+
+		// billing -> auth would close auth -> billing
+		code, _, errorResponse = setDependency(ownerToken, org.ID, billing.ID, billing2.ID, auth.ID, "^1.0.0")
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, "dependency_cycle", errorResponse.Type)
+
+		code, _, _ = setDependency(ownerToken, org.ID, billing.ID, billing1.ID, billing.ID, "*")
+		assert.Equal(t, http.StatusConflict, code)
+
+		resp, err := helpers.MakeAuthenticatedRequest("DELETE", dependencyPath(platformOrg.ID, auth.ID, auth1.ID, billing.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", dependencyPath(platformOrg.ID, auth.ID, auth1.ID, billing.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+
+	t.Run("Dependents", func(t *testing.T) {
+		getDependents := func(serviceID, versionID string) []models.Dependent {
+			resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions/%s/dependents", org.ID, serviceID, versionID), nil, ownerToken)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusOK)
+
+			var dependents []models.Dependent
+			helpers.AssertJSONResponse(resp, &dependents)
+			return dependents
+		}
+
+		dependents := getDependents(billing.ID, billing1.ID)
+		if assert.Len(t, dependents, 1) {
+			assert.Equal(t, gateway2.ID, dependents[0].VersionID)
+			assert.Equal(t, "^1.4.0", dependents[0].Constraint)
+		}
+
+		// 2.0.0 doesn't satisfy ^1.4.0
+		assert.Empty(t, getDependents(billing.ID, billing2.ID))
+	})
+
+	t.Run("Closure", func(t *testing.T) {
+		code, _, _ := setDependency(ownerToken, org.ID, billing.ID, billing1.ID, auth.ID, "~1.0")
+		assert.Equal(t, http.StatusOK, code)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions/%s/dependencies/closure", org.ID, gateway.ID, gateway2.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var nodes []models.DependencyNode
+		helpers.AssertJSONResponse(resp, &nodes)
+		depths := map[string][]int{}
+		for _, node := range nodes {
+			depths[node.Service.ID] = append(depths[node.Service.ID], node.Depth)
+		}
+		assert.Equal(t, []int{1}, depths[billing.ID])
+		// auth is a direct dependency of gateway and one of billing 1.4.2
+		assert.Equal(t, []int{1, 2}, depths[auth.ID])
+	})
+
+	t.Run("Impact", func(t *testing.T) {
+		getImpact := func(serviceID, versions string) (int, models.ImpactReport) {
+			resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/impact?versions=%s", org.ID, serviceID, url.QueryEscape(versions)), nil, ownerToken)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+
+			var report models.ImpactReport
+			if resp.Code == http.StatusOK {
+				helpers.AssertJSONResponse(resp, &report)
+			}
+			return resp.Code, report
+		}
+
+		// deprecating billing 1.x breaks gateway 2.1.0, the only 1.x version is 1.4.2
+		code, report := getImpact(billing.ID, "1.x")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.AffectedVersions, 1)
+		if assert.Len(t, report.Impacted, 1) {
+			assert.Equal(t, gateway2.ID, report.Impacted[0].VersionID)
+			assert.Equal(t, models.ImpactBreaks, report.Impacted[0].Status)
+			assert.Equal(t, 1, report.Impacted[0].Depth)
+		}
+
+		// auth 1.0.0 going away breaks billing 1.4.2, which in turn breaks gateway 2.1.0
+		code, report = getImpact(auth.ID, "1.x")
+		assert.Equal(t, http.StatusOK, code)
+		statuses := map[string]models.ImpactStatus{}
+		for _, impacted := range report.Impacted {
+			statuses[impacted.VersionID] = impacted.Status
+		}
+		assert.Equal(t, models.ImpactBreaks, statuses[billing1.ID])
+		assert.Equal(t, models.ImpactBreaks, statuses[gateway2.ID])
+
+		// billing 1.4.3 keeps ^1.4.0 satisfied, gateway is only at risk
+		helpers.CreateTestServiceVersion(ownerToken, org.ID, billing.ID, "Billing", "1.4.3", "")
+		code, report = getImpact(billing.ID, "<=1.4.2")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.Len(t, report.Impacted, 1) {
+			assert.Equal(t, models.ImpactAtRisk, report.Impacted[0].Status)
+		}
+
+		code, _ = getImpact(billing.ID, "one")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("UnreadableServicesLeftOut", func(t *testing.T) {
+		// the outsider is an editor of the organization but can't read the platform organization of auth
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions/%s/dependencies", org.ID, gateway.ID, gateway2.ID), nil, outsiderToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var dependencies []models.ServiceDependency
+		helpers.AssertJSONResponse(resp, &dependencies)
+		if assert.Len(t, dependencies, 1) {
+			assert.Equal(t, billing.ID, dependencies[0].DependsOnServiceID)
+		}
+	})
+
+	t.Run("ExportGraph", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/dependency-graph", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var graph models.DependencyGraph
+		helpers.AssertJSONResponse(resp, &graph)
+		assert.Len(t, graph.Nodes, 3)
+		assert.Len(t, graph.Edges, 3)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/dependency-graph?format=dot", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "text/vnd.graphviz")
+		assert.Contains(t, resp.Body.String(), "digraph dependencies {")
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("%q -> %q [label=\"2.1.0: ^1.4.0 (1.4.3)\"];", gateway.ID, billing.ID))
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/dependency-graph?format=mermaid", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "flowchart LR")
+		assert.Contains(t, resp.Body.String(), `-->|"2.1.0: ^1.4.0 (1.4.3)"|`)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/dependency-graph?format=svg", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+	})
+
+	t.Run("CycleThroughUnreadableServiceRedacted", func(t *testing.T) {
+		ledger := helpers.CreateTestService(ownerToken, org.ID, "Ledger", "Records transactions")
+		payouts := helpers.CreateTestService(ownerToken, org.ID, "Payouts", "Pays out merchants")
+		ledger1 := helpers.CreateTestServiceVersion(ownerToken, org.ID, ledger.ID, "Ledger", "1.0.0", "")
+		payouts1 := helpers.CreateTestServiceVersion(ownerToken, org.ID, payouts.ID, "Payouts", "1.0.0", "")
+		secret2 := helpers.CreateTestServiceVersion(outsiderToken, otherOrg.ID, secret.ID, "Secret", "2.0.0", "")
+
+		// payouts -> secret -> ledger, only the outsider can read secret
+		code, _, _ := setDependency(outsiderToken, otherOrg.ID, secret.ID, secret2.ID, ledger.ID, "*")
+		assert.Equal(t, http.StatusOK, code)
+		code, _, _ = setDependency(outsiderToken, org.ID, payouts.ID, payouts1.ID, secret.ID, "*")
+		assert.Equal(t, http.StatusOK, code)
+
+		code, _, errorResponse := setDependency(ownerToken, org.ID, ledger.ID, ledger1.ID, payouts.ID, "*")
+		assert.Equal(t, http.StatusConflict, code, "The cycle should be rejected even through unreadable services")
+		assert.Equal(t, "dependency_cycle", errorResponse.Type)
+		details, ok := errorResponse.Details.(map[string]interface{})
+		if assert.True(t, ok) {
+			assert.Equal(t, []interface{}{ledger.ID, payouts.ID, "", ledger.ID}, details["cycle"])
+		}
+	})
+}
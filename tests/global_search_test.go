@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestGlobalSearch tests searching across the organizations of the user and that nothing of other organizations
+// or of services restricted to teams leaks into the results
+func TestGlobalSearch(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, ownerToken := helpers.CreateTestUser("global-owner@example.com", "Owner", TestPassword)
+	viewer, viewerToken := helpers.CreateTestUser("global-viewer@example.com", "Viewer", TestPassword)
+	_, outsiderToken := helpers.CreateTestUser("global-outsider@example.com", "Outsider", TestPassword)
+
+	retail := helpers.CreateTestOrganization(ownerToken, "Retail", "Storefront and inventory billing teams")
+	logistics := helpers.CreateTestOrganization(ownerToken, "Logistics", "Warehouses and delivery")
+	other := helpers.CreateTestOrganization(outsiderToken, "Other Billing Co", "Not shared with anyone else")
+	helpers.AddTestMember(retail.ID, viewer.ID, models.RoleViewer)
+	helpers.AddTestMember(logistics.ID, viewer.ID, models.RoleViewer)
+
+	retailBilling := helpers.CreateTestService(ownerToken, retail.ID, "Billing", "Invoices for storefront orders")
+	logisticsBilling := helpers.CreateTestService(ownerToken, logistics.ID, "Freight Billing", "Invoices for carriers")
+	restricted := helpers.CreateTestService(ownerToken, logistics.ID, "Billing Audit", "Restricted to the finance team")
+	version := helpers.CreateTestServiceVersion(ownerToken, retail.ID, retailBilling.ID, "Billing v2", "2.0.0", "Supports recurring invoices")
+	helpers.CreateTestService(outsiderToken, other.ID, "Billing", "Invoices of another organization")
+
+	// a team in logistics hides its services from the viewer, who isn't in any team
+	resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/teams", logistics.ID), map[string]interface{}{
+		"name":        "Finance",
+		"description": "Test team description",
+	}, ownerToken)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	helpers.AssertStatusCode(resp, http.StatusCreated)
+	var team models.Team
+	helpers.AssertJSONResponse(resp, &team)
+	resp, err = helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/teams/%s/services/%s", logistics.ID, team.ID, restricted.ID), map[string]interface{}{
+		"access": "read",
+	}, ownerToken)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	helpers.AssertStatusCode(resp, http.StatusOK)
+
+	search := func(token, q string) models.SearchResult {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/search?q=%s", url.QueryEscape(q)), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.SearchResult
+		helpers.AssertJSONResponse(resp, &result)
+		return result
+	}
+
+	hitIDs := func(result models.SearchResult) map[string]models.SearchKind {
+		ids := map[string]models.SearchKind{}
+		for _, hit := range result.Data {
+			ids[hit.ID] = hit.Kind
+		}
+		return ids
+	}
+
+	t.Run("AcrossOrganizations", func(t *testing.T) {
+		result := search(ownerToken, "billing")
+		ids := hitIDs(result)
+
+		assert.Equal(t, models.SearchOrganization, ids[retail.ID])
+		assert.Equal(t, models.SearchService, ids[retailBilling.ID])
+		assert.Equal(t, models.SearchService, ids[logisticsBilling.ID])
+		assert.Equal(t, models.SearchService, ids[restricted.ID])
+		assert.Equal(t, models.SearchVersion, ids[version.ID])
+		assert.NotContains(t, ids, other.ID)
+		assert.Equal(t, 5, result.Meta.TotalCount)
+
+		for _, hit := range result.Data {
+			if hit.ID == logisticsBilling.ID {
+				assert.Equal(t, logistics.ID, hit.OrganizationID)
+				assert.Equal(t, "Logistics", hit.OrganizationName)
+				assert.Equal(t, logistics.Slug, hit.OrganizationSlug)
+			}
+		}
+	})
+
+	t.Run("MembershipAndTeamsApply", func(t *testing.T) {
+		ids := hitIDs(search(viewerToken, "billing"))
+		assert.Contains(t, ids, retailBilling.ID)
+		// logistics has a team, the viewer only sees the services granted to their teams there
+		assert.NotContains(t, ids, logisticsBilling.ID)
+		assert.NotContains(t, ids, restricted.ID)
+		assert.NotContains(t, ids, other.ID)
+
+		ids = hitIDs(search(outsiderToken, "billing"))
+		assert.Len(t, ids, 2)
+		assert.Contains(t, ids, other.ID)
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/search?q=billing&per_page=2&page=1", nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.SearchResult
+		helpers.AssertJSONResponse(resp, &result)
+		assert.Len(t, result.Data, 2)
+		assert.Equal(t, 5, result.Meta.TotalCount)
+		assert.Equal(t, 3, result.Meta.TotalPages)
+	})
+
+	t.Run("PersonalAccessTokenScopes", func(t *testing.T) {
+		token := helpers.CreateTestPersonalAccessToken(ownerToken, []string{logistics.ID}, []string{"services:read"})
+
+		ids := hitIDs(search(token, "billing"))
+		assert.Len(t, ids, 2)
+		assert.Contains(t, ids, logisticsBilling.ID)
+		assert.Contains(t, ids, restricted.ID)
+	})
+}
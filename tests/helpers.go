@@ -7,10 +7,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
 )
 
 // TestPassword is a strong password that meets all validation requirements:
@@ -20,6 +22,9 @@ import (
 // - Contains special character (!)
 const TestPassword = "Password123!"
 
+// emailTokenRegex matches the token query parameter of links in mails
+var emailTokenRegex = regexp.MustCompile(`[?&]token=([A-Za-z0-9_-]+)`)
+
 // TestHelpers provides utility functions for testing
 type TestHelpers struct {
 	t *testing.T
@@ -158,11 +163,33 @@ func (h *TestHelpers) CleanupDatabase() {
 	}
 
 	// Clean tables in reverse order of dependencies
+	testDB.Exec("DELETE FROM service_dependencies")
+	testDB.Exec("DELETE FROM attribute_schemas")
+	testDB.Exec("DELETE FROM slug_redirects")
+	testDB.Exec("DELETE FROM organization_quotas")
+	testDB.Exec("DELETE FROM service_grants")
+	testDB.Exec("DELETE FROM team_members")
+	testDB.Exec("DELETE FROM teams")
 	testDB.Exec("DELETE FROM service_versions")
 	testDB.Exec("DELETE FROM services")
+	testDB.Exec("DELETE FROM invitations")
 	testDB.Exec("DELETE FROM user_organization_maps")
 	testDB.Exec("DELETE FROM organizations")
+	testDB.Exec("DELETE FROM refresh_tokens")
+	testDB.Exec("DELETE FROM password_reset_tokens")
+	testDB.Exec("DELETE FROM email_verification_tokens")
+	testDB.Exec("DELETE FROM mfa_challenges")
+	testDB.Exec("DELETE FROM mfa_recovery_codes")
+	testDB.Exec("DELETE FROM user_mfas")
+	testDB.Exec("DELETE FROM personal_access_tokens")
+	testDB.Exec("DELETE FROM oidc_login_states")
+	testDB.Exec("DELETE FROM user_identities")
+	testDB.Exec("DELETE FROM login_attempts")
+	testDB.Exec("DELETE FROM sessions")
 	testDB.Exec("DELETE FROM users")
+
+	// rows were deleted behind the back of the models, cached checks must not outlive them
+	models.PurgeAuthCaches()
 }
 
 // CreateTestUser creates a test user and returns user and token
@@ -181,12 +208,11 @@ func (h *TestHelpers) CreateTestUser(email, name, password string) (*models.User
 		h.t.Fatalf("Failed to register test user: %v", err)
 	}
 
-	if resp.Code != http.StatusCreated {
+	if resp.Code != http.StatusAccepted {
 		h.t.Fatalf("Failed to register test user, status: %d, body: %s", resp.Code, resp.Body.String())
 	}
 
-	var user models.User
-	h.AssertJSONResponse(resp, &user)
+	user := h.VerifyTestUser(email)
 
 	// Login to get token
 	loginPayload := map[string]interface{}{
@@ -206,7 +232,55 @@ func (h *TestHelpers) CreateTestUser(email, name, password string) (*models.User
 	var loginResponse models.TokenResponse
 	h.AssertJSONResponse(loginResp, &loginResponse)
 
-	return &user, loginResponse.AccessToken
+	return user, loginResponse.AccessToken
+}
+
+// VerifyTestUser verifies the email of a registered user with the token from the latest verification mail
+func (h *TestHelpers) VerifyTestUser(email string) *models.User {
+	h.ensureTestEnvironment()
+
+	token := h.ExtractEmailToken(h.LatestEmailTo(email))
+	resp, err := h.MakeRequest("POST", "/v1/users/verify", map[string]interface{}{
+		"token": token,
+	})
+	if err != nil {
+		h.t.Fatalf("Failed to verify test user: %v", err)
+	}
+
+	if resp.Code != http.StatusOK {
+		h.t.Fatalf("Failed to verify test user, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+
+	var user models.User
+	h.AssertJSONResponse(resp, &user)
+
+	return &user
+}
+
+// CreateTestPersonalAccessToken creates a personal access token for the user of the token and returns the raw token
+func (h *TestHelpers) CreateTestPersonalAccessToken(token string, orgIDs []string, scopes []string) string {
+	h.ensureTestEnvironment()
+
+	payload := map[string]interface{}{
+		"name":            "Test Token",
+		"organizationIds": orgIDs,
+		"scopes":          scopes,
+		"expiresInDays":   30,
+	}
+
+	resp, err := h.MakeAuthenticatedRequest("POST", "/v1/users/me/tokens", payload, token)
+	if err != nil {
+		h.t.Fatalf("Failed to create test personal access token: %v", err)
+	}
+
+	if resp.Code != http.StatusCreated {
+		h.t.Fatalf("Failed to create test personal access token, status: %d, body: %s", resp.Code, resp.Body.String())
+	}
+
+	var created models.CreatedPersonalAccessTokenResponse
+	h.AssertJSONResponse(resp, &created)
+
+	return created.Token
 }
 
 // CreateTestOrganization creates a test organization
@@ -233,6 +307,20 @@ func (h *TestHelpers) CreateTestOrganization(token, name, description string) *m
 	return &org
 }
 
+// AddTestMember adds the user to the organization with the role directly in the database
+func (h *TestHelpers) AddTestMember(orgID, userID string, role models.Role) {
+	h.ensureTestEnvironment()
+
+	membership := models.UserOrganizationMap{
+		UserID:         userID,
+		OrganizationID: orgID,
+		Role:           role,
+	}
+	if err := testDB.Create(&membership).Error; err != nil {
+		h.t.Fatalf("Failed to add test member: %v", err)
+	}
+}
+
 // CreateTestService creates a test service in the database
 func (h *TestHelpers) CreateTestService(token, orgID, name, description string) *models.Service {
 	h.ensureTestEnvironment()
@@ -282,6 +370,53 @@ func (h *TestHelpers) CreateTestServiceVersion(token, orgID, serviceID, name, ve
 	return &serviceVersion
 }
 
+// LatestEmailTo returns the most recent mail sent to the address, failing the test if there is none
+func (h *TestHelpers) LatestEmailTo(to string) mailer.Message {
+	h.ensureTestEnvironment()
+	// mails sent in the background are awaited
+	mailer.Wait()
+
+	messages, err := GetTestMailer().Messages()
+	if err != nil {
+		h.t.Fatalf("Failed to read test mails: %v", err)
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].To == to {
+			return messages[i]
+		}
+	}
+	h.t.Fatalf("No mail sent to %s", to)
+	return mailer.Message{}
+}
+
+// CountEmailsTo returns the number of mails sent to the address
+func (h *TestHelpers) CountEmailsTo(to string) int {
+	h.ensureTestEnvironment()
+	// mails sent in the background are awaited
+	mailer.Wait()
+
+	messages, err := GetTestMailer().Messages()
+	if err != nil {
+		h.t.Fatalf("Failed to read test mails: %v", err)
+	}
+	count := 0
+	for _, msg := range messages {
+		if msg.To == to {
+			count++
+		}
+	}
+	return count
+}
+
+// ExtractEmailToken returns the token of the first link in the mail body
+func (h *TestHelpers) ExtractEmailToken(msg mailer.Message) string {
+	match := emailTokenRegex.FindStringSubmatch(msg.Body)
+	if match == nil {
+		h.t.Fatalf("No token found in mail: %s", msg.Body)
+	}
+	return match[1]
+}
+
 // GetTestServerURL returns the test server URL
 func (h *TestHelpers) GetTestServerURL() string {
 	return GetTestServer().URL
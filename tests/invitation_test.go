@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestInvitations tests inviting users to an organization by email
+func TestInvitations(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	invite := func(token, orgID, email string, role models.Role) *models.Invitation {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/invitations", orgID), map[string]interface{}{
+			"email": email,
+			"role":  role,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusCreated)
+
+		var invitation models.Invitation
+		helpers.AssertJSONResponse(resp, &invitation)
+		return &invitation
+	}
+
+	accept := func(token, invitationToken string) (int, models.Organization) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/invitations/accept", map[string]interface{}{
+			"token": invitationToken,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+
+		var org models.Organization
+		if resp.Code == http.StatusOK {
+			helpers.AssertJSONResponse(resp, &org)
+		}
+		return resp.Code, org
+	}
+
+	t.Run("AcceptAfterSignup", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Invite Organization", "Test organization description")
+
+		invitation := invite(ownerToken, org.ID, "Invite-New@example.com", models.RoleEditor)
+		assert.Equal(t, "invite-new@example.com", invitation.Email)
+		assert.Equal(t, models.InvitationPending, invitation.Status)
+
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("invite-new@example.com"))
+
+		// the invited email signs up after the invitation was sent
+		_, token := helpers.CreateTestUser("invite-new@example.com", "New User", TestPassword)
+
+		code, joined := accept(token, invitationToken)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, org.ID, joined.ID)
+		assert.Equal(t, models.RoleEditor, joined.Role)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		// an invitation can be used once
+		code, _ = accept(token, invitationToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("OnlyInvitedEmailCanAccept", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner2@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Invite Organization", "Test organization description")
+		_, otherToken := helpers.CreateTestUser("invite-other@example.com", "Other", TestPassword)
+
+		invite(ownerToken, org.ID, "invite-someone@example.com", models.RoleViewer)
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("invite-someone@example.com"))
+
+		code, _ := accept(otherToken, invitationToken)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("ListAndRevoke", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner3@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Invite Organization", "Test organization description")
+
+		invite(ownerToken, org.ID, "invite-revoked@example.com", models.RoleViewer)
+		// inviting again replaces the pending invitation
+		invitation := invite(ownerToken, org.ID, "invite-revoked@example.com", models.RoleAdmin)
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("invite-revoked@example.com"))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/invitations", org.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var invitations []models.Invitation
+		helpers.AssertJSONResponse(resp, &invitations)
+		assert.Len(t, invitations, 1)
+		assert.Equal(t, models.RoleAdmin, invitations[0].Role)
+
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", fmt.Sprintf("/v1/orgs/%s/invitations/%s", org.ID, invitation.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		_, token := helpers.CreateTestUser("invite-revoked@example.com", "Revoked", TestPassword)
+		code, _ := accept(token, invitationToken)
+		assert.Equal(t, http.StatusBadRequest, code, "Revoked invitation should not be accepted")
+
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", fmt.Sprintf("/v1/orgs/%s/invitations/%s", org.ID, invitation.ID), nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner4@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Invite Organization", "Test organization description")
+
+		invite(ownerToken, org.ID, "invite-decline@example.com", models.RoleViewer)
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("invite-decline@example.com"))
+
+		resp, err := helpers.MakeRequest("POST", "/v1/invitations/decline", map[string]interface{}{
+			"token": invitationToken,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		_, token := helpers.CreateTestUser("invite-decline@example.com", "Decliner", TestPassword)
+		code, _ := accept(token, invitationToken)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Permissions", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner5@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Invite Organization", "Test organization description")
+
+		admin, adminToken := helpers.CreateTestUser("invite-admin@example.com", "Admin", TestPassword)
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+		viewer, viewerToken := helpers.CreateTestUser("invite-viewer@example.com", "Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+
+		request := func(token, email string, role models.Role) int {
+			resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/invitations", org.ID), map[string]interface{}{
+				"email": email,
+				"role":  role,
+			}, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			return resp.Code
+		}
+
+		assert.Equal(t, http.StatusForbidden, request(viewerToken, "invite-x@example.com", models.RoleViewer), "Viewers can't invite")
+		assert.Equal(t, http.StatusForbidden, request(adminToken, "invite-x@example.com", models.RoleOwner), "Admins can't invite owners")
+		assert.Equal(t, http.StatusCreated, request(adminToken, "invite-x@example.com", models.RoleEditor))
+		assert.Equal(t, http.StatusConflict, request(ownerToken, "INVITE-VIEWER@example.com", models.RoleEditor), "Members can't be invited")
+		assert.Equal(t, http.StatusBadRequest, request(ownerToken, "invite-y@example.com", "superuser"))
+	})
+
+	t.Run("OrganizationNameStaysInSubject", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("invite-owner5@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Header Organization", "Test organization description")
+
+		// names from before line breaks were rejected
+		name := "Header Organization\r\nBcc: attacker@example.com"
+		GetTestDB().Model(&models.Organization{}).Where("id = ?", org.ID).Update("name", name)
+
+		invite(ownerToken, org.ID, "invite-header@example.com", models.RoleViewer)
+		msg := helpers.LatestEmailTo("invite-header@example.com")
+		assert.Equal(t, fmt.Sprintf("You have been invited to %s on Konnect", name), msg.Subject, "The name should not add headers")
+	})
+}
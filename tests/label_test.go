@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestLabels tests labelling services and versions and filtering them with label selectors
+func TestLabels(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("labels@example.com", "Labels", TestPassword)
+	org := helpers.CreateTestOrganization(token, "Labels Org", "Test organization description")
+
+	createService := func(name string, labels map[string]string) models.Service {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":   name,
+			"labels": labels,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var service models.Service
+		helpers.AssertJSONResponse(resp, &service)
+		return service
+	}
+
+	selectServices := func(selector string) []string {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?sort_by=name&sort=asc&labelSelector=%s", org.ID, url.QueryEscape(selector)), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Service]
+		helpers.AssertJSONResponse(resp, &result)
+		names := make([]string, 0, len(result.Data))
+		for _, service := range result.Data {
+			names = append(names, service.Name)
+		}
+		return names
+	}
+
+	payments := createService("Payments", map[string]string{"env": "prod", "example.com/team": "billing"})
+	createService("Search", map[string]string{"env": "staging", "tier": "backend"})
+	createService("Legacy", map[string]string{"env": "dev", "deprecated": ""})
+	createService("Unlabelled", nil)
+
+	t.Run("ServiceSelectors", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"env": "prod", "example.com/team": "billing"}, payments.Labels)
+
+		assert.Equal(t, []string{"Payments"}, selectServices("env=prod"))
+		assert.Equal(t, []string{"Payments"}, selectServices("env==prod,example.com/team=billing"))
+		assert.Equal(t, []string{"Legacy", "Search", "Unlabelled"}, selectServices("env!=prod"))
+		assert.Equal(t, []string{"Payments", "Search"}, selectServices("env in (prod, staging)"))
+		assert.Equal(t, []string{"Legacy", "Unlabelled"}, selectServices("env notin (prod,staging)"))
+		assert.Equal(t, []string{"Legacy", "Payments", "Search"}, selectServices("env"))
+		assert.Equal(t, []string{"Payments", "Search", "Unlabelled"}, selectServices("!deprecated"))
+		assert.Equal(t, []string{"Legacy", "Payments", "Search", "Unlabelled"}, selectServices(""))
+	})
+
+	t.Run("InvalidLabels", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":   "Invalid",
+			"labels": map[string]string{"-env": "prod"},
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+
+		for _, selector := range []string{"env in (prod", "env=prod value", "Example.com/team=billing"} {
+			resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?labelSelector=%s", org.ID, url.QueryEscape(selector)), nil, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("UpdateLabels", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, payments.ID), map[string]interface{}{
+			"labels": map[string]string{"env": "staging"},
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var updated models.Service
+		helpers.AssertJSONResponse(resp, &updated)
+		assert.Equal(t, map[string]string{"env": "staging"}, updated.Labels)
+		assert.Equal(t, []string{"Payments", "Search"}, selectServices("env=staging"))
+
+		// other fields leave the labels unchanged
+		resp, err = helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, payments.ID), map[string]interface{}{
+			"name": "Payments",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		helpers.AssertJSONResponse(resp, &updated)
+		assert.Equal(t, map[string]string{"env": "staging"}, updated.Labels)
+	})
+
+	t.Run("VersionSelectors", func(t *testing.T) {
+		service := helpers.CreateTestService(token, org.ID, "Versioned", "Test service description")
+		for version, channel := range map[string]string{"1.0.0": "stable", "1.1.0-beta": "beta", "2.0.0": "stable"} {
+			resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+				"name":    "Release " + version,
+				"version": version,
+				"labels":  map[string]string{"channel": channel},
+			}, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusOK)
+		}
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions?sort_by=version&sort=asc&labelSelector=%s", org.ID, service.ID, url.QueryEscape("channel=stable")), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.ServiceVersion]
+		helpers.AssertJSONResponse(resp, &result)
+		if assert.Len(t, result.Data, 2) {
+			assert.Equal(t, "1.0.0", result.Data[0].Version)
+			assert.Equal(t, "2.0.0", result.Data[1].Version)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestServiceLifecycle tests the lifecycle transitions of services and the restrictions of retired services
+func TestServiceLifecycle(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("lifecycle@example.com", "Lifecycle", TestPassword)
+	org := helpers.CreateTestOrganization(token, "Lifecycle Org", "Test organization description")
+
+	updateService := func(serviceID string, body map[string]interface{}) (int, models.Service) {
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, serviceID), body, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+
+		var service models.Service
+		if resp.Code == http.StatusOK {
+			helpers.AssertJSONResponse(resp, &service)
+		}
+		return resp.Code, service
+	}
+
+	t.Run("Transitions", func(t *testing.T) {
+		service := helpers.CreateTestService(token, org.ID, "Checkout", "Test service description")
+		assert.Equal(t, models.LifecycleActive, service.Lifecycle)
+
+		// active services can't go back to experimental
+		code, _ := updateService(service.ID, map[string]interface{}{"lifecycle": "experimental"})
+		assert.Equal(t, http.StatusConflict, code)
+
+		sunsetAt := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+		code, updated := updateService(service.ID, map[string]interface{}{
+			"lifecycle":       "deprecated",
+			"lifecycleReason": "Replaced by Checkout v2",
+			"sunsetAt":        sunsetAt,
+		})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.LifecycleDeprecated, updated.Lifecycle)
+		assert.Equal(t, "Replaced by Checkout v2", updated.LifecycleReason)
+		if assert.NotNil(t, updated.SunsetAt) {
+			assert.True(t, sunsetAt.Equal(*updated.SunsetAt))
+		}
+
+		// taking back the deprecation clears the sunset date
+		code, updated = updateService(service.ID, map[string]interface{}{"lifecycle": "active"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Nil(t, updated.SunsetAt)
+		assert.Empty(t, updated.LifecycleReason)
+
+		// only deprecated services have a sunset date
+		code, _ = updateService(service.ID, map[string]interface{}{"sunsetAt": sunsetAt})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = updateService(service.ID, map[string]interface{}{"lifecycle": "retired"})
+		assert.Equal(t, http.StatusConflict, code)
+
+		code, _ = updateService(service.ID, map[string]interface{}{"lifecycle": "deprecated"})
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = updateService(service.ID, map[string]interface{}{"lifecycle": "retired", "lifecycleReason": "Shut down"})
+		assert.Equal(t, http.StatusOK, code)
+
+		// retired is final
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), map[string]interface{}{
+			"lifecycle": "active",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+
+		var errorResponse models.ErrorResponse
+		helpers.AssertJSONResponse(resp, &errorResponse)
+		assert.Equal(t, "invalid_lifecycle_transition", errorResponse.Type)
+	})
+
+	t.Run("RetiredServicesAreReadOnlyForVersions", func(t *testing.T) {
+		service := helpers.CreateTestService(token, org.ID, "Legacy Search", "Test service description")
+		helpers.CreateTestServiceVersion(token, org.ID, service.ID, "Release 1", "1.0.0", "Test version description")
+
+		code, _ := updateService(service.ID, map[string]interface{}{"lifecycle": "deprecated"})
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = updateService(service.ID, map[string]interface{}{"lifecycle": "retired"})
+		assert.Equal(t, http.StatusOK, code)
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":    "Release 2",
+			"version": "2.0.0",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+
+		// existing versions can still be read
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+	})
+
+	t.Run("FilterByLifecycle", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":      "Recommendations",
+			"lifecycle": "experimental",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?lifecycle=experimental,retired&sort_by=name&sort=asc", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Service]
+		helpers.AssertJSONResponse(resp, &result)
+		names := make([]string, 0, len(result.Data))
+		for _, service := range result.Data {
+			names = append(names, service.Name)
+		}
+		assert.Equal(t, []string{"Checkout", "Legacy Search", "Recommendations"}, names)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?lifecycle=sunset", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+
+		// new services can't start deprecated or retired
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":      "Old Service",
+			"lifecycle": "retired",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+	})
+}
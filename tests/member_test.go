@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestMembers tests the /v1/orgs/:orgId/members endpoints
+func TestMembers(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	request := func(method, path string, body interface{}, token string) int {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, body, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	listMembers := func(token, orgID, query string) models.PaginatedResult[models.Member] {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/members%s", orgID, query), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Member]
+		helpers.AssertJSONResponse(resp, &result)
+		return result
+	}
+
+	t.Run("List", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("members-owner@example.com", "Alice Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		viewer, viewerToken := helpers.CreateTestUser("members-viewer@example.com", "Bob Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+
+		result := listMembers(viewerToken, org.ID, "?sort_by=role&sort=asc")
+		assert.Equal(t, 2, result.Meta.TotalCount)
+		assert.Equal(t, models.RoleOwner, result.Data[0].Role, "Owners should be ranked first")
+		assert.Equal(t, "Alice Owner", result.Data[0].Name)
+		assert.Equal(t, "members-viewer@example.com", result.Data[1].Email)
+
+		result = listMembers(ownerToken, org.ID, "?q=bob")
+		assert.Equal(t, 1, result.Meta.TotalCount)
+		assert.Equal(t, viewer.ID, result.Data[0].UserID)
+
+		result = listMembers(ownerToken, org.ID, "?per_page=1&page=1&sort_by=name&sort=asc")
+		assert.Len(t, result.Data, 1)
+		assert.Equal(t, "Bob Viewer", result.Data[0].Name)
+	})
+
+	t.Run("ChangeRole", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("members-owner2@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		admin, adminToken := helpers.CreateTestUser("members-admin2@example.com", "Admin", TestPassword)
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+		viewer, viewerToken := helpers.CreateTestUser("members-viewer2@example.com", "Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+
+		memberPath := fmt.Sprintf("/v1/orgs/%s/members/%s", org.ID, viewer.ID)
+
+		assert.Equal(t, http.StatusForbidden, request("PATCH", memberPath, map[string]interface{}{"role": "editor"}, viewerToken), "Viewers can't change roles")
+		assert.Equal(t, http.StatusForbidden, request("PATCH", memberPath, map[string]interface{}{"role": "owner"}, adminToken), "Admins can't make owners")
+
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", memberPath, map[string]interface{}{"role": "editor"}, adminToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var member models.Member
+		helpers.AssertJSONResponse(resp, &member)
+		assert.Equal(t, models.RoleEditor, member.Role)
+
+		// the new role applies right away
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":        "Editor Service",
+			"description": "Test service description",
+		}, viewerToken))
+
+		assert.Equal(t, http.StatusNotFound, request("PATCH", fmt.Sprintf("/v1/orgs/%s/members/%s", org.ID, "00000000-0000-0000-0000-000000000000"), map[string]interface{}{"role": "editor"}, ownerToken))
+		assert.Equal(t, http.StatusBadRequest, request("PATCH", memberPath, map[string]interface{}{"role": "superuser"}, ownerToken))
+	})
+
+	t.Run("LastOwnerIsProtected", func(t *testing.T) {
+		owner, ownerToken := helpers.CreateTestUser("members-owner3@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		admin, adminToken := helpers.CreateTestUser("members-admin3@example.com", "Admin", TestPassword)
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+
+		ownerPath := fmt.Sprintf("/v1/orgs/%s/members/%s", org.ID, owner.ID)
+
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", ownerPath, map[string]interface{}{"role": "admin"}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+		helpers.AssertErrorResponse(resp, "The organization needs at least one owner, make another member owner first")
+
+		assert.Equal(t, http.StatusConflict, request("DELETE", ownerPath, nil, ownerToken))
+		assert.Equal(t, http.StatusConflict, request("POST", fmt.Sprintf("/v1/orgs/%s/leave", org.ID), nil, ownerToken))
+		assert.Equal(t, http.StatusForbidden, request("DELETE", ownerPath, nil, adminToken), "Admins can't remove owners")
+
+		// with a second owner the first one can step down
+		assert.Equal(t, http.StatusOK, request("PATCH", fmt.Sprintf("/v1/orgs/%s/members/%s", org.ID, admin.ID), map[string]interface{}{"role": "owner"}, ownerToken))
+		assert.Equal(t, http.StatusOK, request("PATCH", ownerPath, map[string]interface{}{"role": "viewer"}, ownerToken))
+	})
+
+	t.Run("RemoveAndLeave", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("members-owner4@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		editor, editorToken := helpers.CreateTestUser("members-editor4@example.com", "Editor", TestPassword)
+		helpers.AddTestMember(org.ID, editor.ID, models.RoleEditor)
+		viewer, viewerToken := helpers.CreateTestUser("members-viewer4@example.com", "Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+
+		orgPath := fmt.Sprintf("/v1/orgs/%s", org.ID)
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("%s/members/%s", orgPath, editor.ID), nil, ownerToken))
+		assert.Equal(t, http.StatusForbidden, request("GET", orgPath, nil, editorToken), "Removed member should lose access right away")
+
+		assert.Equal(t, http.StatusNoContent, request("POST", orgPath+"/leave", nil, viewerToken))
+		assert.Equal(t, http.StatusForbidden, request("GET", orgPath, nil, viewerToken))
+
+		assert.Equal(t, 1, listMembers(ownerToken, org.ID, "").Meta.TotalCount)
+
+		// a removed member can be invited back
+		assert.Equal(t, http.StatusCreated, request("POST", orgPath+"/invitations", map[string]interface{}{
+			"email": "members-editor4@example.com",
+			"role":  "viewer",
+		}, ownerToken))
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("members-editor4@example.com"))
+		assert.Equal(t, http.StatusOK, request("POST", "/v1/invitations/accept", map[string]interface{}{"token": invitationToken}, editorToken))
+		assert.Equal(t, http.StatusOK, request("GET", orgPath, nil, editorToken))
+	})
+
+	t.Run("TransferOwnership", func(t *testing.T) {
+		owner, ownerToken := helpers.CreateTestUser("members-owner5@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		admin, adminToken := helpers.CreateTestUser("members-admin5@example.com", "Admin", TestPassword)
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+
+		transferPath := fmt.Sprintf("/v1/orgs/%s/transfer", org.ID)
+
+		assert.Equal(t, http.StatusForbidden, request("POST", transferPath, map[string]interface{}{"userId": admin.ID}, adminToken), "Only owners can transfer")
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", transferPath, map[string]interface{}{"userId": admin.ID}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var transferred models.Organization
+		helpers.AssertJSONResponse(resp, &transferred)
+		assert.Equal(t, admin.ID, transferred.CreatedBy)
+
+		roles := map[string]models.Role{}
+		for _, member := range listMembers(adminToken, org.ID, "").Data {
+			roles[member.UserID] = member.Role
+		}
+		assert.Equal(t, models.RoleOwner, roles[admin.ID])
+		assert.Equal(t, models.RoleAdmin, roles[owner.ID], "Previous owner should stay as an admin")
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("/v1/orgs/%s", org.ID), nil, adminToken), "New owner can delete the organization")
+	})
+
+	t.Run("TimeBoundMembership", func(t *testing.T) {
+		owner, ownerToken := helpers.CreateTestUser("members-owner6@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		contractor, contractorToken := helpers.CreateTestUser("members-contractor6@example.com", "Contractor", TestPassword)
+
+		orgPath := fmt.Sprintf("/v1/orgs/%s", org.ID)
+		contractorPath := fmt.Sprintf("%s/members/%s", orgPath, contractor.ID)
+
+		assert.Equal(t, http.StatusBadRequest, request("POST", orgPath+"/invitations", map[string]interface{}{
+			"email":          "members-owner7@example.com",
+			"role":           "owner",
+			"membershipDays": 3,
+		}, ownerToken), "Owners can't be invited for a limited time")
+
+		assert.Equal(t, http.StatusCreated, request("POST", orgPath+"/invitations", map[string]interface{}{
+			"email":          "members-contractor6@example.com",
+			"role":           "editor",
+			"membershipDays": 3,
+		}, ownerToken))
+		invitationToken := helpers.ExtractEmailToken(helpers.LatestEmailTo("members-contractor6@example.com"))
+		assert.Equal(t, http.StatusOK, request("POST", "/v1/invitations/accept", map[string]interface{}{"token": invitationToken}, contractorToken))
+
+		listOrganizations := func() models.Organization {
+			resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, contractorToken)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusOK)
+
+			var result models.PaginatedResult[models.Organization]
+			helpers.AssertJSONResponse(resp, &result)
+			if !assert.Len(t, result.Data, 1) {
+				t.FailNow()
+			}
+			return *result.Data[0]
+		}
+
+		organization := listOrganizations()
+		if assert.NotNil(t, organization.MembershipExpiresAt) {
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, 3), *organization.MembershipExpiresAt, time.Minute)
+		}
+		assert.True(t, organization.ExpiringSoon)
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", contractorPath+"/extend", map[string]interface{}{"days": 30}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var member models.Member
+		helpers.AssertJSONResponse(resp, &member)
+		if assert.NotNil(t, member.ExpiresAt) {
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, 33), *member.ExpiresAt, time.Minute, "Extension should start from the current end")
+		}
+		assert.False(t, listOrganizations().ExpiringSoon)
+
+		assert.Equal(t, http.StatusForbidden, request("POST", fmt.Sprintf("%s/members/%s/extend", orgPath, owner.ID), map[string]interface{}{"days": 30}, ownerToken), "Members can't extend their own membership")
+		assert.Equal(t, http.StatusForbidden, request("POST", contractorPath+"/extend", map[string]interface{}{"days": 30}, contractorToken))
+
+		// an expired membership is treated as absent until it is removed
+		testDB := GetTestDB()
+		testDB.Model(&models.UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", org.ID, contractor.ID).
+			Update("expires_at", time.Now().Add(-time.Minute))
+		models.PurgeAuthCaches()
+
+		assert.Equal(t, http.StatusForbidden, request("GET", orgPath, nil, contractorToken))
+		assert.Equal(t, 1, listMembers(ownerToken, org.ID, "").Meta.TotalCount)
+
+		if err := (models.MemberModel{}).CleanupExpired(context.Background()); err != nil {
+			t.Fatalf("Failed to cleanup expired memberships: %v", err)
+		}
+
+		var removed models.UserOrganizationMap
+		testDB.Unscoped().Where("organization_id = ? AND user_id = ?", org.ID, contractor.ID).First(&removed)
+		assert.True(t, removed.DeletedAt.Valid)
+		assert.Equal(t, models.MembershipExpired, removed.RemovedReason)
+	})
+
+	t.Run("MakingMembershipPermanentRequiresHigherRole", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("members-owner8@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Members Organization", "Test organization description")
+		contractor, contractorToken := helpers.CreateTestUser("members-contractor8@example.com", "Contractor", TestPassword)
+		admin, adminToken := helpers.CreateTestUser("members-admin8@example.com", "Admin", TestPassword)
+		helpers.AddTestMember(org.ID, contractor.ID, models.RoleAdmin)
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+
+		GetTestDB().Model(&models.UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", org.ID, contractor.ID).
+			Update("expires_at", time.Now().AddDate(0, 0, 3))
+		models.PurgeAuthCaches()
+
+		contractorPath := fmt.Sprintf("/v1/orgs/%s/members/%s/extend", org.ID, contractor.ID)
+		assert.Equal(t, http.StatusForbidden, request("POST", contractorPath, map[string]interface{}{}, contractorToken), "Admins can't make their own membership permanent")
+		assert.Equal(t, http.StatusForbidden, request("POST", contractorPath, map[string]interface{}{"days": 30}, contractorToken), "Admins can't extend their own membership")
+		assert.Equal(t, http.StatusForbidden, request("POST", contractorPath, map[string]interface{}{}, adminToken), "Admins can't make the membership of another admin permanent")
+		assert.Equal(t, http.StatusOK, request("POST", contractorPath, map[string]interface{}{"days": 30}, adminToken), "Admins can extend the membership of another admin")
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", contractorPath, map[string]interface{}{}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var member models.Member
+		helpers.AssertJSONResponse(resp, &member)
+		assert.Nil(t, member.ExpiresAt, "Owners can make a membership permanent")
+	})
+}
@@ -0,0 +1,376 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/oidc"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+const (
+	testOIDCProvider     = "corp"
+	testOIDCClientID     = "konnect-test"
+	testOIDCClientSecret = "konnect-test-secret"
+)
+
+// fakeIdentity is the user signing in at the stand-in identity provider
+type fakeIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// fakeAuthorization is an authorization code issued by the stand-in identity provider
+type fakeAuthorization struct {
+	identity      fakeIdentity
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// fakeIdP is a minimal OpenID Connect provider supporting discovery, JWKS and the authorization code flow with PKCE
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]fakeAuthorization
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	idp := &fakeIdP{t: t, key: key, codes: map[string]fakeAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "idp-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize plays the part of the user signing in at the provider and returns the authorization code
+// the provider would redirect back with
+func (idp *fakeIdP) authorize(authURL *url.URL, identity fakeIdentity) string {
+	query := authURL.Query()
+	assert.Equal(idp.t, "code", query.Get("response_type"))
+	assert.Equal(idp.t, testOIDCClientID, query.Get("client_id"))
+	assert.Equal(idp.t, "S256", query.Get("code_challenge_method"))
+
+	code, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		idp.t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.codes[code] = fakeAuthorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	return code
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	tokenError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testOIDCClientID || clientSecret != testOIDCClientSecret {
+		tokenError("invalid_client")
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != authorization.redirectURI {
+		tokenError("invalid_grant")
+		return
+	}
+	if oidc.CodeChallenge(r.PostFormValue("code_verifier")) != authorization.codeChallenge {
+		tokenError("invalid_grant")
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testOIDCClientID,
+		"sub":            authorization.identity.Subject,
+		"email":          authorization.identity.Email,
+		"email_verified": authorization.identity.EmailVerified,
+		"name":           authorization.identity.Name,
+		"nonce":          authorization.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = "idp-key"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Fatalf("Failed to sign id token: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+// useOIDCProvider configures the stand-in identity provider and removes it after the test
+func useOIDCProvider(t *testing.T, idp *fakeIdP) {
+	t.Setenv("OIDC_PROVIDERS", testOIDCProvider)
+	t.Setenv("OIDC_CORP_ISSUER", idp.server.URL)
+	t.Setenv("OIDC_CORP_CLIENT_ID", testOIDCClientID)
+	t.Setenv("OIDC_CORP_CLIENT_SECRET", testOIDCClientSecret)
+	if err := oidc.Init(); err != nil {
+		t.Fatalf("Failed to load OIDC providers: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("OIDC_PROVIDERS")
+		oidc.Init()
+	})
+}
+
+// TestOIDCLogin tests GET /v1/auth/oidc/{provider}/start and /v1/auth/oidc/{provider}/callback
+func TestOIDCLogin(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	idp := newFakeIdP(t)
+	useOIDCProvider(t, idp)
+
+	// start begins a login and returns the URL the user is redirected to
+	start := func() *url.URL {
+		resp, err := helpers.MakeRequest("GET", "/v1/auth/oidc/corp/start", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusFound)
+
+		authURL, err := url.Parse(resp.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect: %v", err)
+		}
+		assert.Equal(t, idp.server.URL+"/authorize", fmt.Sprintf("%s://%s%s", authURL.Scheme, authURL.Host, authURL.Path))
+		return authURL
+	}
+
+	callback := func(code, state string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("GET", "/v1/auth/oidc/corp/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	// login signs in at the provider as the identity and completes the callback
+	login := func(identity fakeIdentity) *httptest.ResponseRecorder {
+		authURL := start()
+		code := idp.authorize(authURL, identity)
+		return callback(code, authURL.Query().Get("state"))
+	}
+
+	me := func(resp *httptest.ResponseRecorder) string {
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		claims, err := utils.ValidateToken(tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("Invalid access token: %v", err)
+		}
+		return claims.UserID
+	}
+
+	t.Run("ProvisionsNewUser", func(t *testing.T) {
+		identity := fakeIdentity{Subject: "sub-new", Email: "sso-new@example.com", EmailVerified: true, Name: "SSO User"}
+
+		userID := me(login(identity))
+
+		var user models.User
+		if err := testDB.Where("id = ?", userID).First(&user).Error; err != nil {
+			t.Fatalf("Provisioned user not found: %v", err)
+		}
+		assert.Equal(t, "sso-new@example.com", user.Email)
+		assert.Equal(t, "SSO User", user.Name)
+		assert.True(t, user.IsVerified(), "Provisioned user should be verified")
+
+		// signing in again uses the linked identity
+		assert.Equal(t, userID, me(login(identity)))
+	})
+
+	t.Run("LinksExistingAccount", func(t *testing.T) {
+		user, _ := helpers.CreateTestUser("sso-existing@example.com", "Existing User", TestPassword)
+
+		userID := me(login(fakeIdentity{Subject: "sub-existing", Email: "sso-existing@example.com", EmailVerified: true}))
+		assert.Equal(t, user.ID, userID)
+
+		// the identity stays linked when the email changes at the provider
+		userID = me(login(fakeIdentity{Subject: "sub-existing", Email: "renamed@example.com", EmailVerified: true}))
+		assert.Equal(t, user.ID, userID)
+	})
+
+	t.Run("PreRegisteredAccountLockedOut", func(t *testing.T) {
+		// someone registers the email of the victim and keeps a session open within the grace period
+		t.Setenv("UNVERIFIED_LOGIN_GRACE_HOURS", "24")
+		resp, err := helpers.MakeRequest("POST", "/v1/users/register", map[string]interface{}{
+			"email":    "sso-hijack@example.com",
+			"name":     "Attacker",
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+
+		passwordLogin := func() *httptest.ResponseRecorder {
+			resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+				"email":    "sso-hijack@example.com",
+				"password": TestPassword,
+			})
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			return resp
+		}
+		resp = passwordLogin()
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+
+		// the owner of the email signs in with the provider
+		me(login(fakeIdentity{Subject: "sub-hijack", Email: "sso-hijack@example.com", EmailVerified: true}))
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertStatusCode(passwordLogin(), http.StatusUnauthorized)
+	})
+
+	t.Run("UnverifiedEmailNotLinked", func(t *testing.T) {
+		helpers.CreateTestUser("sso-victim@example.com", "Victim", TestPassword)
+
+		resp := login(fakeIdentity{Subject: "sub-attacker", Email: "sso-victim@example.com", EmailVerified: false})
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Your email is not verified by the identity provider")
+	})
+
+	t.Run("StateIsSingleUse", func(t *testing.T) {
+		authURL := start()
+		state := authURL.Query().Get("state")
+		identity := fakeIdentity{Subject: "sub-replay", Email: "sso-replay@example.com", EmailVerified: true}
+
+		helpers.AssertStatusCode(callback(idp.authorize(authURL, identity), state), http.StatusOK)
+
+		resp := callback(idp.authorize(authURL, identity), state)
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid or expired login, please try again")
+	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+		authURL := start()
+
+		resp := callback("not-a-code", authURL.Query().Get("state"))
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+	})
+
+	t.Run("ProviderError", func(t *testing.T) {
+		resp, err := helpers.MakeRequest("GET", "/v1/auth/oidc/corp/callback?error=access_denied", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+	})
+
+	t.Run("UnknownProvider", func(t *testing.T) {
+		resp, err := helpers.MakeRequest("GET", "/v1/auth/oidc/unknown/start", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+}
+
+// TestOIDCProvidersFile tests loading providers from OIDC_PROVIDERS_FILE
+func TestOIDCProvidersFile(t *testing.T) {
+	file := t.TempDir() + "/providers.json"
+	data := `[{"name": "file-idp", "issuer": "https://idp.example.com/", "clientId": "konnect"}]`
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write providers file: %v", err)
+	}
+
+	t.Setenv("OIDC_PROVIDERS_FILE", file)
+	t.Setenv("APP_BASE_URL", "https://konnect.example.com")
+	if err := oidc.Init(); err != nil {
+		t.Fatalf("Failed to load OIDC providers: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("OIDC_PROVIDERS_FILE")
+		oidc.Init()
+	})
+
+	provider, ok := oidc.Get("file-idp")
+	if !ok {
+		t.Fatalf("Provider from file not loaded")
+	}
+	assert.Equal(t, "https://idp.example.com", provider.Issuer)
+	assert.Equal(t, []string{"openid", "email", "profile"}, provider.Scopes)
+	assert.Equal(t, "https://konnect.example.com/v1/auth/oidc/file-idp/callback", provider.RedirectURL)
+}
@@ -69,6 +69,11 @@ func TestCreateOrganization(t *testing.T) {
 				payload:      map[string]interface{}{"name": "AB", "description": "Valid description"},
 				expectedCode: http.StatusBadRequest,
 			},
+			{
+				name:         "Name with line breaks",
+				payload:      map[string]interface{}{"name": "Acme\r\nBcc: attacker@example.com", "description": "Valid description"},
+				expectedCode: http.StatusBadRequest,
+			},
 			{
 				name:         "Empty request body",
 				payload:      map[string]interface{}{},
@@ -151,6 +156,7 @@ func TestGetOrganizations(t *testing.T) {
 		assert.Len(t, result.Data, 1, "Expected 1 organization in data")
 		assert.Equal(t, 1, result.Meta.TotalCount, "Expected total count to be 1")
 		assert.Equal(t, "Test Organization", result.Data[0].Name, "Organization name should match")
+		assert.Equal(t, models.RoleOwner, result.Data[0].Role, "Creator should be the owner")
 	})
 
 	t.Run("WithQueryParameters", func(t *testing.T) {
@@ -362,6 +368,10 @@ func TestUpdateOrganization(t *testing.T) {
 				name:    "Invalid name",
 				payload: map[string]interface{}{"name": "AB", "description": "Valid description"},
 			},
+			{
+				name:    "Name with line breaks",
+				payload: map[string]interface{}{"name": "Acme\nBcc: attacker@example.com", "description": "Valid description"},
+			},
 		}
 
 		for _, tc := range testCases {
@@ -421,4 +431,77 @@ func TestDeleteOrganization(t *testing.T) {
 
 		helpers.AssertStatusCode(resp, http.StatusForbidden)
 	})
-}
\ No newline at end of file
+}
+
+// TestOrganizationRoles tests that the role of a member decides what the member can do in the organization
+func TestOrganizationRoles(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, ownerToken := helpers.CreateTestUser("roles-owner@example.com", "Owner", TestPassword)
+	org := helpers.CreateTestOrganization(ownerToken, "Roles Organization", "Test organization description")
+	service := helpers.CreateTestService(ownerToken, org.ID, "Roles Service", "Test service description")
+
+	tokens := map[models.Role]string{models.RoleOwner: ownerToken}
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleEditor, models.RoleViewer} {
+		user, token := helpers.CreateTestUser(fmt.Sprintf("roles-%s@example.com", role), "Member", TestPassword)
+		helpers.AddTestMember(org.ID, user.ID, role)
+		tokens[role] = token
+	}
+
+	request := func(role models.Role, method, path string, body interface{}) int {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, body, tokens[role])
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	orgPath := fmt.Sprintf("/v1/orgs/%s", org.ID)
+	servicePath := fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID)
+
+	t.Run("EveryRoleCanRead", func(t *testing.T) {
+		for role := range tokens {
+			assert.Equal(t, http.StatusOK, request(role, "GET", orgPath, nil), "%s should read the organization", role)
+			assert.Equal(t, http.StatusOK, request(role, "GET", servicePath, nil), "%s should read services", role)
+			assert.Equal(t, http.StatusOK, request(role, "GET", servicePath+"/versions", nil), "%s should read versions", role)
+		}
+	})
+
+	t.Run("ViewerCannotWrite", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":        "Viewer Service",
+			"description": "Test service description",
+		}, tokens[models.RoleViewer])
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "The viewer role is not allowed to perform the request")
+
+		assert.Equal(t, http.StatusForbidden, request(models.RoleViewer, "PATCH", servicePath, map[string]interface{}{"name": "Renamed"}))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleViewer, "DELETE", servicePath, nil))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleViewer, "PUT", orgPath, map[string]interface{}{"name": "Renamed"}))
+	})
+
+	t.Run("EditorCanChangeServicesButNotTheOrganization", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(models.RoleEditor, "PATCH", servicePath, map[string]interface{}{"name": "Renamed by editor"}))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleEditor, "PUT", orgPath, map[string]interface{}{"name": "Renamed"}))
+	})
+
+	t.Run("AdminCanUpdateButNotDelete", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(models.RoleAdmin, "PUT", orgPath, map[string]interface{}{"name": "Renamed by admin", "description": "Test organization description"}))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleAdmin, "DELETE", orgPath, nil))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleEditor, "DELETE", orgPath, nil))
+		assert.Equal(t, http.StatusForbidden, request(models.RoleViewer, "DELETE", orgPath, nil))
+	})
+
+	t.Run("OnlyOwnerCanDelete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, request(models.RoleOwner, "DELETE", orgPath, nil))
+	})
+}
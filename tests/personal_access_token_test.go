@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// TestCreatePersonalAccessToken tests POST /v1/users/me/tokens endpoint
+func TestCreatePersonalAccessToken(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("pat-create@example.com", "Test User", TestPassword)
+	org := helpers.CreateTestOrganization(token, "Test Organization", "Test org description")
+
+	t.Run("Success", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/tokens", map[string]interface{}{
+			"name":            "CI",
+			"organizationIds": []string{org.ID},
+			"scopes":          []string{"services:read", "versions:write"},
+			"expiresInDays":   30,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusCreated)
+
+		var created models.CreatedPersonalAccessTokenResponse
+		helpers.AssertJSONResponse(resp, &created)
+		assert.True(t, strings.HasPrefix(created.Token, models.PersonalAccessTokenPrefix))
+		assert.Equal(t, "CI", created.Name)
+		assert.Equal(t, []string{org.ID}, created.OrganizationIDs)
+		assert.Equal(t, []string{"services:read", "versions:write"}, created.Scopes)
+		assert.Nil(t, created.LastUsedAt)
+	})
+
+	t.Run("NotAMemberOfOrganization", func(t *testing.T) {
+		_, otherToken := helpers.CreateTestUser("pat-other@example.com", "Other User", TestPassword)
+		otherOrg := helpers.CreateTestOrganization(otherToken, "Other Organization", "Other org description")
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/tokens", map[string]interface{}{
+			"name":            "CI",
+			"organizationIds": []string{org.ID, otherOrg.ID},
+			"scopes":          []string{"services:read"},
+			"expiresInDays":   30,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+	})
+
+	t.Run("ValidationErrors", func(t *testing.T) {
+		testCases := []struct {
+			name            string
+			payload         map[string]interface{}
+			expectedMessage string
+		}{
+			{
+				name:            "Missing organizations",
+				payload:         map[string]interface{}{"name": "CI", "scopes": []string{"services:read"}, "expiresInDays": 30},
+				expectedMessage: "Please provide at least one organization",
+			},
+			{
+				name:            "Unknown scope",
+				payload:         map[string]interface{}{"name": "CI", "organizationIds": []string{org.ID}, "scopes": []string{"services:delete"}, "expiresInDays": 30},
+				expectedMessage: "Scopes should be one of orgs:read, orgs:write, services:read, services:write, versions:read, versions:write",
+			},
+			{
+				name:            "Expiry too long",
+				payload:         map[string]interface{}{"name": "CI", "organizationIds": []string{org.ID}, "scopes": []string{"services:read"}, "expiresInDays": 1000},
+				expectedMessage: "Expiry should be between 1 to 365 days",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/tokens", tc.payload, token)
+				if err != nil {
+					t.Fatalf("Failed to make request: %v", err)
+				}
+				helpers.AssertStatusCode(resp, http.StatusBadRequest)
+				helpers.AssertErrorResponse(resp, tc.expectedMessage)
+			})
+		}
+	})
+}
+
+// TestPersonalAccessTokenAccess tests the organization and scope limits of personal access tokens
+func TestPersonalAccessTokenAccess(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("pat-access@example.com", "Test User", TestPassword)
+	org := helpers.CreateTestOrganization(token, "Test Organization", "Test org description")
+	otherOrg := helpers.CreateTestOrganization(token, "Other Organization", "Other org description")
+	service := helpers.CreateTestService(token, org.ID, "Test Service", "Test service description")
+
+	pat := helpers.CreateTestPersonalAccessToken(token, []string{org.ID}, []string{"services:read", "versions:write"})
+
+	request := func(method, path string, body interface{}) int {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, body, pat)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	t.Run("GrantedScope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("GET", fmt.Sprintf("/v1/orgs/%s/services", org.ID), nil))
+		assert.Equal(t, http.StatusOK, request("GET", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), nil))
+	})
+
+	t.Run("WriteScopeIncludesRead", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), nil))
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":        "Initial",
+			"version":     "1.0.0",
+			"description": "Created by a personal access token",
+		}))
+	})
+
+	t.Run("MissingScope", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":        "Another Service",
+			"description": "Should not be created by a read only token",
+		}, pat)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Token is missing the services:write scope")
+
+		assert.Equal(t, http.StatusForbidden, request("GET", fmt.Sprintf("/v1/orgs/%s", org.ID), nil))
+	})
+
+	t.Run("OtherOrganization", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services", otherOrg.ID), nil, pat)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Token is not authorized for this organization")
+	})
+
+	t.Run("AccountRoutesRejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("GET", "/v1/users/me/tokens", nil))
+		assert.Equal(t, http.StatusForbidden, request("GET", "/v1/orgs", nil))
+	})
+
+	t.Run("LastUsedIsRecorded", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/users/me/tokens", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens []models.PersonalAccessToken
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.Len(t, tokens, 1)
+		assert.NotNil(t, tokens[0].LastUsedAt, "Last use should be recorded")
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expiring := helpers.CreateTestPersonalAccessToken(token, []string{org.ID}, []string{"services:read"})
+		testDB.Exec("UPDATE personal_access_tokens SET expires_at = NOW() - INTERVAL '1 minute' WHERE token_hash = ?", utils.HashToken(expiring))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services", org.ID), nil, expiring)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/users/me/tokens", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var tokens []models.PersonalAccessToken
+		helpers.AssertJSONResponse(resp, &tokens)
+
+		for _, pat := range tokens {
+			resp, err = helpers.MakeAuthenticatedRequest("DELETE", fmt.Sprintf("/v1/users/me/tokens/%s", pat.ID), nil, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			helpers.AssertStatusCode(resp, http.StatusNoContent)
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, request("GET", fmt.Sprintf("/v1/orgs/%s/services", org.ID), nil))
+
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", fmt.Sprintf("/v1/users/me/tokens/%s", tokens[0].ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+}
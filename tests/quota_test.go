@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestQuotas tests the limits of organizations, the /v1/orgs/:orgId/usage endpoint and the operator quota endpoints
+func TestQuotas(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	t.Setenv("OPERATOR_EMAILS", "quota-operator@example.com")
+
+	getUsage := func(token, orgID string) models.OrganizationUsage {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/usage", orgID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var usage models.OrganizationUsage
+		helpers.AssertJSONResponse(resp, &usage)
+		return usage
+	}
+
+	assertQuotaExceeded := func(t *testing.T, method, path string, body interface{}, token string, resource models.QuotaResource) {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, body, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+
+		var errResp struct {
+			Type    string                    `json:"type"`
+			Details models.QuotaExceededError `json:"details"`
+		}
+		helpers.AssertJSONResponse(resp, &errResp)
+		assert.Equal(t, "quota_exceeded", errResp.Type)
+		assert.Equal(t, resource, errResp.Details.Resource)
+	}
+
+	t.Run("DefaultLimits", func(t *testing.T) {
+		t.Setenv("QUOTA_MAX_SERVICES", "2")
+		t.Setenv("QUOTA_MAX_VERSIONS_PER_SERVICE", "1")
+
+		_, ownerToken := helpers.CreateTestUser("quota-owner@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Quota Organization", "Test organization description")
+
+		service := helpers.CreateTestService(ownerToken, org.ID, "First Service", "Test service description")
+		helpers.CreateTestService(ownerToken, org.ID, "Second Service", "Test service description")
+		assertQuotaExceeded(t, "POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":        "Third Service",
+			"description": "Test service description",
+		}, ownerToken, models.QuotaServices)
+
+		helpers.CreateTestServiceVersion(ownerToken, org.ID, service.ID, "First Version", "1.0.0", "Test version description")
+		assertQuotaExceeded(t, "POST", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", org.ID, service.ID), map[string]interface{}{
+			"name":        "Second Version",
+			"version":     "1.1.0",
+			"description": "Test version description",
+		}, ownerToken, models.QuotaVersionsPerService)
+
+		usage := getUsage(ownerToken, org.ID)
+		assert.Equal(t, models.QuotaUsage{Limit: 2, Usage: 2}, usage.Services)
+		assert.Equal(t, models.QuotaUsage{Limit: 1, Usage: 1}, usage.VersionsPerService)
+		assert.Equal(t, 1, usage.Members.Usage)
+	})
+
+	t.Run("OperatorOverride", func(t *testing.T) {
+		_, operatorToken := helpers.CreateTestUser("quota-operator@example.com", "Operator", TestPassword)
+		_, ownerToken := helpers.CreateTestUser("quota-override-owner@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Override Organization", "Test organization description")
+		quotaPath := fmt.Sprintf("/v1/operator/orgs/%s/quotas", org.ID)
+
+		resp, err := helpers.MakeAuthenticatedRequest("PUT", quotaPath, map[string]interface{}{"maxServices": 1}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", "/v1/operator/orgs/00000000-0000-0000-0000-000000000000/quotas", map[string]interface{}{"maxServices": 1}, operatorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", quotaPath, map[string]interface{}{"maxServices": 1, "maxMembers": 1}, operatorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var limits models.QuotaLimits
+		helpers.AssertJSONResponse(resp, &limits)
+		assert.Equal(t, 1, limits.MaxServices)
+		assert.Equal(t, 1, limits.MaxMembers)
+		assert.Equal(t, models.DefaultQuotaLimits().MaxVersionsPerService, limits.MaxVersionsPerService)
+
+		helpers.CreateTestService(ownerToken, org.ID, "Only Service", "Test service description")
+		assertQuotaExceeded(t, "POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name":        "Another Service",
+			"description": "Test service description",
+		}, ownerToken, models.QuotaServices)
+
+		// the owner already uses the only seat
+		_, inviteeToken := helpers.CreateTestUser("quota-invitee@example.com", "Invitee", TestPassword)
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/invitations", org.ID), map[string]interface{}{
+			"email": "quota-invitee@example.com",
+			"role":  "viewer",
+		}, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusCreated)
+		assertQuotaExceeded(t, "POST", "/v1/invitations/accept", map[string]interface{}{
+			"token": helpers.ExtractEmailToken(helpers.LatestEmailTo("quota-invitee@example.com")),
+		}, inviteeToken, models.QuotaMembers)
+
+		// limits left out of an override go back to the default
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", quotaPath, map[string]interface{}{}, operatorToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		usage := getUsage(ownerToken, org.ID)
+		assert.Equal(t, models.DefaultQuotaLimits().MaxServices, usage.Services.Limit)
+		assert.Equal(t, 1, usage.Services.Usage)
+	})
+}
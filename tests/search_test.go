@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestSearch tests the ranked full-text search of an organization and its fallback to similar names
+func TestSearch(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("search@example.com", "Search", TestPassword)
+	org := helpers.CreateTestOrganization(token, "Search Org", "Test organization description")
+
+	payments := helpers.CreateTestService(token, org.ID, "Payments", "Charges cards and handles refunds for orders")
+	helpers.CreateTestService(token, org.ID, "Notifications", "Sends emails about payments and shipping updates")
+	shipping := helpers.CreateTestService(token, org.ID, "Shipping", "Tracks parcels of orders")
+	version := helpers.CreateTestServiceVersion(token, org.ID, shipping.ID, "Carrier integration", "1.0.0", "Adds refunds for lost parcels")
+
+	search := func(q string) models.SearchResult {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/search?q=%s", org.ID, url.QueryEscape(q)), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.SearchResult
+		helpers.AssertJSONResponse(resp, &result)
+		return result
+	}
+
+	t.Run("RankedByRelevance", func(t *testing.T) {
+		result := search("payments")
+		if assert.Len(t, result.Data, 2) {
+			// a match in the name ranks above a match in the description
+			assert.Equal(t, payments.ID, result.Data[0].ID)
+			assert.Equal(t, models.SearchService, result.Data[0].Kind)
+			assert.Contains(t, result.Data[0].NameSnippet, "<mark>Payments</mark>")
+			assert.Contains(t, result.Data[1].DescriptionSnippet, "<mark>payments</mark>")
+			assert.Greater(t, result.Data[0].Score, result.Data[1].Score)
+		}
+		assert.Empty(t, result.DidYouMean)
+	})
+
+	t.Run("VersionDescriptions", func(t *testing.T) {
+		result := search("refund")
+		kinds := map[string]models.SearchKind{}
+		for _, hit := range result.Data {
+			kinds[hit.ID] = hit.Kind
+		}
+		assert.Equal(t, models.SearchService, kinds[payments.ID])
+		assert.Equal(t, models.SearchVersion, kinds[version.ID])
+		assert.Equal(t, 2, result.Meta.TotalCount)
+	})
+
+	t.Run("DidYouMean", func(t *testing.T) {
+		result := search("shiping")
+		assert.Equal(t, "Shipping", result.DidYouMean)
+		if assert.NotEmpty(t, result.Data) {
+			assert.Equal(t, shipping.ID, result.Data[0].ID)
+		}
+	})
+
+	t.Run("ServiceListMatchesDescriptions", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services?q=parcels", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Service]
+		helpers.AssertJSONResponse(resp, &result)
+		if assert.Len(t, result.Data, 1) {
+			assert.Equal(t, shipping.ID, result.Data[0].ID)
+		}
+	})
+
+	t.Run("EmptyQuery", func(t *testing.T) {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/search", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+	})
+
+	t.Run("SnippetsEscaped", func(t *testing.T) {
+		helpers.CreateTestService(token, org.ID, "Widgets <script>alert(1)</script>", `Renders <img src=x onerror="alert(1)"> widgets & gadgets`)
+
+		result := search("widgets")
+		if assert.Len(t, result.Data, 1) {
+			hit := result.Data[0]
+			assert.Contains(t, hit.NameSnippet, "<mark>Widgets</mark>")
+			assert.Contains(t, hit.NameSnippet, "&lt;script&gt;")
+			assert.NotContains(t, hit.NameSnippet, "<script>")
+			assert.Contains(t, hit.DescriptionSnippet, "<mark>widgets</mark> &amp; gadgets")
+			assert.Contains(t, hit.DescriptionSnippet, "&lt;img src=x onerror=&quot;alert(1)&quot;&gt;")
+			assert.Equal(t, "Widgets <script>alert(1)</script>", hit.Name, "Names should stay plain text")
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// TestSessions tests the /v1/users/me/sessions endpoints
+func TestSessions(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	login := func(email string) models.TokenResponse {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		return tokens
+	}
+
+	listSessions := func(token string) []models.Session {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/users/me/sessions", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var sessions []models.Session
+		helpers.AssertJSONResponse(resp, &sessions)
+		return sessions
+	}
+
+	statusWith := func(token string) int {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	refresh := func(refreshToken string) int {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{
+			"refreshToken": refreshToken,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	t.Run("List", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("sessions-list@example.com", "Test User", TestPassword)
+		other := login("sessions-list@example.com")
+
+		sessions := listSessions(token)
+		assert.Len(t, sessions, 2)
+
+		claims, err := utils.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+		assert.NotEmpty(t, claims.SessionID, "Access token should reference its session")
+
+		for _, session := range sessions {
+			assert.Equal(t, session.ID == claims.SessionID, session.Current)
+			assert.NotEmpty(t, session.Device)
+			assert.False(t, session.LastSeenAt.IsZero())
+		}
+
+		// the refreshed access token belongs to the same session
+		resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{
+			"refreshToken": other.RefreshToken,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		assert.Len(t, listSessions(token), 2, "Refreshing should not start a new session")
+	})
+
+	t.Run("RevokeOne", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("sessions-revoke@example.com", "Test User", TestPassword)
+		other := login("sessions-revoke@example.com")
+
+		otherClaims, err := utils.ValidateToken(other.AccessToken)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+
+		resp, err := helpers.MakeAuthenticatedRequest("DELETE", "/v1/users/me/sessions/"+otherClaims.SessionID, nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		assert.Equal(t, http.StatusUnauthorized, statusWith(other.AccessToken), "Access token of the revoked session should not work")
+		assert.Equal(t, http.StatusUnauthorized, refresh(other.RefreshToken), "Refresh token of the revoked session should not work")
+		assert.Equal(t, http.StatusOK, statusWith(token), "Other sessions should keep working")
+		assert.Len(t, listSessions(token), 1)
+
+		// already revoked
+		resp, err = helpers.MakeAuthenticatedRequest("DELETE", "/v1/users/me/sessions/"+otherClaims.SessionID, nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+	})
+
+	t.Run("CannotRevokeSessionOfAnotherUser", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("sessions-owner@example.com", "Test User", TestPassword)
+		_, otherToken := helpers.CreateTestUser("sessions-intruder@example.com", "Other User", TestPassword)
+
+		claims, err := utils.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+
+		resp, err := helpers.MakeAuthenticatedRequest("DELETE", "/v1/users/me/sessions/"+claims.SessionID, nil, otherToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+		helpers.AssertErrorResponse(resp, "Session not found")
+		assert.Equal(t, http.StatusOK, statusWith(token))
+	})
+
+	t.Run("RevokeAll", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("sessions-all@example.com", "Test User", TestPassword)
+		other := login("sessions-all@example.com")
+
+		resp, err := helpers.MakeAuthenticatedRequest("DELETE", "/v1/users/me/sessions", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		assert.Equal(t, http.StatusUnauthorized, statusWith(token))
+		assert.Equal(t, http.StatusUnauthorized, statusWith(other.AccessToken))
+		assert.Equal(t, http.StatusUnauthorized, refresh(other.RefreshToken))
+
+		// a new login works at the next token epoch
+		fresh := login("sessions-all@example.com")
+		assert.Equal(t, http.StatusOK, statusWith(fresh.AccessToken))
+	})
+
+	t.Run("PasswordChangeRevokesOtherSessions", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("sessions-password@example.com", "Test User", TestPassword)
+		other := login("sessions-password@example.com")
+		org := helpers.CreateTestOrganization(token, "Sessions Organization", "Test organization description")
+		pat := helpers.CreateTestPersonalAccessToken(token, []string{org.ID}, []string{"orgs:read"})
+		orgStatusWith := func(token string) int {
+			resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s", org.ID), nil, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			return resp.Code
+		}
+		assert.Equal(t, http.StatusOK, orgStatusWith(pat))
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/password", map[string]interface{}{
+			"currentPassword": TestPassword,
+			"newPassword":     "NewPassword456!",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+
+		assert.Equal(t, http.StatusUnauthorized, statusWith(other.AccessToken), "Access tokens of other sessions should stop working right away")
+		assert.Equal(t, http.StatusOK, statusWith(tokens.AccessToken))
+		assert.Len(t, listSessions(tokens.AccessToken), 1)
+		assert.Equal(t, http.StatusUnauthorized, orgStatusWith(pat), "Personal access tokens should be revoked")
+	})
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"io"
 	"log"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"github.com/thilak009/kong-assignment/db"
 	"github.com/thilak009/kong-assignment/forms"
 	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
 	"github.com/thilak009/kong-assignment/pkg/middleware"
 	"github.com/thilak009/kong-assignment/routes"
 	"github.com/thilak009/kong-assignment/utils"
@@ -22,6 +24,9 @@ var (
 	testDB     *gorm.DB
 	testServer *httptest.Server
 	testRouter *gin.Engine
+	testMailer *mailer.FileMailer
+
+	testMailerDir string
 )
 
 // TestMain runs before any tests and sets up the test environment
@@ -40,6 +45,9 @@ func setup() {
 	// Setup test database
 	setupTestDatabase()
 
+	// Setup test mailer
+	setupTestMailer()
+
 	// Setup test router
 	setupTestRouter()
 
@@ -53,6 +61,10 @@ func teardown() {
 		testServer.Close()
 	}
 
+	if testMailer != nil {
+		os.RemoveAll(testMailerDir)
+	}
+
 	if testDB != nil {
 		sqlDB, _ := testDB.DB()
 		if sqlDB != nil {
@@ -75,11 +87,52 @@ func setupTestDatabase() {
 	// Get the initialized database instance
 	testDB = db.GetDB()
 
+	// Give memberships of an existing test database their roles before migrating
+	if err := models.MigrateMembershipRoles(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate membership roles: %v", err)
+	}
+
+	// Verify the users of an existing test database before migrating
+	if err := models.MigrateUserVerification(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate user verification: %v", err)
+	}
+
+	// Require service grants in the organizations with teams of an existing test database before migrating
+	if err := models.MigrateServiceGrants(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate service grants: %v", err)
+	}
+
+	// Give organizations and services of an existing test database their slugs before migrating
+	if err := models.MigrateSlugs(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate slugs: %v", err)
+	}
+
 	// Run migrations using existing function
-	err := db.RunMigrations(&models.User{}, &models.Organization{}, &models.Service{}, &models.ServiceVersion{}, &models.UserOrganizationMap{})
+	err := db.RunMigrations(&models.User{}, &models.Organization{}, &models.Service{}, &models.ServiceVersion{}, &models.UserOrganizationMap{}, &models.BlacklistedToken{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{},
+		&models.UserMFA{}, &models.MFARecoveryCode{}, &models.MFAChallenge{},
+		&models.PersonalAccessToken{}, &models.OIDCLoginState{}, &models.UserIdentity{}, &models.LoginAttempt{}, &models.Session{}, &models.Invitation{},
+		&models.Team{}, &models.TeamMember{}, &models.ServiceGrant{}, &models.OrganizationQuota{}, &models.SlugRedirect{},
+		&models.AttributeSchema{},
+		&models.ServiceDependency{})
 	if err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
+
+	// Add the full-text search columns and the search indexes once the tables exist
+	if err := models.MigrateSearch(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate search: %v", err)
+	}
+}
+
+// setupTestMailer writes all outgoing mails to a temporary directory so that tests can read them back
+func setupTestMailer() {
+	dir, err := os.MkdirTemp("", "konnect-test-outbox-")
+	if err != nil {
+		log.Fatalf("Failed to create test mail outbox: %v", err)
+	}
+	testMailerDir = dir
+	testMailer = mailer.NewFileMailer(dir, "no-reply@konnect.test")
+	mailer.Set(testMailer)
 }
 
 // setupTestRouter creates a test router reusing main.go setup
@@ -88,6 +141,7 @@ func setupTestRouter() {
 	gin.DefaultWriter = io.Discard
 
 	testRouter = gin.New()
+	testRouter.SetTrustedProxies(utils.TrustedProxies())
 
 	// Add only recovery middleware, skip default logger
 	testRouter.Use(gin.Recovery())
@@ -113,6 +167,11 @@ func GetTestRouter() *gin.Engine {
 	return testRouter
 }
 
+// GetTestMailer returns the mailer that captures outgoing mails
+func GetTestMailer() *mailer.FileMailer {
+	return testMailer
+}
+
 // GetTestServer returns the test server instance
 func GetTestServer() *httptest.Server {
 	return testServer
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestSlugs tests addressing organizations and services by their slugs and the redirects of previous slugs
+func TestSlugs(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	_, token := helpers.CreateTestUser("slugs@example.com", "Slugs", TestPassword)
+
+	t.Run("GeneratedSlugs", func(t *testing.T) {
+		org := helpers.CreateTestOrganization(token, "Acme Corp!", "Test organization description")
+		assert.Equal(t, "acme-corp", org.Slug)
+
+		duplicate := helpers.CreateTestOrganization(token, "acme corp", "Test organization description")
+		assert.Equal(t, "acme-corp-2", duplicate.Slug)
+
+		service := helpers.CreateTestService(token, org.ID, "Payments API", "Test service description")
+		assert.Equal(t, "payments-api", service.Slug)
+
+		// service slugs are only unique within the organization
+		other := helpers.CreateTestService(token, duplicate.ID, "Payments API", "Test service description")
+		assert.Equal(t, "payments-api", other.Slug)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/acme-corp/services/payments-api", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var fetched models.Service
+		helpers.AssertJSONResponse(resp, &fetched)
+		assert.Equal(t, service.ID, fetched.ID)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/acme-corp/services/%s", service.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+	})
+
+	t.Run("RenamedSlugsRedirect", func(t *testing.T) {
+		org := helpers.CreateTestOrganization(token, "Globex", "Test organization description")
+		service := helpers.CreateTestService(token, org.ID, "Billing", "Test service description")
+
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), map[string]interface{}{
+			"slug": "invoicing",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s", org.ID), map[string]interface{}{
+			"name":        "Globex",
+			"description": "Test organization description",
+			"slug":        "globex-corp",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/globex/services/billing/versions?page=1", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusPermanentRedirect)
+		assert.Equal(t, "/v1/orgs/globex-corp/services/invoicing/versions?page=1", resp.Header().Get("Location"))
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/globex-corp/services/invoicing", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		// a new resource can take over a previous slug, which stops redirecting
+		reused := helpers.CreateTestService(token, org.ID, "Billing", "Test service description")
+		assert.Equal(t, "billing-2", reused.Slug, "Generated slugs should not take previous slugs")
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+			"name": "Billing",
+			"slug": "billing",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/globex-corp/services/billing", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+	})
+
+	t.Run("RedirectsHiddenFromOutsiders", func(t *testing.T) {
+		org := helpers.CreateTestOrganization(token, "Hooli", "Test organization description")
+		service := helpers.CreateTestService(token, org.ID, "Nucleus", "Test service description")
+		outsider, outsiderToken := helpers.CreateTestUser("slugs-outsider@example.com", "Outsider", TestPassword)
+
+		resp, err := helpers.MakeAuthenticatedRequest("PATCH", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID), map[string]interface{}{
+			"slug": "nucleus-v2",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s", org.ID), map[string]interface{}{
+			"name":        "Hooli",
+			"description": "Test organization description",
+			"slug":        "hooli-xyz",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/hooli", nil, outsiderToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		assert.Empty(t, resp.Header().Get("Location"), "Non-members should not learn the current slug")
+		assert.NotContains(t, resp.Body.String(), org.ID)
+
+		// members without access to the service don't learn its current slug either
+		helpers.AddTestMember(org.ID, outsider.ID, models.RoleViewer)
+		resp, err = helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/teams", org.ID), map[string]interface{}{"name": "Core"}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusCreated)
+
+		var team models.Team
+		helpers.AssertJSONResponse(resp, &team)
+		resp, err = helpers.MakeAuthenticatedRequest("PUT", fmt.Sprintf("/v1/orgs/%s/teams/%s/services/%s", org.ID, team.ID, service.ID), map[string]interface{}{
+			"access": "read",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/hooli-xyz/services/nucleus", nil, outsiderToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNotFound)
+		assert.Empty(t, resp.Header().Get("Location"))
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/hooli", nil, outsiderToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusPermanentRedirect)
+		assert.Equal(t, "/v1/orgs/hooli-xyz", resp.Header().Get("Location"), "Members should be redirected")
+	})
+
+	t.Run("InvalidSlugs", func(t *testing.T) {
+		org := helpers.CreateTestOrganization(token, "Initech", "Test organization description")
+		helpers.CreateTestService(token, org.ID, "Reports", "Test service description")
+
+		testCases := []struct {
+			name           string
+			slug           string
+			expectedStatus int
+		}{
+			{"Taken", "reports", http.StatusConflict},
+			{"Uppercase", "Reports-2", http.StatusBadRequest},
+			{"Too short", "ab", http.StatusBadRequest},
+			{"Id format", "123e4567-e89b-12d3-a456-426614174000", http.StatusBadRequest},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/services", org.ID), map[string]interface{}{
+					"name": "Reports",
+					"slug": tc.slug,
+				}, token)
+				if err != nil {
+					t.Fatalf("Failed to make request: %v", err)
+				}
+				helpers.AssertStatusCode(resp, tc.expectedStatus)
+			})
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestTeams tests the /v1/orgs/:orgId/teams endpoints and the service grants of teams
+func TestTeams(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	request := func(method, path string, body interface{}, token string) int {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, body, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	createTeam := func(token, orgID, name string) models.Team {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", fmt.Sprintf("/v1/orgs/%s/teams", orgID), map[string]interface{}{
+			"name":        name,
+			"description": "Test team description",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusCreated)
+
+		var team models.Team
+		helpers.AssertJSONResponse(resp, &team)
+		return team
+	}
+
+	listServices := func(token, orgID, query string) models.PaginatedResult[models.Service] {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services%s", orgID, query), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.Service]
+		helpers.AssertJSONResponse(resp, &result)
+		return result
+	}
+
+	t.Run("ManageTeams", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("teams-owner@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Teams Organization", "Test organization description")
+		viewer, viewerToken := helpers.CreateTestUser("teams-viewer@example.com", "Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+		outsider, _ := helpers.CreateTestUser("teams-outsider@example.com", "Outsider", TestPassword)
+
+		team := createTeam(ownerToken, org.ID, "Platform")
+		teamPath := fmt.Sprintf("/v1/orgs/%s/teams/%s", org.ID, team.ID)
+
+		assert.Equal(t, http.StatusConflict, request("POST", fmt.Sprintf("/v1/orgs/%s/teams", org.ID), map[string]interface{}{"name": "platform"}, ownerToken))
+		assert.Equal(t, http.StatusForbidden, request("POST", fmt.Sprintf("/v1/orgs/%s/teams", org.ID), map[string]interface{}{"name": "Viewers"}, viewerToken))
+
+		assert.Equal(t, http.StatusNoContent, request("PUT", fmt.Sprintf("%s/members/%s", teamPath, viewer.ID), nil, ownerToken))
+		assert.Equal(t, http.StatusNoContent, request("PUT", fmt.Sprintf("%s/members/%s", teamPath, viewer.ID), nil, ownerToken), "Adding a member twice should not fail")
+		assert.Equal(t, http.StatusBadRequest, request("PUT", fmt.Sprintf("%s/members/%s", teamPath, outsider.ID), nil, ownerToken))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", teamPath, nil, viewerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var fetched models.Team
+		helpers.AssertJSONResponse(resp, &fetched)
+		assert.Equal(t, "Platform", fetched.Name)
+		assert.Len(t, fetched.Members, 1)
+		assert.Equal(t, viewer.ID, fetched.Members[0].UserID)
+
+		assert.Equal(t, http.StatusOK, request("PUT", teamPath, map[string]interface{}{"name": "Platform Team"}, ownerToken))
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("%s/members/%s", teamPath, viewer.ID), nil, ownerToken))
+		assert.Equal(t, http.StatusNotFound, request("DELETE", fmt.Sprintf("%s/members/%s", teamPath, viewer.ID), nil, ownerToken))
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", teamPath, nil, ownerToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", teamPath, nil, ownerToken))
+	})
+
+	t.Run("ServiceGrants", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("teams-owner2@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Teams Organization", "Test organization description")
+		editor, editorToken := helpers.CreateTestUser("teams-editor2@example.com", "Editor", TestPassword)
+		helpers.AddTestMember(org.ID, editor.ID, models.RoleEditor)
+		viewer, viewerToken := helpers.CreateTestUser("teams-viewer2@example.com", "Viewer", TestPassword)
+		helpers.AddTestMember(org.ID, viewer.ID, models.RoleViewer)
+
+		restricted := helpers.CreateTestService(ownerToken, org.ID, "Restricted Service", "Test service description")
+		openService := helpers.CreateTestService(ownerToken, org.ID, "Open Service", "Test service description")
+		helpers.CreateTestServiceVersion(ownerToken, org.ID, restricted.ID, "Initial", "1.0.0", "Test version description")
+
+		team := createTeam(ownerToken, org.ID, "Payments")
+		teamPath := fmt.Sprintf("/v1/orgs/%s/teams/%s", org.ID, team.ID)
+		servicePath := fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, restricted.ID)
+
+		assert.Equal(t, http.StatusBadRequest, request("PUT", fmt.Sprintf("%s/services/%s", teamPath, restricted.ID), map[string]interface{}{"access": "owner"}, ownerToken))
+		assert.Equal(t, http.StatusNotFound, request("PUT", fmt.Sprintf("%s/services/%s", teamPath, "00000000-0000-0000-0000-000000000000"), map[string]interface{}{"access": "read"}, ownerToken))
+		assert.Equal(t, http.StatusOK, request("PUT", fmt.Sprintf("%s/services/%s", teamPath, restricted.ID), map[string]interface{}{"access": "write"}, ownerToken))
+		assert.Equal(t, http.StatusNoContent, request("PUT", fmt.Sprintf("%s/members/%s", teamPath, viewer.ID), nil, ownerToken))
+
+		// once the organization has a team, members only see the services granted to their teams
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath, nil, editorToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath+"/versions", nil, editorToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, openService.ID), nil, editorToken))
+		assert.Equal(t, 0, listServices(editorToken, org.ID, "").Meta.TotalCount, "Count should only include visible services")
+
+		// the grant gives the viewer write access to the service and its versions, but not admin access
+		result := listServices(viewerToken, org.ID, "")
+		if assert.Equal(t, 1, result.Meta.TotalCount) {
+			assert.Equal(t, restricted.ID, result.Data[0].ID)
+		}
+		assert.Equal(t, http.StatusOK, request("PATCH", servicePath, map[string]interface{}{"name": "Renamed Service"}, viewerToken))
+		assert.Equal(t, http.StatusOK, request("POST", servicePath+"/versions", map[string]interface{}{
+			"name":        "Second",
+			"version":     "1.1.0",
+			"description": "Test version description",
+		}, viewerToken))
+		assert.Equal(t, http.StatusForbidden, request("DELETE", servicePath, nil, viewerToken))
+
+		// owners see every service
+		assert.Equal(t, 2, listServices(ownerToken, org.ID, "").Meta.TotalCount)
+
+		// removing the last grant or the team doesn't open the service to the whole organization
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("%s/services/%s", teamPath, restricted.ID), nil, ownerToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath, nil, editorToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath, nil, viewerToken))
+		assert.Equal(t, http.StatusNoContent, request("DELETE", teamPath, nil, ownerToken))
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath, nil, editorToken))
+		assert.Equal(t, 0, listServices(viewerToken, org.ID, "").Meta.TotalCount)
+		assert.Equal(t, http.StatusOK, request("GET", servicePath, nil, ownerToken))
+	})
+
+	t.Run("MigrationRequiresGrantsWithTeams", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("teams-owner3@example.com", "Owner", TestPassword)
+		withTeams := helpers.CreateTestOrganization(ownerToken, "Teams Organization", "Test organization description")
+		withoutTeams := helpers.CreateTestOrganization(ownerToken, "Teamless Organization", "Test organization description")
+		createTeam(ownerToken, withTeams.ID, "Platform")
+
+		// go back to a database from before grants were required
+		testDB := GetTestDB()
+		if err := testDB.Migrator().DropColumn(&models.Organization{}, "ServiceGrantsRequired"); err != nil {
+			t.Fatalf("Failed to drop service_grants_required: %v", err)
+		}
+		if err := models.MigrateServiceGrants(context.Background()); err != nil {
+			t.Fatalf("Failed to migrate service grants: %v", err)
+		}
+
+		required := map[string]bool{}
+		var orgs []models.Organization
+		testDB.Where("id IN ?", []string{withTeams.ID, withoutTeams.ID}).Find(&orgs)
+		for _, org := range orgs {
+			required[org.ID] = org.ServiceGrantsRequired
+		}
+		assert.True(t, required[withTeams.ID])
+		assert.False(t, required[withoutTeams.ID])
+	})
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/models"
+)
+
+// TestTrash tests the trash of organizations, services and versions, restoring from it and purging it
+func TestTrash(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	request := func(method, path string, token string) int {
+		resp, err := helpers.MakeAuthenticatedRequest(method, path, nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	countVersions := func(token, orgID, serviceID string) int {
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/services/%s/versions", orgID, serviceID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var result models.PaginatedResult[models.ServiceVersion]
+		helpers.AssertJSONResponse(resp, &result)
+		return result.Meta.TotalCount
+	}
+
+	t.Run("RestoreService", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("trash-services@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(token, "Trash Services", "Test organization description")
+		service := helpers.CreateTestService(token, org.ID, "Trashed Service", "Test service description")
+		helpers.CreateTestServiceVersion(token, org.ID, service.ID, "First Version", "1.0.0", "Test version description")
+		deletedAlone := helpers.CreateTestServiceVersion(token, org.ID, service.ID, "Second Version", "1.1.0", "Test version description")
+		servicePath := fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, service.ID)
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("%s/versions/%s", servicePath, deletedAlone.ID), token))
+		assert.Equal(t, http.StatusNoContent, request("DELETE", servicePath, token))
+		assert.Equal(t, http.StatusNotFound, request("GET", servicePath, token))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/trash/services", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var trash models.PaginatedResult[models.DeletedService]
+		helpers.AssertJSONResponse(resp, &trash)
+		if assert.Len(t, trash.Data, 1) {
+			assert.Equal(t, service.ID, trash.Data[0].ID)
+			assert.True(t, trash.Data[0].PurgeAt.After(trash.Data[0].DeletedAt))
+		}
+
+		assert.Equal(t, http.StatusOK, request("POST", servicePath+"/restore", token))
+		assert.Equal(t, http.StatusNotFound, request("POST", servicePath+"/restore", token), "A restored service is no longer in the trash")
+		assert.Equal(t, http.StatusOK, request("GET", servicePath, token))
+		assert.Equal(t, 1, countVersions(token, org.ID, service.ID), "Only the versions deleted with the service should be restored with it")
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", fmt.Sprintf("/v1/orgs/%s/trash/versions", org.ID), nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var versionTrash models.PaginatedResult[models.DeletedServiceVersion]
+		helpers.AssertJSONResponse(resp, &versionTrash)
+		if assert.Len(t, versionTrash.Data, 1) {
+			assert.Equal(t, deletedAlone.ID, versionTrash.Data[0].ID)
+		}
+
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("%s/versions/%s/restore", servicePath, deletedAlone.ID), token))
+		assert.Equal(t, 2, countVersions(token, org.ID, service.ID))
+	})
+
+	t.Run("RestoreOrganization", func(t *testing.T) {
+		_, ownerToken := helpers.CreateTestUser("trash-owner@example.com", "Owner", TestPassword)
+		admin, adminToken := helpers.CreateTestUser("trash-admin@example.com", "Admin", TestPassword)
+		org := helpers.CreateTestOrganization(ownerToken, "Trash Organization", "Test organization description")
+		helpers.AddTestMember(org.ID, admin.ID, models.RoleAdmin)
+		service := helpers.CreateTestService(ownerToken, org.ID, "Organization Service", "Test service description")
+		orgPath := fmt.Sprintf("/v1/orgs/%s", org.ID)
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", orgPath, ownerToken))
+		assert.Equal(t, http.StatusForbidden, request("GET", orgPath, adminToken))
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/trash/orgs", nil, ownerToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var trash models.PaginatedResult[models.DeletedOrganization]
+		helpers.AssertJSONResponse(resp, &trash)
+		if assert.Len(t, trash.Data, 1) {
+			assert.Equal(t, org.ID, trash.Data[0].ID)
+		}
+
+		assert.Equal(t, http.StatusNotFound, request("POST", orgPath+"/restore", adminToken), "Only owners should be able to restore the organization")
+		assert.Equal(t, http.StatusOK, request("POST", orgPath+"/restore", ownerToken))
+
+		assert.Equal(t, http.StatusOK, request("GET", orgPath, adminToken), "Memberships should be restored with the organization")
+		assert.Equal(t, http.StatusOK, request("GET", fmt.Sprintf("%s/services/%s", orgPath, service.ID), adminToken))
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("trash-purge@example.com", "Owner", TestPassword)
+		org := helpers.CreateTestOrganization(token, "Purged Organization", "Test organization description")
+		kept := helpers.CreateTestService(token, org.ID, "Recently Deleted", "Test service description")
+		purged := helpers.CreateTestService(token, org.ID, "Long Deleted", "Test service description")
+		helpers.CreateTestServiceVersion(token, org.ID, purged.ID, "First Version", "1.0.0", "Test version description")
+
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, kept.ID), token))
+		assert.Equal(t, http.StatusNoContent, request("DELETE", fmt.Sprintf("/v1/orgs/%s/services/%s", org.ID, purged.ID), token))
+
+		longAgo := time.Now().Add(-models.TrashRetention() - time.Hour)
+		testDB.Unscoped().Model(&models.Service{}).Where("id = ?", purged.ID).UpdateColumn("deleted_at", longAgo)
+		testDB.Unscoped().Model(&models.ServiceVersion{}).Where("service_id = ?", purged.ID).UpdateColumn("deleted_at", longAgo)
+
+		if err := (models.TrashModel{}).Purge(context.Background()); err != nil {
+			t.Fatalf("Failed to purge the trash: %v", err)
+		}
+
+		var services, versions int64
+		testDB.Unscoped().Model(&models.Service{}).Where("id = ?", purged.ID).Count(&services)
+		testDB.Unscoped().Model(&models.ServiceVersion{}).Where("service_id = ?", purged.ID).Count(&versions)
+		assert.Equal(t, int64(0), services)
+		assert.Equal(t, int64(0), versions)
+
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/v1/orgs/%s/services/%s/restore", org.ID, kept.ID), token))
+	})
+}
@@ -1,11 +1,20 @@
 package tests
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thilak009/kong-assignment/models"
+	"github.com/thilak009/kong-assignment/pkg/mailer"
+	"github.com/thilak009/kong-assignment/utils"
 )
 
 // TestUserRegistration tests POST /v1/users/register endpoint
@@ -30,10 +39,14 @@ func TestUserRegistration(t *testing.T) {
 			t.Fatalf("Failed to make request: %v", err)
 		}
 
-		helpers.AssertStatusCode(resp, http.StatusCreated)
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
 
-		var user models.User
-		helpers.AssertJSONResponse(resp, &user)
+		var message models.MessageResponse
+		helpers.AssertJSONResponse(resp, &message)
+		assert.Equal(t, "Please check your email to verify your account", message.Message)
+
+		// User details are returned once the email is verified
+		user := helpers.VerifyTestUser("test@example.com")
 
 		assert.NotEmpty(t, user.ID, "User ID should not be empty")
 		assert.Equal(t, "test@example.com", user.Email, "Email should match")
@@ -41,6 +54,7 @@ func TestUserRegistration(t *testing.T) {
 		assert.Empty(t, user.Password, "Password should not be returned")
 		assert.False(t, user.CreatedAt.IsZero(), "CreatedAt should not be zero")
 		assert.False(t, user.UpdatedAt.IsZero(), "UpdatedAt should not be zero")
+		assert.NotNil(t, user.VerifiedAt, "VerifiedAt should be set")
 	})
 
 	t.Run("ValidationErrors", func(t *testing.T) {
@@ -121,7 +135,8 @@ func TestUserRegistration(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to make request: %v", err)
 		}
-		helpers.AssertStatusCode(resp1, http.StatusCreated)
+		helpers.AssertStatusCode(resp1, http.StatusAccepted)
+		helpers.VerifyTestUser("duplicate@example.com")
 
 		// Try to create second user with same email
 		payload2 := map[string]interface{}{
@@ -135,8 +150,15 @@ func TestUserRegistration(t *testing.T) {
 			t.Fatalf("Failed to make request: %v", err)
 		}
 
-		helpers.AssertStatusCode(resp2, http.StatusConflict)
-		helpers.AssertErrorResponse(resp2, "User with this email already exists")
+		// Same response as a new registration so that existing emails can't be enumerated
+		helpers.AssertStatusCode(resp2, http.StatusAccepted)
+		var message models.MessageResponse
+		helpers.AssertJSONResponse(resp2, &message)
+		assert.Equal(t, "Please check your email to verify your account", message.Message)
+
+		// The owner of the email is told about the attempt instead
+		msg := helpers.LatestEmailTo("duplicate@example.com")
+		assert.Equal(t, "Your Konnect account", msg.Subject)
 	})
 }
 
@@ -325,3 +347,964 @@ func TestUserLogout(t *testing.T) {
 		helpers.AssertErrorResponse(resp2, "Invalid token")
 	})
 }
+
+// TestTokenRefresh tests POST /v1/users/token/refresh endpoint
+func TestTokenRefresh(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	login := func(email string) models.TokenResponse {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		return tokens
+	}
+
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{
+			"refreshToken": refreshToken,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		helpers.CreateTestUser("refresh@example.com", "Test User", TestPassword)
+		tokens := login("refresh@example.com")
+		assert.NotEmpty(t, tokens.RefreshToken, "Refresh token should be returned on login")
+		assert.Greater(t, tokens.ExpiresIn, 0, "Access token lifetime should be returned on login")
+
+		resp := refresh(tokens.RefreshToken)
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var refreshed models.TokenResponse
+		helpers.AssertJSONResponse(resp, &refreshed)
+		assert.NotEmpty(t, refreshed.AccessToken, "Access token should not be empty")
+		assert.NotEmpty(t, refreshed.RefreshToken, "Refresh token should not be empty")
+		assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken, "Refresh token should be rotated")
+
+		// New access token should be usable
+		orgsResp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, refreshed.AccessToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(orgsResp, http.StatusOK)
+	})
+
+	t.Run("ReuseRevokesFamily", func(t *testing.T) {
+		helpers.CreateTestUser("reuse@example.com", "Test User", TestPassword)
+		tokens := login("reuse@example.com")
+
+		resp := refresh(tokens.RefreshToken)
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		var rotated models.TokenResponse
+		helpers.AssertJSONResponse(resp, &rotated)
+
+		// Presenting the already rotated token again must fail
+		reuseResp := refresh(tokens.RefreshToken)
+		helpers.AssertStatusCode(reuseResp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(reuseResp, "Invalid refresh token")
+
+		// and the token it was rotated into must be revoked as well
+		rotatedResp := refresh(rotated.RefreshToken)
+		helpers.AssertStatusCode(rotatedResp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(rotatedResp, "Invalid refresh token")
+	})
+
+	t.Run("OtherLoginsUnaffectedByReuse", func(t *testing.T) {
+		helpers.CreateTestUser("family@example.com", "Test User", TestPassword)
+		first := login("family@example.com")
+		second := login("family@example.com")
+
+		helpers.AssertStatusCode(refresh(first.RefreshToken), http.StatusOK)
+		helpers.AssertStatusCode(refresh(first.RefreshToken), http.StatusUnauthorized)
+
+		helpers.AssertStatusCode(refresh(second.RefreshToken), http.StatusOK)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		resp := refresh("not-a-refresh-token")
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(resp, "Invalid refresh token")
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Please provide the refresh token")
+	})
+}
+
+// failingMailer fails to send every mail
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return errors.New("mail server unavailable")
+}
+
+// TestPasswordReset tests POST /v1/users/password/forgot and POST /v1/users/password/reset endpoints
+func TestPasswordReset(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	const newPassword = "NewPassword456!"
+
+	forgot := func(email string) {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/password/forgot", map[string]interface{}{
+			"email": email,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+	}
+
+	reset := func(token, password string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/password/reset", map[string]interface{}{
+			"token":    token,
+			"password": password,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	login := func(email, password string) int {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": password,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp.Code
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		helpers.CreateTestUser("reset@example.com", "Test User", TestPassword)
+
+		forgot("reset@example.com")
+		msg := helpers.LatestEmailTo("reset@example.com")
+		assert.Contains(t, msg.Subject, "Reset your Konnect password")
+		token := helpers.ExtractEmailToken(msg)
+
+		resp := reset(token, newPassword)
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		assert.Equal(t, http.StatusUnauthorized, login("reset@example.com", TestPassword), "Old password should not work")
+		assert.Equal(t, http.StatusOK, login("reset@example.com", newPassword), "New password should work")
+
+		// Password must be stored hashed
+		var user models.User
+		GetTestDB().Where("email = ?", "reset@example.com").First(&user)
+		assert.NotEqual(t, newPassword, user.Password, "Password should not be stored in plain text")
+	})
+
+	t.Run("TokenIsSingleUse", func(t *testing.T) {
+		helpers.CreateTestUser("singleuse@example.com", "Test User", TestPassword)
+
+		forgot("singleuse@example.com")
+		token := helpers.ExtractEmailToken(helpers.LatestEmailTo("singleuse@example.com"))
+
+		helpers.AssertStatusCode(reset(token, newPassword), http.StatusNoContent)
+
+		resp := reset(token, "AnotherPassword789!")
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid or expired password reset token")
+	})
+
+	t.Run("OnlyLatestTokenIsValid", func(t *testing.T) {
+		helpers.CreateTestUser("latest@example.com", "Test User", TestPassword)
+
+		forgot("latest@example.com")
+		first := helpers.ExtractEmailToken(helpers.LatestEmailTo("latest@example.com"))
+		forgot("latest@example.com")
+		second := helpers.ExtractEmailToken(helpers.LatestEmailTo("latest@example.com"))
+
+		helpers.AssertStatusCode(reset(first, newPassword), http.StatusBadRequest)
+		helpers.AssertStatusCode(reset(second, newPassword), http.StatusNoContent)
+	})
+
+	t.Run("RevokesRefreshTokens", func(t *testing.T) {
+		helpers.CreateTestUser("revoke@example.com", "Test User", TestPassword)
+
+		loginResp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    "revoke@example.com",
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(loginResp, &tokens)
+
+		forgot("revoke@example.com")
+		token := helpers.ExtractEmailToken(helpers.LatestEmailTo("revoke@example.com"))
+		helpers.AssertStatusCode(reset(token, newPassword), http.StatusNoContent)
+
+		resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{
+			"refreshToken": tokens.RefreshToken,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+	})
+
+	t.Run("UnknownEmailLooksTheSame", func(t *testing.T) {
+		forgot("nobody@example.com")
+		assert.Equal(t, 0, helpers.CountEmailsTo("nobody@example.com"), "No mail should be sent for unknown accounts")
+	})
+
+	t.Run("MailFailureLooksTheSame", func(t *testing.T) {
+		helpers.CreateTestUser("mailfailure@example.com", "Test User", TestPassword)
+
+		mailer.Set(failingMailer{})
+		t.Cleanup(func() {
+			mailer.Wait()
+			mailer.Set(GetTestMailer())
+		})
+
+		// the mail is sent in the background, a failing mail server doesn't change the response
+		forgot("mailfailure@example.com")
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		resp := reset("not-a-reset-token", newPassword)
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid or expired password reset token")
+	})
+
+	t.Run("WeakPassword", func(t *testing.T) {
+		resp := reset("some-token", "weak")
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponseNotEmpty(resp)
+	})
+}
+
+// TestEmailVerification tests POST /v1/users/verify and POST /v1/users/verify/resend endpoints
+func TestEmailVerification(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	register := func(email string) {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/register", map[string]interface{}{
+			"email":    email,
+			"name":     "Test User",
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+	}
+
+	login := func(email string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	verify := func(token string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/verify", map[string]interface{}{
+			"token": token,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("UnverifiedCannotLogin", func(t *testing.T) {
+		register("unverified@example.com")
+
+		resp := login("unverified@example.com")
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Please verify your email before logging in")
+
+		helpers.VerifyTestUser("unverified@example.com")
+		helpers.AssertStatusCode(login("unverified@example.com"), http.StatusOK)
+	})
+
+	t.Run("UnverifiedWithinGracePeriod", func(t *testing.T) {
+		t.Setenv("UNVERIFIED_LOGIN_GRACE_HOURS", "24")
+		register("grace@example.com")
+
+		helpers.AssertStatusCode(login("grace@example.com"), http.StatusOK)
+	})
+
+	t.Run("TokenIsSingleUse", func(t *testing.T) {
+		register("singleuse-verify@example.com")
+		token := helpers.ExtractEmailToken(helpers.LatestEmailTo("singleuse-verify@example.com"))
+
+		helpers.AssertStatusCode(verify(token), http.StatusOK)
+
+		resp := verify(token)
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid or expired verification token")
+	})
+
+	t.Run("ResendInvalidatesEarlierLinks", func(t *testing.T) {
+		register("resend@example.com")
+		first := helpers.ExtractEmailToken(helpers.LatestEmailTo("resend@example.com"))
+
+		resp, err := helpers.MakeRequest("POST", "/v1/users/verify/resend", map[string]interface{}{
+			"email": "resend@example.com",
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+		second := helpers.ExtractEmailToken(helpers.LatestEmailTo("resend@example.com"))
+
+		helpers.AssertStatusCode(verify(first), http.StatusBadRequest)
+		helpers.AssertStatusCode(verify(second), http.StatusOK)
+	})
+
+	t.Run("ResendUnknownEmail", func(t *testing.T) {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/verify/resend", map[string]interface{}{
+			"email": "unknown-verify@example.com",
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+		assert.Equal(t, 0, helpers.CountEmailsTo("unknown-verify@example.com"))
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		resp := verify("not-a-verification-token")
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid or expired verification token")
+	})
+
+	t.Run("MigrationVerifiesExistingUsers", func(t *testing.T) {
+		register("before-verification@example.com")
+
+		// go back to a database from before email verification existed
+		testDB := GetTestDB()
+		if err := testDB.Migrator().DropColumn(&models.User{}, "VerifiedAt"); err != nil {
+			t.Fatalf("Failed to drop verified_at: %v", err)
+		}
+		if err := models.MigrateUserVerification(context.Background()); err != nil {
+			t.Fatalf("Failed to migrate user verification: %v", err)
+		}
+
+		var user models.User
+		if err := testDB.Where("email = ?", "before-verification@example.com").First(&user).Error; err != nil {
+			t.Fatalf("Failed to find user: %v", err)
+		}
+		if assert.NotNil(t, user.VerifiedAt) {
+			assert.WithinDuration(t, user.CreatedAt, *user.VerifiedAt, time.Millisecond)
+		}
+		helpers.AssertStatusCode(login("before-verification@example.com"), http.StatusOK)
+
+		// running it again leaves users registered since unverified
+		register("after-verification@example.com")
+		if err := models.MigrateUserVerification(context.Background()); err != nil {
+			t.Fatalf("Failed to migrate user verification: %v", err)
+		}
+		helpers.AssertStatusCode(login("after-verification@example.com"), http.StatusForbidden)
+	})
+}
+
+// TestMFA tests TOTP enrollment, the two step login and disabling MFA
+func TestMFA(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	login := func(email string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	challenge := func(email string) string {
+		resp := login(email)
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var response models.MFAChallengeResponse
+		helpers.AssertJSONResponse(resp, &response)
+		assert.True(t, response.MFARequired)
+		assert.NotEmpty(t, response.ChallengeToken)
+		return response.ChallengeToken
+	}
+
+	loginMFA := func(challengeToken, code string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login/mfa", map[string]interface{}{
+			"challengeToken": challengeToken,
+			"code":           code,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	totpCode := func(secret string, at time.Time) string {
+		code, err := utils.TOTPCode(secret, at)
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		return code
+	}
+
+	// enableMFA enrolls the user and returns the secret and recovery codes
+	enableMFA := func(token string) (string, []string) {
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/enroll", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var enrollment models.MFAEnrollmentResponse
+		helpers.AssertJSONResponse(resp, &enrollment)
+		assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/")
+		assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/confirm", map[string]interface{}{
+			"code": totpCode(enrollment.Secret, time.Now()),
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var recovery models.RecoveryCodesResponse
+		helpers.AssertJSONResponse(resp, &recovery)
+		assert.Len(t, recovery.RecoveryCodes, 10)
+		return enrollment.Secret, recovery.RecoveryCodes
+	}
+
+	t.Run("LoginWithTOTP", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-totp@example.com", "Test User", TestPassword)
+		secret, _ := enableMFA(token)
+
+		// the code of the current step was used to confirm, use the next one which is still accepted
+		code := totpCode(secret, time.Now().Add(30*time.Second))
+
+		resp := loginMFA(challenge("mfa-totp@example.com"), code)
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		// a code can't be replayed
+		resp = loginMFA(challenge("mfa-totp@example.com"), code)
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(resp, "Invalid code")
+	})
+
+	t.Run("LoginWithRecoveryCode", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-recovery@example.com", "Test User", TestPassword)
+		_, recoveryCodes := enableMFA(token)
+
+		helpers.AssertStatusCode(loginMFA(challenge("mfa-recovery@example.com"), recoveryCodes[0]), http.StatusOK)
+
+		// recovery codes are single use
+		resp := loginMFA(challenge("mfa-recovery@example.com"), recoveryCodes[0])
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(resp, "Invalid code")
+	})
+
+	t.Run("ChallengeIsSingleUse", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-single@example.com", "Test User", TestPassword)
+		_, recoveryCodes := enableMFA(token)
+
+		challengeToken := challenge("mfa-single@example.com")
+		helpers.AssertStatusCode(loginMFA(challengeToken, recoveryCodes[0]), http.StatusOK)
+
+		resp := loginMFA(challengeToken, recoveryCodes[1])
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(resp, "Invalid or expired MFA challenge, please login again")
+	})
+
+	t.Run("ChallengeLockedAfterWrongCodes", func(t *testing.T) {
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "100")
+		_, token := helpers.CreateTestUser("mfa-attempts@example.com", "Test User", TestPassword)
+		_, recoveryCodes := enableMFA(token)
+
+		challengeToken := challenge("mfa-attempts@example.com")
+		for i := 0; i < 5; i++ {
+			helpers.AssertStatusCode(loginMFA(challengeToken, "000000"), http.StatusUnauthorized)
+		}
+
+		resp := loginMFA(challengeToken, recoveryCodes[0])
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+		helpers.AssertErrorResponse(resp, "Invalid or expired MFA challenge, please login again")
+	})
+
+	t.Run("WrongCodesLockTheAccount", func(t *testing.T) {
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "100")
+		t.Setenv("LOGIN_MAX_FAILURES", "3")
+		_, token := helpers.CreateTestUser("mfa-lockout@example.com", "Test User", TestPassword)
+		_, recoveryCodes := enableMFA(token)
+
+		challengeToken := challenge("mfa-lockout@example.com")
+		helpers.AssertStatusCode(loginMFA(challengeToken, "000000"), http.StatusUnauthorized)
+		helpers.AssertStatusCode(loginMFA(challengeToken, "000000"), http.StatusUnauthorized)
+
+		// the right password alone doesn't clear the wrong codes
+		helpers.AssertStatusCode(loginMFA(challenge("mfa-lockout@example.com"), "000000"), http.StatusUnauthorized)
+
+		helpers.AssertStatusCode(login("mfa-lockout@example.com"), http.StatusTooManyRequests)
+		resp := loginMFA(challengeToken, recoveryCodes[0])
+		helpers.AssertStatusCode(resp, http.StatusTooManyRequests)
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("EnrollWhenAlreadyEnabled", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-twice@example.com", "Test User", TestPassword)
+		enableMFA(token)
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/enroll", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+	})
+
+	t.Run("ConfirmWithInvalidCode", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-invalid@example.com", "Test User", TestPassword)
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/enroll", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/confirm", map[string]interface{}{
+			"code": "abcdef",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Invalid code")
+
+		// not enabled until confirmed
+		resp = login("mfa-invalid@example.com")
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("mfa-disable@example.com", "Test User", TestPassword)
+		enableMFA(token)
+
+		resp, err := helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/disable", map[string]interface{}{
+			"password": "WrongPassword123!",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Invalid password")
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/mfa/disable", map[string]interface{}{
+			"password": TestPassword,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		resp = login("mfa-disable@example.com")
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.NotEmpty(t, tokens.AccessToken, "Login should issue tokens once MFA is disabled")
+	})
+}
+
+// TestLoginLockout tests the brute force protection of POST /v1/users/login
+func TestLoginLockout(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    email,
+			"password": password,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("ProgressiveDelay", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "2")
+		t.Setenv("LOGIN_BASE_DELAY_SECONDS", "30")
+		helpers.CreateTestUser("delay@example.com", "Test User", TestPassword)
+
+		helpers.AssertStatusCode(login("delay@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		helpers.AssertStatusCode(login("delay@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+
+		// even the right password has to wait
+		resp := login("delay@example.com", TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusTooManyRequests)
+		helpers.AssertErrorResponse(resp, "Too many failed login attempts, please try again later")
+		assert.Equal(t, "30", resp.Header().Get("Retry-After"))
+
+		// the delay doubles with the next failure
+		GetTestDB().Model(&models.LoginAttempt{}).Where("key = ?", "account:delay@example.com").Update("blocked_until", nil)
+		helpers.AssertStatusCode(login("delay@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		resp = login("delay@example.com", TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusTooManyRequests)
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+
+		// once the delay is over the right password works and clears the failures
+		GetTestDB().Model(&models.LoginAttempt{}).Where("key = ?", "account:delay@example.com").Update("blocked_until", nil)
+		helpers.AssertStatusCode(login("delay@example.com", TestPassword), http.StatusOK)
+
+		var count int64
+		GetTestDB().Model(&models.LoginAttempt{}).Where("key = ?", "account:delay@example.com").Count(&count)
+		assert.Equal(t, int64(0), count, "Successful login should clear the failures of the account")
+	})
+
+	t.Run("AccountLockout", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "100")
+		t.Setenv("LOGIN_MAX_FAILURES", "3")
+		t.Setenv("LOGIN_LOCKOUT_MINUTES", "15")
+		helpers.CreateTestUser("locked@example.com", "Test User", TestPassword)
+
+		// the owner requested a reset before someone locked the account
+		resp, err := helpers.MakeRequest("POST", "/v1/users/password/forgot", map[string]interface{}{
+			"email": "locked@example.com",
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+		requested := helpers.ExtractEmailToken(helpers.LatestEmailTo("locked@example.com"))
+
+		for i := 0; i < 3; i++ {
+			helpers.AssertStatusCode(login("locked@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		}
+
+		resp = login("locked@example.com", TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusTooManyRequests)
+		assert.Equal(t, "900", resp.Header().Get("Retry-After"))
+
+		msg := helpers.LatestEmailTo("locked@example.com")
+		assert.Equal(t, "Your Konnect account has been locked", msg.Subject)
+		assert.NotEqual(t, requested, helpers.ExtractEmailToken(msg))
+
+		// resetting the password with the link the owner requested still works and unlocks the account
+		resp, err = helpers.MakeRequest("POST", "/v1/users/password/reset", map[string]interface{}{
+			"token":    requested,
+			"password": "NewPassword456!",
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+		helpers.AssertStatusCode(login("locked@example.com", "NewPassword456!"), http.StatusOK)
+	})
+
+	t.Run("UnknownEmailLockout", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "100")
+		t.Setenv("LOGIN_MAX_FAILURES", "3")
+
+		for i := 0; i < 3; i++ {
+			helpers.AssertStatusCode(login("nobody@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		}
+		helpers.AssertStatusCode(login("nobody@example.com", "WrongPassword123!"), http.StatusTooManyRequests)
+
+		var count int64
+		GetTestDB().Model(&models.PasswordResetToken{}).Count(&count)
+		assert.Equal(t, int64(0), count, "No reset link should be created without an account")
+		assert.Equal(t, 0, helpers.CountEmailsTo(""))
+		assert.Equal(t, 0, helpers.CountEmailsTo("nobody@example.com"))
+	})
+
+	t.Run("UnlocksAfterCoolDown", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_DELAY_AFTER_FAILURES", "100")
+		t.Setenv("LOGIN_MAX_FAILURES", "2")
+		helpers.CreateTestUser("cooldown@example.com", "Test User", TestPassword)
+
+		helpers.AssertStatusCode(login("cooldown@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		helpers.AssertStatusCode(login("cooldown@example.com", "WrongPassword123!"), http.StatusUnauthorized)
+		helpers.AssertStatusCode(login("cooldown@example.com", TestPassword), http.StatusTooManyRequests)
+
+		GetTestDB().Model(&models.LoginAttempt{}).Where("key = ?", "account:cooldown@example.com").
+			Update("locked_until", time.Now().Add(-time.Second))
+		helpers.AssertStatusCode(login("cooldown@example.com", TestPassword), http.StatusOK)
+	})
+
+	t.Run("IPLockout", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_MAX_IP_FAILURES", "3")
+		helpers.CreateTestUser("ip@example.com", "Test User", TestPassword)
+		emailsBefore := helpers.CountEmailsTo("ip@example.com")
+
+		// guessing different accounts from one IP
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			helpers.AssertStatusCode(login(email, "WrongPassword123!"), http.StatusUnauthorized)
+		}
+
+		resp := login("ip@example.com", TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusTooManyRequests)
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+		assert.Equal(t, emailsBefore, helpers.CountEmailsTo("ip@example.com"), "Accounts should not be emailed for an IP lockout")
+	})
+
+	t.Run("ForwardedForNotTrusted", func(t *testing.T) {
+		helpers.CleanupDatabase()
+		t.Setenv("LOGIN_MAX_IP_FAILURES", "3")
+		helpers.CreateTestUser("forwarded@example.com", "Test User", TestPassword)
+
+		// loginFrom claims another client IP, the request doesn't come from a trusted proxy
+		loginFrom := func(ip, email, password string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(map[string]interface{}{"email": email, "password": password})
+			req := httptest.NewRequest("POST", "/v1/users/login", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Forwarded-For", ip)
+			resp := httptest.NewRecorder()
+			GetTestRouter().ServeHTTP(resp, req)
+			return resp
+		}
+
+		for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			helpers.AssertStatusCode(loginFrom(fmt.Sprintf("203.0.113.%d", i+1), email, "WrongPassword123!"), http.StatusUnauthorized)
+		}
+
+		helpers.AssertStatusCode(loginFrom("203.0.113.99", "forwarded@example.com", TestPassword), http.StatusTooManyRequests)
+	})
+}
+
+// TestUserProfile tests the /v1/users/me endpoints
+func TestUserProfile(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	t.Run("GetAndUpdate", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("profile@example.com", "Test User", TestPassword)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/users/me", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var user models.User
+		helpers.AssertJSONResponse(resp, &user)
+		assert.Equal(t, "profile@example.com", user.Email)
+		assert.Equal(t, "Test User", user.Name)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PATCH", "/v1/users/me", map[string]interface{}{
+			"name": "Renamed User",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+		helpers.AssertJSONResponse(resp, &user)
+		assert.Equal(t, "Renamed User", user.Name)
+
+		resp, err = helpers.MakeAuthenticatedRequest("PATCH", "/v1/users/me", map[string]interface{}{
+			"name": "R",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+		helpers.AssertErrorResponse(resp, "Name should be between 2 to 100 characters")
+	})
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("change-password@example.com", "Test User", TestPassword)
+		const newPassword = "NewPassword456!"
+
+		// a refresh token of another login
+		resp, err := helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    "change-password@example.com",
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		var otherLogin models.TokenResponse
+		helpers.AssertJSONResponse(resp, &otherLogin)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/password", map[string]interface{}{
+			"currentPassword": "WrongPassword123!",
+			"newPassword":     newPassword,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+		helpers.AssertErrorResponse(resp, "Invalid password")
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/password", map[string]interface{}{
+			"currentPassword": TestPassword,
+			"newPassword":     "weak",
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusBadRequest)
+
+		resp, err = helpers.MakeAuthenticatedRequest("POST", "/v1/users/me/password", map[string]interface{}{
+			"currentPassword": TestPassword,
+			"newPassword":     newPassword,
+		}, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var tokens models.TokenResponse
+		helpers.AssertJSONResponse(resp, &tokens)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		refresh := func(refreshToken string) int {
+			resp, err := helpers.MakeRequest("POST", "/v1/users/token/refresh", map[string]interface{}{
+				"refreshToken": refreshToken,
+			})
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			return resp.Code
+		}
+		assert.Equal(t, http.StatusUnauthorized, refresh(otherLogin.RefreshToken), "Refresh tokens of other logins should be revoked")
+		assert.Equal(t, http.StatusOK, refresh(tokens.RefreshToken), "The returned refresh token should work")
+
+		resp, err = helpers.MakeRequest("POST", "/v1/users/login", map[string]interface{}{
+			"email":    "change-password@example.com",
+			"password": newPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_, token := helpers.CreateTestUser("delete-me@example.com", "Test User", TestPassword)
+		otherUser, otherToken := helpers.CreateTestUser("delete-other@example.com", "Other User", TestPassword)
+		org := helpers.CreateTestOrganization(token, "Solo Org", "Only one member")
+
+		deleteMe := func(token, password string) *httptest.ResponseRecorder {
+			resp, err := helpers.MakeAuthenticatedRequest("DELETE", "/v1/users/me", map[string]interface{}{
+				"password": password,
+			}, token)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			return resp
+		}
+
+		resp := deleteMe(token, "WrongPassword123!")
+		helpers.AssertStatusCode(resp, http.StatusForbidden)
+
+		resp = deleteMe(token, TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+		helpers.AssertErrorResponse(resp, "You are the only member of an organization, delete the organization before deleting your account")
+
+		// other members don't keep the organization alive without an owner
+		helpers.AddTestMember(org.ID, otherUser.ID, models.RoleAdmin)
+		resp = deleteMe(token, TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusConflict)
+		helpers.AssertErrorResponse(resp, "You are the only owner of an organization, transfer the ownership or delete the organization before deleting your account")
+
+		// with another owner the organization is left to them
+		GetTestDB().Model(&models.UserOrganizationMap{}).
+			Where("organization_id = ? AND user_id = ?", org.ID, otherUser.ID).
+			Update("role", models.RoleOwner)
+
+		resp = deleteMe(token, TestPassword)
+		helpers.AssertStatusCode(resp, http.StatusNoContent)
+
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/users/me", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs/"+org.ID, nil, otherToken)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		// the email can be used to register again
+		resp, err = helpers.MakeRequest("POST", "/v1/users/register", map[string]interface{}{
+			"email":    "delete-me@example.com",
+			"name":     "Test User",
+			"password": TestPassword,
+		})
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusAccepted)
+		assert.Contains(t, helpers.LatestEmailTo("delete-me@example.com").Subject, "Verify your Konnect account")
+	})
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/thilak009/kong-assignment/utils"
+)
+
+// writeTestKey writes a PKCS#8 PEM encoded private key to dir/<kid>.pem
+func writeTestKey(t *testing.T, dir, kid string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+}
+
+// useKeyRing points the key ring to the given directory and restores the default HS256 key ring after the test
+func useKeyRing(t *testing.T, dir, activeKID, retiredKeys string) {
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", activeKID)
+	t.Setenv("JWT_RETIRED_KEYS", retiredKeys)
+	if err := utils.InitKeyRing(); err != nil {
+		t.Fatalf("Failed to load key ring: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("JWT_KEYS_DIR")
+		utils.InitKeyRing()
+	})
+}
+
+// TestJWKS tests GET /.well-known/jwks.json and asymmetric token signing
+func TestJWKS(t *testing.T) {
+	helpers := NewTestHelpers(t)
+
+	// Clean database before and after test
+	helpers.CleanupDatabase()
+	t.Cleanup(func() {
+		helpers.CleanupDatabase()
+	})
+
+	t.Run("EmptyForHS256", func(t *testing.T) {
+		resp, err := helpers.MakeRequest("GET", "/.well-known/jwks.json", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var jwks utils.JWKS
+		helpers.AssertJSONResponse(resp, &jwks)
+		assert.Empty(t, jwks.Keys, "No keys should be published for HS256")
+	})
+
+	t.Run("RS256SignedTokenVerifiableWithJWKS", func(t *testing.T) {
+		dir := t.TempDir()
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		writeTestKey(t, dir, "rsa-1", rsaKey)
+		writeTestKey(t, dir, "ed-1", edKey)
+		useKeyRing(t, dir, "rsa-1", "ed-1="+time.Now().UTC().Format(time.RFC3339))
+
+		_, token := helpers.CreateTestUser("jwks@example.com", "Test User", TestPassword)
+
+		resp, err := helpers.MakeRequest("GET", "/.well-known/jwks.json", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		var jwks utils.JWKS
+		helpers.AssertJSONResponse(resp, &jwks)
+		assert.Len(t, jwks.Keys, 2, "Active and retired keys should be published")
+
+		var rsaJWK *utils.JWK
+		for i := range jwks.Keys {
+			if jwks.Keys[i].Kid == "rsa-1" {
+				rsaJWK = &jwks.Keys[i]
+			}
+		}
+		if rsaJWK == nil {
+			t.Fatalf("Active key missing from JWKS: %+v", jwks)
+		}
+		assert.Equal(t, "RSA", rsaJWK.Kty)
+		assert.Equal(t, "RS256", rsaJWK.Alg)
+
+		// Verify the token only with what is published in the JWKS
+		n, _ := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+		e, _ := base64.RawURLEncoding.DecodeString(rsaJWK.E)
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+		parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+			return publicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		assert.NoError(t, err, "Token should verify with the published key")
+		assert.Equal(t, "rsa-1", parsed.Header["kid"], "Token should carry the kid of the active key")
+	})
+
+	t.Run("RetiredKeyGracePeriod", func(t *testing.T) {
+		dir := t.TempDir()
+		_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+		_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+		writeTestKey(t, dir, "old", oldKey)
+		writeTestKey(t, dir, "new", newKey)
+		useKeyRing(t, dir, "old", "")
+
+		_, token := helpers.CreateTestUser("rotation@example.com", "Test User", TestPassword)
+
+		// Rotate, the old key is retired now but still within its grace period
+		useKeyRing(t, dir, "new", "old="+time.Now().UTC().Format(time.RFC3339))
+		resp, err := helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusOK)
+
+		// Retired long ago, grace period is over
+		useKeyRing(t, dir, "new", "old=2000-01-01T00:00:00Z")
+		resp, err = helpers.MakeAuthenticatedRequest("GET", "/v1/orgs", nil, token)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		helpers.AssertStatusCode(resp, http.StatusUnauthorized)
+	})
+
+	t.Run("RequiresRetirementTime", func(t *testing.T) {
+		dir := t.TempDir()
+		_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+		_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+		writeTestKey(t, dir, "old", oldKey)
+		writeTestKey(t, dir, "new", newKey)
+		t.Setenv("JWT_KEYS_DIR", dir)
+		t.Setenv("JWT_ACTIVE_KID", "new")
+		t.Setenv("JWT_RETIRED_KEYS", "")
+		t.Cleanup(func() {
+			os.Unsetenv("JWT_KEYS_DIR")
+			utils.InitKeyRing()
+		})
+
+		// a default retirement time would restart the grace period with every restart
+		assert.Error(t, utils.InitKeyRing(), "Retired keys without a retirement time must be rejected")
+	})
+
+	t.Run("RefusesDefaultSecretInProduction", func(t *testing.T) {
+		t.Setenv("ENV", "PRODUCTION")
+		t.Setenv("JWT_SECRET", "")
+		t.Setenv("JWT_KEYS_DIR", "")
+		t.Cleanup(func() {
+			utils.InitKeyRing()
+		})
+
+		assert.Error(t, utils.InitKeyRing(), "Default secret must be rejected in PRODUCTION")
+	})
+}
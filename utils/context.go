@@ -9,4 +9,14 @@ func GetUserID(c *gin.Context) string {
 		return ""
 	}
 	return userID.(string)
-}
\ No newline at end of file
+}
+
+// GetSessionID gets the ID of the session the access token of the request belongs to from the context,
+// empty for personal access tokens and tokens issued before sessions were introduced
+func GetSessionID(c *gin.Context) string {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return ""
+	}
+	return sessionID.(string)
+}
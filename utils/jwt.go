@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,11 +16,14 @@ import (
 type Claims struct {
 	UserID string `json:"userId"`
 	Email  string `json:"email"`
+	// SessionID is the login the token was issued for, tokens issued before sessions were introduced don't have it
+	SessionID string `json:"sid,omitempty"`
+	// Epoch is the token epoch of the user when the token was issued, bumping the epoch of the user
+	// invalidates all of their tokens at once
+	Epoch int `json:"epoch,omitempty"`
 	jwt.RegisteredClaims
 }
 
-var jwtSecret = []byte(GetEnv("JWT_SECRET", "your-secret-key"))
-
 func GetEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -25,27 +31,45 @@ func GetEnv(key, fallback string) string {
 	return fallback
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(userID, email string) (string, error) {
+// AccessTokenTTL returns the lifetime of an access token (default: 15 minutes),
+// clients are expected to use their refresh token to get a new one once it expires
+func AccessTokenTTL() time.Duration {
+	minutes, err := strconv.Atoi(GetEnv("ACCESS_TOKEN_TTL_MINUTES", "15"))
+	if err != nil || minutes < 1 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GenerateToken generates a JWT token for a user's session
+func GenerateToken(userID, email, sessionID string, epoch int) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
+		Epoch:     epoch,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	ring, err := getKeyRing()
+	if err != nil {
+		return "", err
+	}
+	return ring.sign(claims)
 }
 
 // ValidateToken validates a JWT token and returns the claims
 func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	ring, err := getKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ring.verificationKey, jwt.WithValidMethods(ring.validMethods()))
 
 	if err != nil {
 		return nil, err
@@ -60,9 +84,12 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 // GetTokenClaims extracts claims from a token without full validation (for logout)
 func GetTokenClaims(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	}, jwt.WithoutClaimsValidation())
+	ring, err := getKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ring.verificationKey, jwt.WithValidMethods(ring.validMethods()), jwt.WithoutClaimsValidation())
 
 	if err != nil {
 		return nil, err
@@ -80,3 +107,13 @@ func HashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return fmt.Sprintf("%x", hash)
 }
+
+// GenerateOpaqueToken generates a random URL safe token which carries no information by itself,
+// used for tokens that are looked up in the database by their hash (e.g. refresh tokens)
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultJWTSecret is used for HS256 signing when JWT_SECRET is not set, it must never be used in production
+const defaultJWTSecret = "your-secret-key"
+
+// SigningKey is a private key of the key ring along with the JWT signing method derived from its type
+type SigningKey struct {
+	KID    string
+	Method jwt.SigningMethod
+	Key    crypto.Signer
+	// RetiredAt is nil for the active key, retired keys are only used to verify tokens until the grace period ends
+	RetiredAt *time.Time
+}
+
+// KeyRing holds the keys used to sign and verify access tokens.
+//
+// When JWT_KEYS_DIR is set, every "<kid>.pem" file in the directory is loaded as a RSA or Ed25519 private key
+// and tokens are signed with RS256/EdDSA by the key named in JWT_ACTIVE_KID. All other keys in the directory
+// are retired, they keep validating tokens for JWT_KEY_GRACE_PERIOD_MINUTES after they were retired so that
+// tokens issued before a rotation do not fail. The retirement time of every retired key has to be set with
+// JWT_RETIRED_KEYS=kid1=2026-01-02T15:04:05Z,kid2=..., loading fails for retired keys without one.
+//
+// When JWT_KEYS_DIR is not set, tokens are signed with HS256 using JWT_SECRET (legacy mode).
+type KeyRing struct {
+	active      *SigningKey
+	keys        map[string]*SigningKey
+	gracePeriod time.Duration
+	hmacSecret  []byte
+}
+
+var (
+	keyRing    *KeyRing
+	keyRingErr error
+	keyRingMu  sync.RWMutex
+)
+
+// InitKeyRing (re)loads the key ring from the environment, it must be called once the environment is loaded.
+// Returns an error if the keys cannot be loaded or if the default secret would be used in PRODUCTION.
+func InitKeyRing() error {
+	ring, err := loadKeyRing()
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing, keyRingErr = ring, err
+	return err
+}
+
+// getKeyRing returns the loaded key ring, loading it from the environment on first use
+func getKeyRing() (*KeyRing, error) {
+	keyRingMu.RLock()
+	ring, err := keyRing, keyRingErr
+	keyRingMu.RUnlock()
+	if ring == nil && err == nil {
+		err = InitKeyRing()
+		keyRingMu.RLock()
+		ring = keyRing
+		keyRingMu.RUnlock()
+	}
+	return ring, err
+}
+
+func loadKeyRing() (*KeyRing, error) {
+	graceMinutes, err := strconv.Atoi(GetEnv("JWT_KEY_GRACE_PERIOD_MINUTES", "60"))
+	if err != nil || graceMinutes < 0 {
+		graceMinutes = 60
+	}
+	ring := &KeyRing{
+		keys:        map[string]*SigningKey{},
+		gracePeriod: time.Duration(graceMinutes) * time.Minute,
+	}
+
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+	if keysDir == "" {
+		secret := GetEnv("JWT_SECRET", defaultJWTSecret)
+		if secret == defaultJWTSecret && os.Getenv("ENV") == "PRODUCTION" {
+			return nil, errors.New("JWT_SECRET must be set or JWT_KEYS_DIR configured when running in PRODUCTION")
+		}
+		ring.hmacSecret = []byte(secret)
+		return ring, nil
+	}
+
+	retiredAt, err := parseRetiredKeys(os.Getenv("JWT_RETIRED_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys in %s: %w", keysDir, err)
+	}
+
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadSigningKey(file)
+		if err != nil {
+			return nil, err
+		}
+		key.KID = kid
+		if kid == activeKID {
+			ring.active = key
+		} else if t, ok := retiredAt[kid]; ok {
+			key.RetiredAt = &t
+		} else {
+			// defaulting to the start time would restart the grace period on every restart
+			return nil, fmt.Errorf("retired signing key %q has no retirement time, set it in JWT_RETIRED_KEYS", kid)
+		}
+		ring.keys[kid] = key
+	}
+
+	if ring.active == nil {
+		return nil, fmt.Errorf("active signing key %q (JWT_ACTIVE_KID) not found in %s", activeKID, keysDir)
+	}
+
+	return ring, nil
+}
+
+// parseRetiredKeys parses JWT_RETIRED_KEYS of the form kid1=RFC3339,kid2=RFC3339
+func parseRetiredKeys(value string) (map[string]time.Time, error) {
+	retiredAt := map[string]time.Time{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, at, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_RETIRED_KEYS entry %q, expected kid=RFC3339 timestamp", entry)
+		}
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retirement time for key %q: %w", kid, err)
+		}
+		retiredAt[kid] = t
+	}
+	return retiredAt, nil
+}
+
+// loadSigningKey reads a PKCS#8 (RSA or Ed25519) or PKCS#1 (RSA) PEM encoded private key
+func loadSigningKey(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", file, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM key %s", file)
+	}
+
+	var parsed interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", file, err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{Method: jwt.SigningMethodRS256, Key: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{Method: jwt.SigningMethodEdDSA, Key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T in %s, only RSA and Ed25519 keys are supported", parsed, file)
+	}
+}
+
+// sign signs the claims with the active key, or the HMAC secret in legacy mode
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	if r.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(r.hmacSecret)
+	}
+	token := jwt.NewWithClaims(r.active.Method, claims)
+	token.Header["kid"] = r.active.KID
+	return token.SignedString(r.active.Key)
+}
+
+// verificationKey is the jwt.Keyfunc used to pick the key a token must be verified with
+func (r *KeyRing) verificationKey(token *jwt.Token) (interface{}, error) {
+	if r.active == nil {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return r.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("signing method %s does not match key %q", token.Method.Alg(), kid)
+	}
+	if key.RetiredAt != nil {
+		if time.Now().After(key.RetiredAt.Add(r.gracePeriod)) {
+			return nil, fmt.Errorf("signing key %q is retired", kid)
+		}
+		// a retired key must not have signed anything after it was retired
+		if iat, err := token.Claims.GetIssuedAt(); err != nil || iat == nil || iat.After(*key.RetiredAt) {
+			return nil, fmt.Errorf("token was issued after signing key %q was retired", kid)
+		}
+	}
+	return key.Key.Public(), nil
+}
+
+func (r *KeyRing) validMethods() []string {
+	if r.active == nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// GetJWKS returns the public keys which can currently be used to verify access tokens,
+// the active key along with the retired keys that are still in their grace period
+func GetJWKS() (JWKS, error) {
+	ring, err := getKeyRing()
+	if err != nil {
+		return JWKS{}, err
+	}
+
+	jwks := JWKS{Keys: []JWK{}}
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key := ring.keys[kid]
+		if key.RetiredAt != nil && time.Now().After(key.RetiredAt.Add(ring.gracePeriod)) {
+			continue
+		}
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
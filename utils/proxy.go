@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies returns the IPs and CIDRs of the reverse proxies in TRUSTED_PROXIES (comma separated), the client
+// IP is only read from X-Forwarded-For and X-Real-IP when the request comes from one of them. None are trusted
+// by default, otherwise any client could pick the IP the login throttle counts its attempts against.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}